@@ -0,0 +1,151 @@
+package provider
+
+// Converts a document from a deprecated API version into the version replacing it, restructuring the fields which
+// changed between the two
+type apiConversion func(manifest map[any]any)
+
+// The conversions of the well-known deprecated API versions, keyed by `{apiVersion}/{kind}`
+var apiConversions = map[string]apiConversion{
+	"extensions/v1beta1/Ingress":                            convertIngress,
+	"networking.k8s.io/v1beta1/Ingress":                     convertIngress,
+	"apiextensions.k8s.io/v1beta1/CustomResourceDefinition": convertCustomResourceDefinition,
+}
+
+// Converts the document when it uses a deprecated API version with a known conversion, returning whether it did
+func convertDeprecatedAPIVersion(manifest map[any]any) bool {
+	conversion, ok := apiConversions[stringValue(manifest["apiVersion"])+"/"+stringValue(manifest["kind"])]
+	if !ok {
+		return false
+	}
+
+	conversion(manifest)
+	return true
+}
+
+// Converts an `Ingress` into `networking.k8s.io/v1`, where the backends reference services by a nested structure and
+// every path has a type
+func convertIngress(manifest map[any]any) {
+	manifest["apiVersion"] = "networking.k8s.io/v1"
+
+	spec, ok := manifest["spec"].(map[any]any)
+	if !ok {
+		return
+	}
+	if backend, ok := spec["backend"].(map[any]any); ok {
+		delete(spec, "backend")
+		spec["defaultBackend"] = convertIngressBackend(backend)
+	}
+
+	eachMap(spec["rules"], func(rule map[any]any) {
+		http, _ := rule["http"].(map[any]any)
+		eachMap(http["paths"], func(path map[any]any) {
+			if backend, ok := path["backend"].(map[any]any); ok {
+				path["backend"] = convertIngressBackend(backend)
+			}
+			if _, ok := path["pathType"]; !ok {
+				path["pathType"] = "ImplementationSpecific"
+			}
+		})
+	})
+}
+
+// Converts a backend referencing a service by `serviceName` and `servicePort` into one with a nested `service`
+func convertIngressBackend(backend map[any]any) map[any]any {
+	name, ok := backend["serviceName"]
+	if !ok {
+		return backend
+	}
+
+	port := map[any]any{}
+	switch servicePort := backend["servicePort"].(type) {
+	case string:
+		port["name"] = servicePort
+	case nil:
+	default:
+		port["number"] = servicePort
+	}
+
+	converted := map[any]any{"service": map[any]any{"name": name, "port": port}}
+	for key, value := range backend {
+		if key != "serviceName" && key != "servicePort" {
+			converted[key] = value
+		}
+	}
+	return converted
+}
+
+// Converts a `CustomResourceDefinition` into `apiextensions.k8s.io/v1`, where the schema, subresources, and printer
+// columns are set for each version, and every version must have a schema
+func convertCustomResourceDefinition(manifest map[any]any) {
+	manifest["apiVersion"] = "apiextensions.k8s.io/v1"
+
+	spec, ok := manifest["spec"].(map[any]any)
+	if !ok {
+		return
+	}
+
+	versions, _ := spec["versions"].([]any)
+	if version, ok := spec["version"]; ok {
+		if len(versions) == 0 {
+			versions = []any{map[any]any{"name": version, "served": true, "storage": true}}
+		}
+		delete(spec, "version")
+	}
+
+	// The fields shared by every version are moved into each version which doesn't have its own
+	shared := map[string]any{}
+	for field, versionField := range map[string]string{"validation": "schema", "subresources": "subresources", "additionalPrinterColumns": "additionalPrinterColumns"} {
+		if value, ok := spec[field]; ok {
+			shared[versionField] = value
+			delete(spec, field)
+		}
+	}
+
+	preserveUnknownFields, _ := spec["preserveUnknownFields"].(bool)
+	delete(spec, "preserveUnknownFields")
+
+	eachMap(versions, func(version map[any]any) {
+		for field, value := range shared {
+			if _, ok := version[field]; !ok {
+				version[field] = copyValue(value)
+			}
+		}
+
+		schema, _ := version["schema"].(map[any]any)
+		if schema == nil {
+			schema = map[any]any{}
+			version["schema"] = schema
+		}
+		// Versions without a schema keep accepting any field, as they did without one
+		root, _ := schema["openAPIV3Schema"].(map[any]any)
+		missing := root == nil
+		if missing {
+			root = map[any]any{"type": "object"}
+			schema["openAPIV3Schema"] = root
+		}
+		if preserveUnknownFields || missing {
+			root["x-kubernetes-preserve-unknown-fields"] = true
+		}
+
+		eachMap(version["additionalPrinterColumns"], func(column map[any]any) {
+			if jsonPath, ok := column["JSONPath"]; ok {
+				delete(column, "JSONPath")
+				column["jsonPath"] = jsonPath
+			}
+		})
+	})
+	spec["versions"] = versions
+
+	if conversion, ok := spec["conversion"].(map[any]any); ok && conversion["strategy"] == "Webhook" {
+		webhook := map[any]any{"conversionReviewVersions": []any{"v1beta1"}}
+		if clientConfig, ok := conversion["webhookClientConfig"]; ok {
+			webhook["clientConfig"] = clientConfig
+		}
+		if reviewVersions, ok := conversion["conversionReviewVersions"]; ok {
+			webhook["conversionReviewVersions"] = reviewVersions
+		}
+		delete(conversion, "webhookClientConfig")
+		delete(conversion, "conversionReviewVersions")
+		conversion["webhook"] = webhook
+	}
+}
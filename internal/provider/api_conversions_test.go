@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_ConvertDeprecatedAPIVersions(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(convertDeprecatedAPIVersionsStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: app
+spec:
+  rules:
+  - host: app.example.com
+    http:
+      paths:
+      - backend:
+          service:
+            name: app
+            port:
+              number: 80
+        path: /
+        pathType: ImplementationSpecific
+`)),
+			},
+		},
+	})
+}
+
+func TestConvertIngress(t *testing.T) {
+	ingress := map[any]any{
+		"apiVersion": "networking.k8s.io/v1beta1",
+		"kind":       "Ingress",
+		"spec": map[any]any{
+			"backend": map[any]any{"serviceName": "default", "servicePort": "http"},
+			"rules": []any{map[any]any{"http": map[any]any{"paths": []any{
+				map[any]any{"path": "/api", "pathType": "Prefix", "backend": map[any]any{"serviceName": "api", "servicePort": 8080}},
+			}}}},
+		},
+	}
+	if !convertDeprecatedAPIVersion(ingress) {
+		t.Fatal("expected the ingress to be converted")
+	}
+
+	expected := map[any]any{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"spec": map[any]any{
+			"defaultBackend": map[any]any{"service": map[any]any{"name": "default", "port": map[any]any{"name": "http"}}},
+			"rules": []any{map[any]any{"http": map[any]any{"paths": []any{
+				map[any]any{"path": "/api", "pathType": "Prefix", "backend": map[any]any{"service": map[any]any{"name": "api", "port": map[any]any{"number": 8080}}}},
+			}}}},
+		},
+	}
+	if !reflect.DeepEqual(ingress, expected) {
+		t.Errorf("expected %v, got %v", expected, ingress)
+	}
+	if convertDeprecatedAPIVersion(ingress) {
+		t.Error("expected a converted ingress to be left untouched")
+	}
+}
+
+func TestConvertCustomResourceDefinition(t *testing.T) {
+	definition := map[any]any{
+		"apiVersion": "apiextensions.k8s.io/v1beta1",
+		"kind":       "CustomResourceDefinition",
+		"spec": map[any]any{
+			"version":                  "v1",
+			"validation":               map[any]any{"openAPIV3Schema": map[any]any{"type": "object"}},
+			"subresources":             map[any]any{"status": map[any]any{}},
+			"additionalPrinterColumns": []any{map[any]any{"name": "Ready", "type": "string", "JSONPath": ".status.ready"}},
+			"conversion":               map[any]any{"strategy": "Webhook", "webhookClientConfig": map[any]any{"url": "https://example.com"}},
+		},
+	}
+	if !convertDeprecatedAPIVersion(definition) {
+		t.Fatal("expected the definition to be converted")
+	}
+
+	expected := map[any]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"spec": map[any]any{
+			"versions": []any{map[any]any{
+				"name":                     "v1",
+				"served":                   true,
+				"storage":                  true,
+				"schema":                   map[any]any{"openAPIV3Schema": map[any]any{"type": "object"}},
+				"subresources":             map[any]any{"status": map[any]any{}},
+				"additionalPrinterColumns": []any{map[any]any{"name": "Ready", "type": "string", "jsonPath": ".status.ready"}},
+			}},
+			"conversion": map[any]any{"strategy": "Webhook", "webhook": map[any]any{
+				"clientConfig":             map[any]any{"url": "https://example.com"},
+				"conversionReviewVersions": []any{"v1beta1"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(definition, expected) {
+		t.Errorf("expected %v, got %v", expected, definition)
+	}
+
+	schemaless := map[any]any{
+		"apiVersion": "apiextensions.k8s.io/v1beta1",
+		"kind":       "CustomResourceDefinition",
+		"spec":       map[any]any{"versions": []any{map[any]any{"name": "v1"}}},
+	}
+	convertDeprecatedAPIVersion(schemaless)
+	schema := firstVersionSchema(schemaless)
+	if expected := map[any]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true}; !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected a version without a schema to accept any field, got %v", schema)
+	}
+}
+
+// The schema of the first version of the definition
+func firstVersionSchema(definition map[any]any) any {
+	versions := definition["spec"].(map[any]any)["versions"].([]any)
+	return versions[0].(map[any]any)["schema"].(map[any]any)["openAPIV3Schema"]
+}
+
+const convertDeprecatedAPIVersionsStatement = `
+data "manifest_fetch" "test" {
+	url                             = "%s/ingress"
+	convert_deprecated_api_versions = true
+}
+`
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Rewrites the `apiVersion` of documents, keyed by resource patterns in the format `{apiVersion}/{kind}`
+type apiVersionOverrides struct {
+	patterns []string
+	versions []string
+}
+
+// Compiles the API versions into overrides, failing with the key of the first invalid entry. Nil overrides are
+// returned when there are no API versions.
+func compileAPIVersionOverrides(versions map[string]string) (*apiVersionOverrides, string, error) {
+	if len(versions) == 0 {
+		return nil, "", nil
+	}
+
+	patterns := make([]string, 0, len(versions))
+	for pattern := range versions {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	overrides := &apiVersionOverrides{}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+			return nil, pattern, fmt.Errorf("%q is not a valid resource pattern, must be in the format {apiVersion}/{kind}", pattern)
+		}
+
+		version := versions[pattern]
+		if parts := strings.Split(version, "/"); len(parts) > 2 || contains(parts, "") {
+			return nil, pattern, fmt.Errorf("%q is not a valid apiVersion, must be in the format {group}/{version} or {version}", version)
+		}
+
+		overrides.patterns = append(overrides.patterns, pattern)
+		overrides.versions = append(overrides.versions, version)
+	}
+	return overrides, "", nil
+}
+
+// Rewrites the API version of the document using the first matching pattern in lexical order, returning whether it
+// changed
+func (o *apiVersionOverrides) apply(manifest map[any]any) bool {
+	if o == nil {
+		return false
+	}
+
+	apiVersion, kind := stringValue(manifest["apiVersion"]), stringValue(manifest["kind"])
+	for i, pattern := range o.patterns {
+		if !matchResource(pattern, apiVersion, kind) {
+			continue
+		}
+
+		if apiVersion == o.versions[i] {
+			return false
+		}
+		manifest["apiVersion"] = o.versions[i]
+		return true
+	}
+	return false
+}
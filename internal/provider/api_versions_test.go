@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_APIVersionOverrides(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(apiVersionOverridesStatement, server.URL, "testing.k8s.io/v1/Test", "testing.k8s.io/v2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v2\nkind: Test\nstatus: hello\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument2)),
+			},
+			{
+				Config:      fmt.Sprintf(apiVersionOverridesStatement, server.URL, "Test", "testing.k8s.io/v2"),
+				ExpectError: regexp.MustCompile(`must be in the format {apiVersion}/{kind}`),
+			},
+			{
+				Config:      fmt.Sprintf(apiVersionOverridesStatement, server.URL, "testing.k8s.io/v1/Test", "testing.k8s.io/"),
+				ExpectError: regexp.MustCompile(`is not a valid apiVersion`),
+			},
+		},
+	})
+}
+
+func TestAPIVersionOverrides(t *testing.T) {
+	overrides, _, err := compileAPIVersionOverrides(map[string]string{
+		"policy/v1beta1/PodDisruptionBudget": "policy/v1",
+		"*/PodDisruptionBudget":              "policy/v2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	budget := map[any]any{"apiVersion": "policy/v1beta1", "kind": "PodDisruptionBudget"}
+	if !overrides.apply(budget) {
+		t.Error("expected the budget to be modified")
+	}
+	if budget["apiVersion"] != "policy/v2" {
+		t.Errorf("expected the first pattern in lexical order to be used, got %v", budget["apiVersion"])
+	}
+	if overrides.apply(budget) {
+		t.Error("expected rewriting to the same version to change nothing")
+	}
+	if overrides.apply(map[any]any{"apiVersion": "v1", "kind": "Service"}) {
+		t.Error("expected other kinds to be left untouched")
+	}
+}
+
+const apiVersionOverridesStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/multiple"
+	api_version_overrides = {
+		%q = %q
+	}
+}
+`
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The offset of the magic bytes within the header of a tarball
+const tarMagicOffset = 257
+
+// A file extracted from an artifact or archive
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+func isGzip(content []byte) bool {
+	return len(content) > 2 && content[0] == 0x1f && content[1] == 0x8b
+}
+
+func isZip(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
+
+// Whether the content is an archive that manifests can be extracted from
+func isArchive(content []byte) bool {
+	return isZip(content) || isTarball(content)
+}
+
+// Whether the content is a tarball, optionally gzip compressed
+func isTarball(content []byte) bool {
+	if isGzip(content) {
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return false
+		}
+		defer gz.Close()
+
+		header := make([]byte, tarMagicOffset+5)
+		if _, err := io.ReadFull(gz, header); err != nil {
+			return false
+		}
+		content = header
+	}
+
+	return len(content) >= tarMagicOffset+5 && string(content[tarMagicOffset:tarMagicOffset+5]) == "ustar"
+}
+
+// Extracts the regular files matching the predicate from a tarball in lexical order
+func extractTar(content []byte, compressed bool, match func(name string) bool) ([]archiveFile, error) {
+	var reader io.Reader = bytes.NewReader(content)
+	if compressed {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var files []archiveFile
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if header.Typeflag != tar.TypeReg || !match(name) {
+			continue
+		}
+
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: name, content: content})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// Extracts the regular files matching the predicate from a zip archive in lexical order
+func extractZip(content []byte, match func(name string) bool) ([]archiveFile, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []archiveFile
+	for _, entry := range archive.File {
+		name := path.Clean(strings.TrimPrefix(entry.Name, "./"))
+		if !entry.Mode().IsRegular() || !match(name) {
+			continue
+		}
+
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: name, content: content})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// Extracts the files matching the glob from the archive, defaulting to every manifest file when no glob is provided
+func extractArchive(content []byte, glob string) ([]archiveFile, error) {
+	match := hasManifestExtension
+	if glob != "" {
+		if _, err := path.Match(strings.ReplaceAll(glob, "**", "*"), ""); err != nil {
+			return nil, err
+		}
+		match = func(name string) bool { return globMatch(glob, name) }
+	}
+
+	if isZip(content) {
+		return extractZip(content, match)
+	}
+	return extractTar(content, isGzip(content), match)
+}
+
+func hasManifestExtension(name string) bool {
+	return contains(manifestExtensions, strings.ToLower(path.Ext(name)))
+}
+
+// Matches a slash-separated path against a shell pattern, where a `**` segment matches zero or more directories
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// Joins the contents of the extracted files into a single multi-document stream
+func joinArchiveFiles(files []archiveFile) []byte {
+	documents := make([][]byte, 0, len(files))
+	for _, file := range files {
+		documents = append(documents, file.content)
+	}
+	return joinDocuments(documents)
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Tarball(t *testing.T) {
+	server := setupArchiveServer(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "release.tar.gz"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument2),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Tarball_FileGlob(t *testing.T) {
+	server := setupArchiveServer(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(fileGlobResourceStatement, server.URL, "release.tar.gz", "deploy/**/*.yaml"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument2),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.deploy/base/a.yaml.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.deploy/overlay/b.yaml.0", multipleDocument2),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Tarball_InvalidFileGlob(t *testing.T) {
+	server := setupArchiveServer(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(fileGlobResourceStatement, server.URL, "release.tar.gz", "deploy/["),
+				ExpectError: regexp.MustCompile("Error extracting archive"),
+			},
+		},
+	})
+}
+
+func TestDataSource_Zip_FileGlob(t *testing.T) {
+	server := setupArchiveServer(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "bundle.zip"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", singleDocument),
+				),
+			},
+			{
+				Config: fmt.Sprintf(fileGlobResourceStatement, server.URL, "bundle.zip", "deploy/overlay/*"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument2),
+				),
+			},
+		},
+	})
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"*.yaml", "crds.yaml", true},
+		{"*.yaml", "deploy/crds.yaml", false},
+		{"deploy/**/*.yaml", "deploy/crds.yaml", true},
+		{"deploy/**/*.yaml", "deploy/base/apps/app.yaml", true},
+		{"deploy/**/*.yaml", "other/app.yaml", false},
+		{"**", "any/path/at/all.txt", true},
+		{"**/kustomization.yaml", "kustomization.yaml", true},
+	}
+
+	for _, test := range tests {
+		if actual := globMatch(test.pattern, test.name); actual != test.matches {
+			t.Errorf("globMatch(%q, %q): expected %t, got %t", test.pattern, test.name, test.matches, actual)
+		}
+	}
+}
+
+// Serves a release tarball and zip archive, each containing manifests in nested directories alongside other files
+func setupArchiveServer(t *testing.T) *httptest.Server {
+	files := map[string]string{
+		"deploy/base/a.yaml":    multipleDocument1,
+		"deploy/overlay/b.yaml": multipleDocument2,
+		"examples/single.yml":   singleDocument,
+		"README.md":             "# Release\n",
+		"deploy/base/notes.txt": "Not a manifest\n",
+	}
+	tarball, bundle := mustTarGz(t, files), mustZip(t, files)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release.tar.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			_, _ = w.Write(tarball)
+		case "/bundle.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write(bundle)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func mustZip(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for name, content := range files {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+const fileGlobResourceStatement = `
+data "manifest_fetch" "test" {
+	url       = "%s/%s"
+	file_glob = "%s"
+}
+`
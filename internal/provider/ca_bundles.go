@@ -0,0 +1,32 @@
+package provider
+
+// Removes the CA bundles which are injected into the webhooks of a cluster at runtime, such as by the cainjector of
+// cert-manager, and would otherwise be reverted whenever the manifests are applied
+func stripCABundles(manifest map[any]any) bool {
+	var clientConfigs []map[any]any
+	switch stringValue(manifest["kind"]) {
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		eachMap(manifest["webhooks"], func(webhook map[any]any) {
+			if clientConfig, ok := webhook["clientConfig"].(map[any]any); ok {
+				clientConfigs = append(clientConfigs, clientConfig)
+			}
+		})
+	case "APIService":
+		if spec, ok := manifest["spec"].(map[any]any); ok {
+			clientConfigs = append(clientConfigs, spec)
+		}
+	case "CustomResourceDefinition":
+		if clientConfig, ok := lookupMap(manifest, "spec", "conversion", "webhook", "clientConfig"); ok {
+			clientConfigs = append(clientConfigs, clientConfig)
+		}
+	}
+
+	modified := false
+	for _, clientConfig := range clientConfigs {
+		if _, ok := clientConfig["caBundle"]; ok {
+			delete(clientConfig, "caBundle")
+			modified = true
+		}
+	}
+	return modified
+}
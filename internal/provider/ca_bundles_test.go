@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_StripCABundles(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(stripCABundlesStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", strings.Replace(webhookDocument, "    caBundle: Y2VydGlmaWNhdGU=\n", "", 1))),
+			},
+		},
+	})
+}
+
+func TestStripCABundles(t *testing.T) {
+	for name, manifest := range map[string]map[any]any{
+		"api service": {"kind": "APIService", "spec": map[any]any{"caBundle": "Y2E="}},
+		"conversion webhook": {"kind": "CustomResourceDefinition", "spec": map[any]any{"conversion": map[any]any{"webhook": map[any]any{
+			"clientConfig": map[any]any{"caBundle": "Y2E="},
+		}}}},
+		"mutating webhook": {"kind": "MutatingWebhookConfiguration", "webhooks": []any{map[any]any{"clientConfig": map[any]any{"caBundle": "Y2E="}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if !stripCABundles(manifest) {
+				t.Error("expected the CA bundle to be removed")
+			}
+			if stripCABundles(manifest) {
+				t.Error("expected stripping again to change nothing")
+			}
+		})
+	}
+
+	secret := map[any]any{"kind": "Secret", "data": map[any]any{"caBundle": "Y2E="}}
+	if stripCABundles(secret) {
+		t.Error("expected other kinds to be left untouched")
+	}
+}
+
+const stripCABundlesStatement = `
+data "manifest_fetch" "test" {
+	url              = "%s/webhook"
+	strip_ca_bundles = true
+}
+`
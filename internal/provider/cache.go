@@ -0,0 +1,464 @@
+package provider
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Headers which only affect how the content is requested, not which content is returned
+var cacheIgnoredHeaders = map[string]bool{
+	"If-None-Match":     true,
+	"If-Modified-Since": true,
+}
+
+type cacheOptionsKey struct{}
+
+// Per-request options controlling how the cache is used
+type cacheOptions struct {
+	// Serve the cached response regardless of its freshness
+	pin bool
+
+	// Additional values distinguishing the cache entry
+	triggers map[string]string
+}
+
+func withCacheOptions(ctx context.Context, options cacheOptions) context.Context {
+	return context.WithValue(ctx, cacheOptionsKey{}, options)
+}
+
+func cacheOptionsFrom(ctx context.Context) cacheOptions {
+	options, _ := ctx.Value(cacheOptionsKey{}).(cacheOptions)
+	return options
+}
+
+// An on-disk store of successful responses, keyed by the request URL and headers
+type diskCache struct {
+	dir string
+	ttl time.Duration
+
+	// Whether the freshness of responses is determined by their Cache-Control and Expires headers, falling back to
+	// the TTL when neither is present
+	honorCacheControl bool
+}
+
+func newDiskCache(dir string, ttl time.Duration, honorCacheControl bool) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &diskCache{dir, ttl, honorCacheControl}, nil
+}
+
+// Derives the file name for the request from its URL and headers
+func (c *diskCache) key(request *http.Request) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", request.Method, request.URL.String())
+
+	var names []string
+	for name := range request.Header {
+		if !cacheIgnoredHeaders[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s: %s\n", name, strings.Join(request.Header.Values(name), ", "))
+	}
+
+	triggers := cacheOptionsFrom(request.Context()).triggers
+	if len(triggers) > 0 {
+		names = names[:0]
+		for name := range triggers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		hash.Write([]byte("\n"))
+		for _, name := range names {
+			fmt.Fprintf(hash, "%q=%q\n", name, triggers[name])
+		}
+	}
+
+	return filepath.Join(c.dir, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// Retrieves the cached response for the request along with when it was stored, regardless of its freshness
+func (c *diskCache) get(request *http.Request) (*http.Response, time.Time, bool) {
+	path := c.key(request)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), request)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return response, info.ModTime(), true
+}
+
+// Whether the stored response can be served without contacting the origin, following RFC 7234 section 4.2 when
+// Cache-Control is honored
+func (c *diskCache) fresh(response *http.Response, storedAt time.Time) bool {
+	age := time.Since(storedAt)
+	if !c.honorCacheControl {
+		return age <= c.ttl
+	}
+
+	directives := parseCacheControl(response.Header)
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+
+	if seconds, err := strconv.Atoi(response.Header.Get("Age")); err == nil && seconds > 0 {
+		age += time.Duration(seconds) * time.Second
+	}
+
+	return age <= c.lifetime(response, directives, storedAt)
+}
+
+// Determines how long the response is fresh for
+func (c *diskCache) lifetime(response *http.Response, directives map[string]string, storedAt time.Time) time.Duration {
+	if maxAge, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+
+		// An invalid max-age must be treated as stale
+		return 0
+	}
+
+	if rawExpires := response.Header.Get("Expires"); rawExpires != "" {
+		expires, err := http.ParseTime(rawExpires)
+		if err != nil {
+			return 0
+		}
+
+		date, err := http.ParseTime(response.Header.Get("Date"))
+		if err != nil {
+			date = storedAt
+		}
+
+		return expires.Sub(date)
+	}
+
+	return c.ttl
+}
+
+// Whether the response may be stored at all
+func (c *diskCache) storable(response *http.Response) bool {
+	if !c.honorCacheControl {
+		return true
+	}
+
+	_, noStore := parseCacheControl(response.Header)["no-store"]
+	return !noStore
+}
+
+// Whether a stale response must not be served when it cannot be revalidated
+func (c *diskCache) mustRevalidate(response *http.Response) bool {
+	if !c.honorCacheControl {
+		return false
+	}
+
+	directives := parseCacheControl(response.Header)
+	_, mustRevalidate := directives["must-revalidate"]
+	_, noCache := directives["no-cache"]
+	return mustRevalidate || noCache
+}
+
+// Parses the Cache-Control header into its directives, lowercasing names and unquoting values
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+
+			directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+		}
+	}
+
+	return directives
+}
+
+// Stores the response for the request. The response body is consumed and must be replaced by the caller.
+func (c *diskCache) put(request *http.Request, response *http.Response, body []byte) error {
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.TransferEncoding = nil
+
+	var serialized bytes.Buffer
+	if err := response.Write(&serialized); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never observe a partial response
+	temp, err := os.CreateTemp(c.dir, ".partial-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(serialized.Bytes()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), c.key(request))
+}
+
+// Serves GET requests from the cache when possible, storing successful responses from the next transport
+type cachingTransport struct {
+	cache *diskCache
+	next  http.RoundTripper
+}
+
+func (t *cachingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet {
+		return t.next.RoundTrip(request)
+	}
+
+	cached, storedAt, ok := t.cache.get(request)
+	if ok && (cacheOptionsFrom(request.Context()).pin || t.cache.fresh(cached, storedAt)) {
+		return cached, nil
+	}
+
+	outgoing := request
+	if ok {
+		outgoing = revalidationRequest(request, cached)
+	}
+
+	response, err := t.next.RoundTrip(outgoing)
+	if err != nil {
+		// Stale content is better than no content unless the origin forbids it
+		if ok && !t.cache.mustRevalidate(cached) {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if ok && response.StatusCode == http.StatusNotModified && outgoing != request {
+		response.Body.Close()
+
+		// Refresh the stored response with the updated metadata
+		for name, values := range response.Header {
+			cached.Header[name] = values
+		}
+
+		body, err := io.ReadAll(cached.Body)
+		cached.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		t.store(request, cached, body)
+		return cached, nil
+	}
+	if ok {
+		cached.Body.Close()
+	}
+
+	if response.StatusCode != http.StatusOK || !t.cache.storable(response) {
+		return response, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(request, response, body)
+	return response, nil
+}
+
+// Stores the response, replacing its body so it can still be read by the caller
+func (t *cachingTransport) store(request *http.Request, response *http.Response, body []byte) {
+	// Failing to cache the response shouldn't fail the request
+	if err := t.cache.put(request, response, body); err != nil {
+		tflog.Warn(request.Context(), "Failed to cache response", map[string]any{"url": request.URL.String(), "error": err.Error()})
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+}
+
+// Creates a conditional request using the validators of the cached response. If the cached response has no
+// validators, the original request is used.
+func revalidationRequest(request *http.Request, cached *http.Response) *http.Request {
+	etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return request
+	}
+
+	conditional := request.Clone(request.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	return conditional
+}
+
+// Serves all requests from the cache, regardless of freshness, without ever contacting the origin
+type offlineTransport struct {
+	cache *diskCache
+}
+
+func (t *offlineTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if cached, _, ok := t.cache.get(request); ok {
+		return cached, nil
+	}
+
+	return nil, errors.New("not cached and the provider is in offline mode")
+}
+
+// Marks cached responses of sources which read multiple files, whose body is a tarball of the files
+const cachedFilesHeader = "X-Manifest-Files"
+
+// Retrieves the content of a source which doesn't use the provider's transport through the cache, keyed by its URL
+// as HTTP responses are. Like HTTP responses, fresh content is served from the cache, stale content is served when
+// the source fails, and in offline mode all content is served from the cache regardless of its freshness.
+func (f *fetcher) fetchCached(ctx context.Context, rawURL string, fetch func() (*fetchResponse, error)) (*fetchResponse, error) {
+	if f.provider == nil || f.provider.cache == nil {
+		return fetch()
+	}
+	cache := f.provider.cache
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		if f.provider.offline {
+			return nil, newFetchError("Error reading cache", "%s", err)
+		}
+		return fetch()
+	}
+
+	cached, storedAt, ok := cache.get(request)
+	var stale *fetchResponse
+	if ok {
+		response, err := readCachedResponse(cached)
+		if err == nil && (f.provider.offline || cacheOptionsFrom(ctx).pin || cache.fresh(cached, storedAt)) {
+			return response, nil
+		}
+		stale = response
+	}
+	if f.provider.offline {
+		return nil, newFetchError("Error reading cache", "not cached and the provider is in offline mode")
+	}
+
+	response, err := fetch()
+	if err != nil {
+		// Stale content is better than no content
+		if stale != nil {
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	if err := storeCachedResponse(cache, request, response); err != nil {
+		tflog.Warn(ctx, "Failed to cache response", map[string]any{"url": response.url, "error": err.Error()})
+	}
+	return response, nil
+}
+
+// Stores the response of a source as though it were an HTTP response, bundling its files into a tarball
+func storeCachedResponse(cache *diskCache, request *http.Request, response *fetchResponse) error {
+	stored := &http.Response{
+		StatusCode: http.StatusOK,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Request:    request,
+	}
+	if response.contentType != "" {
+		stored.Header.Set("Content-Type", response.contentType)
+	}
+
+	body := response.body
+	if response.files != nil {
+		var archive bytes.Buffer
+		writer := tar.NewWriter(&archive)
+		for _, file := range response.files {
+			if err := writer.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content))}); err != nil {
+				return err
+			}
+			if _, err := writer.Write(file.content); err != nil {
+				return err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		stored.Header.Set(cachedFilesHeader, "true")
+		body = archive.Bytes()
+	}
+
+	return cache.put(request, stored, body)
+}
+
+// Restores the response of a source from its cached HTTP response
+func readCachedResponse(cached *http.Response) (*fetchResponse, error) {
+	defer cached.Body.Close()
+	body, err := io.ReadAll(cached.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &fetchResponse{url: cached.Request.URL.Redacted(), contentType: cached.Header.Get("Content-Type")}
+	if cached.Header.Get(cachedFilesHeader) == "" {
+		response.body = body
+		return response, nil
+	}
+
+	reader := tar.NewReader(bytes.NewReader(body))
+	response.files = []archiveFile{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		response.files = append(response.files, archiveFile{name: header.Name, content: content})
+	}
+	response.body = joinArchiveFiles(response.files)
+	return response, nil
+}
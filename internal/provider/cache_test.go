@@ -0,0 +1,350 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestProvider_Cache(t *testing.T) {
+	var requests int64
+	server := setupCountingServer(&requests)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, t.TempDir(), "1h", server.URL+"/single", server.URL+"/single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.first", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&requests, 1, 1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_Expired(t *testing.T) {
+	var requests int64
+	server := setupCountingServer(&requests)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, t.TempDir(), "1ns", server.URL+"/single", server.URL+"/single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&requests, 2, -1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_MaxAge(t *testing.T) {
+	var full, notModified int64
+	server := setupCacheControlServer(&full, &notModified)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, t.TempDir(), "1ns", server.URL+"/max-age", server.URL+"/max-age"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&full, 1, 1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_NoStore(t *testing.T) {
+	var full, notModified int64
+	server := setupCacheControlServer(&full, &notModified)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, t.TempDir(), "1h", server.URL+"/no-store", server.URL+"/no-store"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&full, 2, -1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_Revalidate(t *testing.T) {
+	var full, notModified int64
+	server := setupCacheControlServer(&full, &notModified)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, t.TempDir(), "1h", server.URL+"/no-cache", server.URL+"/no-cache"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&full, 1, 1),
+					expectRequests(&notModified, 1, -1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_IgnoreCacheControl(t *testing.T) {
+	var full, notModified int64
+	server := setupCacheControlServer(&full, &notModified)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(strings.Replace(cachedResourceStatement, "ttl =", "honor_cache_control = false\n\t\tttl =", 1), t.TempDir(), "1h", server.URL+"/no-store", server.URL+"/no-store"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.0", singleDocument),
+					expectRequests(&full, 1, 1),
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_Cache_Pin(t *testing.T) {
+	var requests int64
+	server := setupCountingServer(&requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(pinnedCacheResourceStatement, dir, server.URL, "one"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					expectRequests(&requests, 1, 1),
+				),
+			},
+			{
+				Config: fmt.Sprintf(pinnedCacheResourceStatement, dir, server.URL, "one"),
+				Check:  expectRequests(&requests, 1, 1),
+			},
+			{
+				Config: fmt.Sprintf(pinnedCacheResourceStatement, dir, server.URL, "two"),
+				Check:  expectRequests(&requests, 2, 2),
+			},
+		},
+	})
+}
+
+func TestProvider_Offline(t *testing.T) {
+	var requests int64
+	server := setupCountingServer(&requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(cachedResourceStatement, dir, "1ns", server.URL+"/single", server.URL+"/single"),
+			},
+			{
+				PreConfig: func() { atomic.StoreInt64(&requests, 0) },
+				Config:    fmt.Sprintf(offlineResourceStatement, dir, server.URL+"/single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					expectRequests(&requests, 0, 0),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(offlineResourceStatement, dir, server.URL+"/uncached"),
+				ExpectError: regexp.MustCompile(`not cached and\s+the\s+provider\s+is\s+in\s+offline\s+mode`),
+			},
+		},
+	})
+}
+
+func TestProvider_Offline_NoCache(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "manifest" {
+	offline = true
+}
+
+data "manifest_fetch" "test" {
+	url = "http://localhost/single"
+}
+`,
+				ExpectError: regexp.MustCompile("Offline mode requires a cache"),
+			},
+		},
+	})
+}
+
+func TestFetchCached(t *testing.T) {
+	cache, err := newDiskCache(t.TempDir(), time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &providerData{cache: cache}
+	ctx := context.Background()
+
+	files := []archiveFile{{name: "a.yaml", content: []byte("kind: ConfigMap\n")}, {name: "nested/b.yaml", content: []byte("kind: Secret\n")}}
+	fetches := 0
+	fetch := func() (*fetchResponse, error) {
+		fetches++
+		return &fetchResponse{url: "oci://registry/manifests:v1", body: joinArchiveFiles(files), files: files}, nil
+	}
+
+	// Fresh content is served from the cache once it has been fetched
+	for i := 0; i < 2; i++ {
+		if _, err := newFetcher(provider).fetchCached(ctx, "oci://registry/manifests:v1", fetch); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected a single fetch, got %d", fetches)
+	}
+
+	// Offline, cached content is served along with its files, while anything else fails without being fetched
+	provider.offline = true
+	response, err := newFetcher(provider).fetchCached(ctx, "oci://registry/manifests:v1", fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(response.files, files) || string(response.body) != string(joinArchiveFiles(files)) {
+		t.Errorf("expected the cached files %v, got %v", files, response.files)
+	}
+
+	if _, err := newFetcher(provider).fetchCached(ctx, "oci://registry/manifests:v2", fetch); err == nil || !strings.Contains(err.Error(), "not cached and the provider is in offline mode") {
+		t.Errorf("expected an offline error, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("expected nothing to be fetched offline, got %d fetches", fetches)
+	}
+}
+
+// A server which counts the number of requests for the single document it serves
+func setupCountingServer(requests *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(requests, 1)
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+}
+
+// A server which responds with the Cache-Control directive in the path, counting full and not modified responses
+func setupCacheControlServer(full, notModified *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/no-cache":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"static"`)
+
+			if r.Header.Get("If-None-Match") == `"static"` {
+				atomic.AddInt64(notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		atomic.AddInt64(full, 1)
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+}
+
+// Ensures the number of requests made is within the bounds. Terraform may read data sources multiple times per
+// step, so a negative maximum is treated as unbounded.
+func expectRequests(requests *int64, min, max int64) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		actual := atomic.LoadInt64(requests)
+		if actual < min || (max >= 0 && actual > max) {
+			return fmt.Errorf("expected between %d and %d requests, got %d", min, max, actual)
+		}
+		return nil
+	}
+}
+
+const cachedResourceStatement = `
+provider "manifest" {
+	cache {
+		dir = "%s"
+		ttl = "%s"
+	}
+}
+
+data "manifest_fetch" "first" {
+	url = "%s"
+}
+
+data "manifest_fetch" "second" {
+	url = "%s"
+
+	depends_on = [data.manifest_fetch.first]
+}
+`
+
+const offlineResourceStatement = `
+provider "manifest" {
+	offline = true
+
+	cache {
+		dir = "%s"
+		ttl = "1ns"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "%s"
+}
+`
+
+const pinnedCacheResourceStatement = `
+provider "manifest" {
+	cache {
+		dir = "%s"
+		ttl = "1ns"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "%s/single"
+	pin = true
+
+	triggers = {
+		version = "%s"
+	}
+}
+`
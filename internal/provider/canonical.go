@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	// Long strings are never wrapped onto several lines, matching `kubectl`, since wrapping depends on the position of
+	// the string and makes textual diffs noisy
+	yaml.FutureLineWrap()
+}
+
+// Encodes the manifest as `kubectl` does, converting it to JSON before encoding it as YAML. Every key is a string, the
+// keys of every mapping are sorted, and values are quoted consistently, regardless of the style of the source.
+func marshalCanonical(manifest map[any]any) ([]byte, error) {
+	encoded, err := json.Marshal(stringKeyed(manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML, so it decodes into the same representation as the manifest
+	var decoded any
+	if err := yaml.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(decoded)
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Canonicalize(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(canonicalizeStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nspec:\n  items:\n  - a\n  - b\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	description := strings.Repeat("a long description ", 10)
+	encoded, err := marshalCanonical(map[any]any{
+		"kind":       "ConfigMap",
+		"apiVersion": "v1",
+		"metadata":   map[any]any{"name": "app", "annotations": map[any]any{"description": description}},
+		"data":       map[any]any{1: "one", "enabled": "true"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "apiVersion: v1\ndata:\n  \"1\": one\n  enabled: \"true\"\nkind: ConfigMap\nmetadata:\n  annotations:\n    description: '" + description + "'\n  name: app\n"
+	if string(encoded) != expected {
+		t.Errorf("expected %q, got %q", expected, encoded)
+	}
+}
+
+const canonicalizeStatement = `
+data "manifest_fetch" "test" {
+	url          = "%s/formatted"
+	canonicalize = true
+}
+`
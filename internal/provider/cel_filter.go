@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Selects documents with a CEL expression, which is given the document as `object` and must evaluate to a boolean
+type celFilter struct {
+	program cel.Program
+}
+
+// Compiles the expression into a filter, failing if it is malformed or doesn't evaluate to a boolean. A nil filter,
+// which allows every document, is returned when the expression is empty.
+func compileCELFilter(expression string) (*celFilter, error) {
+	if expression == "" {
+		return nil, nil
+	}
+
+	env, err := cel.NewEnv(cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if outputType := ast.OutputType(); !outputType.IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("the expression must evaluate to a bool, not %s", outputType)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &celFilter{program: program}, nil
+}
+
+// Evaluates the expression against the manifest. Unlike the other selectors, evaluation can fail, such as when a
+// field which doesn't exist is accessed without checking for it with `has`.
+func (f *celFilter) allows(manifest map[any]any) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+
+	result, _, err := f.program.Eval(map[string]any{"object": stringKeyed(manifest)})
+	if err != nil {
+		return false, fmt.Errorf("error evaluating cel_filter: %w", err)
+	}
+
+	allowed, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("error evaluating cel_filter: expected a bool, got %s", result.Type().TypeName())
+	}
+	return allowed, nil
+}
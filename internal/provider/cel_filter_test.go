@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_CELFilter(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(celFilterStatement, server.URL, "labeled", `object.kind == 'Deployment' && object.metadata.labels.component != 'pilot'`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", labeledDocument("gateway"))),
+			},
+			{
+				Config: fmt.Sprintf(celFilterStatement, server.URL, "labeled", `has(object.metadata.labels) && object.metadata.name.startsWith('p')`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", labeledDocument("pilot"))),
+			},
+			{
+				Config:      fmt.Sprintf(celFilterStatement, server.URL, "labeled", `object.metadata.labels.component == 'pilot'`),
+				ExpectError: regexp.MustCompile(`error evaluating cel_filter: no such key: labels`),
+			},
+		},
+	})
+}
+
+func TestDataSource_CELFilter_Invalid(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(celFilterStatement, "https://example.com", "manifest.yaml", `object.kind ==`),
+				ExpectError: regexp.MustCompile(`Invalid CEL filter`),
+			},
+			{
+				Config:      fmt.Sprintf(celFilterStatement, "https://example.com", "manifest.yaml", `size(object)`),
+				ExpectError: regexp.MustCompile(`must evaluate to a bool, not int`),
+			},
+		},
+	})
+}
+
+func TestCELFilter(t *testing.T) {
+	manifest := map[any]any{
+		"kind": "Service",
+		"spec": map[any]any{
+			"type":  "LoadBalancer",
+			"ports": []any{map[any]any{"port": 443}, map[any]any{"port": 80}},
+		},
+	}
+
+	tests := map[string]bool{
+		"object.spec.type == 'LoadBalancer'":           true,
+		"object.spec.ports.exists(p, p.port == 443)":   true,
+		"object.spec.ports.all(p, p.port > 100)":       false,
+		"has(object.spec.selector)":                    false,
+		"object.kind in ['Deployment', 'StatefulSet']": false,
+	}
+
+	for expression, expected := range tests {
+		filter, err := compileCELFilter(expression)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", expression, err)
+		}
+
+		actual, err := filter.allows(manifest)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", expression, err)
+		} else if actual != expected {
+			t.Errorf("%s: expected %t, got %t", expression, expected, actual)
+		}
+	}
+
+	filter, _ := compileCELFilter("object.spec.selector.app == 'test'")
+	if _, err := filter.allows(manifest); err == nil {
+		t.Error("expected accessing a missing field to fail")
+	}
+}
+
+const celFilterStatement = `
+data "manifest_fetch" "test" {
+	url        = "%s/%s"
+	cel_filter = "%s"
+}
+`
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Encodes the manifest as YAML by updating the node tree of the source document it was decoded from, so the comments
+// of the upstream document are kept alongside the values which remain. Values which were changed keep the comments of
+// the values they replaced, while keys which aren't in the source follow those which are, in lexical order.
+func marshalWithComments(manifest map[any]any, source []byte) ([]byte, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(source, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind != yamlv3.DocumentNode || len(document.Content) == 0 {
+		return marshalOrdered(manifest, source)
+	}
+
+	root, err := updateNode(document.Content[0], manifest)
+	if err != nil {
+		return nil, err
+	}
+	document.Content[0] = root
+
+	var encoded bytes.Buffer
+	encoder := yamlv3.NewEncoder(&encoded)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// Updates the node to represent the value, reusing the nodes of the values which are unchanged
+func updateNode(node *yamlv3.Node, value any) (*yamlv3.Node, error) {
+	switch value := value.(type) {
+	case map[any]any:
+		if node.Kind != yamlv3.MappingNode {
+			return replaceNode(node, value)
+		}
+
+		keys := make(map[string]any, len(value))
+		for key := range value {
+			keys[fmt.Sprint(key)] = key
+		}
+
+		content := make([]*yamlv3.Node, 0, 2*len(value))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			key, ok := keys[keyNode.Value]
+			if !ok {
+				continue
+			}
+			delete(keys, keyNode.Value)
+
+			updated, err := updateNode(valueNode, value[key])
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, keyNode, updated)
+		}
+
+		added := make([]string, 0, len(keys))
+		for name := range keys {
+			added = append(added, name)
+		}
+		sort.Strings(added)
+		for _, name := range added {
+			keyNode, err := newNode(keys[name])
+			if err != nil {
+				return nil, err
+			}
+			valueNode, err := newNode(value[keys[name]])
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, keyNode, valueNode)
+		}
+
+		node.Content = content
+		return node, nil
+
+	case []any:
+		if node.Kind != yamlv3.SequenceNode {
+			return replaceNode(node, value)
+		}
+
+		content := make([]*yamlv3.Node, len(value))
+		for i, item := range value {
+			original := originalNode(item, node.Content, i)
+			if original == nil {
+				created, err := newNode(item)
+				if err != nil {
+					return nil, err
+				}
+				content[i] = created
+				continue
+			}
+
+			updated, err := updateNode(original, item)
+			if err != nil {
+				return nil, err
+			}
+			content[i] = updated
+		}
+
+		node.Content = content
+		return node, nil
+
+	default:
+		if node.Kind == yamlv3.ScalarNode {
+			// Unchanged scalars keep their original style, such as their quoting
+			replacement, err := newNode(value)
+			if err != nil {
+				return nil, err
+			}
+			if replacement.Tag == node.ShortTag() && replacement.Value == node.Value {
+				return node, nil
+			}
+		}
+		return replaceNode(node, value)
+	}
+}
+
+// Finds the node of the item within the original sequence, matching mappings by their name as the items of lists of
+// containers, ports, or volumes may be removed or reordered, and everything else by its position
+func originalNode(item any, originals []*yamlv3.Node, index int) *yamlv3.Node {
+	if mapping, ok := item.(map[any]any); ok {
+		if name, ok := mapping["name"].(string); ok {
+			for _, original := range originals {
+				if original.Kind != yamlv3.MappingNode {
+					continue
+				}
+				for i := 0; i+1 < len(original.Content); i += 2 {
+					if original.Content[i].Value == "name" && original.Content[i+1].Value == name {
+						return original
+					}
+				}
+			}
+		}
+	}
+
+	if index < len(originals) {
+		return originals[index]
+	}
+	return nil
+}
+
+// Creates a node for the value which carries over the comments of the node it replaces
+func replaceNode(node *yamlv3.Node, value any) (*yamlv3.Node, error) {
+	replacement, err := newNode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	return replacement, nil
+}
+
+func newNode(value any) (*yamlv3.Node, error) {
+	var node yamlv3.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"gopkg.in/yaml.v2"
+)
+
+const commentedDocument = `# The application's deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app # renamed by the overlay
+  uid: "1234"
+spec:
+  # Scaled by the autoscaler
+  replicas: 1
+  template:
+    spec:
+      containers:
+        # The sidecar is removed
+        - name: sidecar
+          image: "proxy:1.0"
+        # The application itself
+        - name: app
+          image: "app:1.0" # pinned by the release
+`
+
+func TestDataSource_PreserveComments(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(preserveCommentsStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestMatchResourceAttr("data.manifest_fetch.test", "manifests.0", regexp.MustCompile(`(?s)^# The application's deployment\n.*# Scaled by the autoscaler\n  replicas: 1\n.*image: "app:1.0" # pinned by the release\n$`)),
+					resource.TestCheckNoResourceAttr("data.manifest_fetch.test", "manifests.0.uid"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(conflictingCommentsStatement, server.URL),
+				ExpectError: regexp.MustCompile("Only one of canonicalize or preserve_comments can be set"),
+			},
+		},
+	})
+}
+
+func TestMarshalWithComments(t *testing.T) {
+	var manifest map[any]any
+	if err := yaml.Unmarshal([]byte(commentedDocument), &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removes an attribute and a container, changes a value, and adds a label
+	metadata := manifest["metadata"].(map[any]any)
+	delete(metadata, "uid")
+	metadata["labels"] = map[any]any{"team": "platform"}
+	spec := manifest["spec"].(map[any]any)
+	spec["replicas"] = 3
+	podSpec := spec["template"].(map[any]any)["spec"].(map[any]any)
+	podSpec["containers"] = podSpec["containers"].([]any)[1:]
+
+	encoded, err := marshalWithComments(manifest, []byte(commentedDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `# The application's deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app # renamed by the overlay
+  labels:
+    team: platform
+spec:
+  # Scaled by the autoscaler
+  replicas: 3
+  template:
+    spec:
+      containers:
+        # The application itself
+        - name: app
+          image: "app:1.0" # pinned by the release
+`
+	if string(encoded) != expected {
+		t.Errorf("expected %q, got %q", expected, encoded)
+	}
+}
+
+func TestSpoolManifest_PreserveComments(t *testing.T) {
+	var manifest map[any]any
+	if err := yaml.Unmarshal([]byte(commentedDocument), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	delete(manifest["metadata"].(map[any]any), "uid")
+
+	for _, comments := range []bool{false, true} {
+		spool := newDocumentSpool(nil)
+		ref, err := spoolManifest(spool, manifest, []byte(commentedDocument), true, false, comments)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		encoded, err := spool.read(ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		kept := strings.Contains(encoded, "# Scaled by the autoscaler") && strings.Contains(encoded, "# pinned by the release")
+		if kept != comments {
+			t.Errorf("expected comments to be kept to be %t, got %q", comments, encoded)
+		}
+		spool.close()
+	}
+}
+
+const preserveCommentsStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/commented"
+	filtered_attributes = ["metadata.uid"]
+	preserve_comments   = true
+}
+`
+
+const conflictingCommentsStatement = `
+data "manifest_fetch" "test" {
+	url               = "%s/commented"
+	canonicalize      = true
+	preserve_comments = true
+}
+`
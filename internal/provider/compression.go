@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// Decompresses the content when it is gzip, zstd, bzip2, or xz encoded, as detected by the Content-Encoding of the
+// response or the magic bytes of the content. Uncompressed content is returned as-is.
+func decompress(content []byte, contentEncoding string) ([]byte, error) {
+	var reader io.Reader
+	var err error
+
+	switch encoding := strings.ToLower(strings.TrimSpace(contentEncoding)); {
+	case encoding == "zstd" || bytes.HasPrefix(content, zstdMagic):
+		var decoder *zstd.Decoder
+		decoder, err = zstd.NewReader(bytes.NewReader(content))
+		if err == nil {
+			defer decoder.Close()
+			reader = decoder
+		}
+	case encoding == "xz" || bytes.HasPrefix(content, xzMagic):
+		reader, err = xz.NewReader(bytes.NewReader(content))
+	case encoding == "bzip2" || isBzip2(content):
+		reader = bzip2.NewReader(bytes.NewReader(content))
+	case encoding == "gzip" || (isGzip(content) && !isTarball(content)):
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(bytes.NewReader(content))
+		if err == nil {
+			defer gz.Close()
+			reader = gz
+		}
+	default:
+		return content, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress content: %w", err)
+	}
+	return decompressed, nil
+}
+
+// Whether the content starts with the bzip2 magic bytes followed by a block size
+func isBzip2(content []byte) bool {
+	return len(content) > 3 && bytes.HasPrefix(content, []byte("BZh")) && content[3] >= '1' && content[3] <= '9'
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// singleDocument compressed with bzip2, since the standard library does not provide an encoder
+const bzip2SingleDocument = "QlpoOTFBWSZTWcje1aAAABzbgEAQQAGgUAUAP+/foCAAlQqPCJtI9I0yAPUENETaIj8qD1NDaT1Eob9UevF8sdiOiEQMA8efv75Ir7uHxh7dF47KCEJEnSMegSJi3rLJmZ631nl2kDh5sDgnrS2lFIrPqJKSQtZaLsp3zTxOu7bRlnh4oQGuqJYTEiQaNbM2/i7kinChIZG9q0A="
+
+func TestDataSource_Compressed(t *testing.T) {
+	server := setupCompressedServer(t)
+	defer server.Close()
+
+	for _, name := range []string{"multiple.yaml.gz", "multiple.yaml.zst", "multiple.yaml.xz", "manifests.tar.zst"} {
+		t.Run(name, func(t *testing.T) {
+			resource.UnitTest(t, resource.TestCase{
+				ProtoV6ProviderFactories: providerFactories(),
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, name),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataSource_Compressed_Bzip2(t *testing.T) {
+	server := setupCompressedServer(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "single.yaml.bz2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+// Serves multipleDocuments compressed with each supported encoding, signalling zstd through the Content-Encoding
+func setupCompressedServer(t *testing.T) *httptest.Server {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(multipleDocuments))
+	_ = gz.Close()
+
+	encoder, _ := zstd.NewWriter(nil)
+	defer encoder.Close()
+
+	var xzed bytes.Buffer
+	writer, err := xz.NewWriter(&xzed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = writer.Write([]byte(multipleDocuments))
+	_ = writer.Close()
+
+	var tarball bytes.Buffer
+	archive := tar.NewWriter(&tarball)
+	_ = archive.WriteHeader(&tar.Header{Name: "multiple.yaml", Mode: 0o644, Size: int64(len(multipleDocuments)), Typeflag: tar.TypeReg})
+	_, _ = archive.Write([]byte(multipleDocuments))
+	_ = archive.Close()
+
+	bzipped, _ := base64.StdEncoding.DecodeString(bzip2SingleDocument)
+
+	files := map[string][]byte{
+		"/multiple.yaml.gz":  gzipped.Bytes(),
+		"/multiple.yaml.zst": encoder.EncodeAll([]byte(multipleDocuments), nil),
+		"/multiple.yaml.xz":  xzed.Bytes(),
+		"/manifests.tar.zst": encoder.EncodeAll(tarball.Bytes(), nil),
+		"/single.yaml.bz2":   bzipped,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Path == "/multiple.yaml.zst" {
+			w.Header().Set("Content-Encoding", "zstd")
+		}
+		_, _ = w.Write(content)
+	}))
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"fmt"
+	"path"
+)
+
+// Removes containers from the pod spec of every workload by their name
+type containerRemover struct {
+	patterns []string
+}
+
+// Compiles the container name patterns into a remover, failing with the index of the first malformed pattern. A nil
+// remover is returned when there are no patterns.
+func compileContainerRemover(patterns []string) (*containerRemover, int, error) {
+	if len(patterns) == 0 {
+		return nil, 0, nil
+	}
+
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, i, fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+	}
+	return &containerRemover{patterns: patterns}, 0, nil
+}
+
+// Removes the matching containers and init containers from the workload, returning whether any were removed
+func (r *containerRemover) apply(manifest map[any]any) bool {
+	if r == nil {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil {
+		return false
+	}
+
+	modified := false
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, ok := spec[field].([]any)
+		if !ok {
+			continue
+		}
+
+		kept := make([]any, 0, len(containers))
+		for _, container := range containers {
+			if attributes, ok := container.(map[any]any); ok && r.matches(stringValue(attributes["name"])) {
+				continue
+			}
+			kept = append(kept, container)
+		}
+		if len(kept) == len(containers) {
+			continue
+		}
+
+		if len(kept) == 0 && field == "initContainers" {
+			delete(spec, field)
+		} else {
+			spec[field] = kept
+		}
+		modified = true
+	}
+	return modified
+}
+
+// Whether the container name matches any of the patterns
+func (r *containerRemover) matches(name string) bool {
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_RemoveContainers(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(removeContainersStatement, server.URL, "in*"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\nspec:\n  template:\n    spec:\n      containers:\n      - image: quay.io/jetstack/cert-manager-controller:v1.14.0\n        name: controller\n")),
+			},
+			{
+				Config:      fmt.Sprintf(removeContainersStatement, server.URL, "["),
+				ExpectError: regexp.MustCompile(`Invalid container pattern`),
+			},
+		},
+	})
+}
+
+func TestContainerRemover(t *testing.T) {
+	remover, _, err := compileContainerRemover([]string{"*-exporter", "istio-proxy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	daemonSet := map[any]any{"kind": "DaemonSet", "spec": map[any]any{"template": map[any]any{"spec": map[any]any{
+		"containers": []any{
+			map[any]any{"name": "agent"},
+			map[any]any{"name": "metrics-exporter"},
+			map[any]any{"name": "istio-proxy"},
+		},
+	}}}}
+	if !remover.apply(daemonSet) {
+		t.Error("expected the daemon set to be modified")
+	}
+	expected := []any{map[any]any{"name": "agent"}}
+	if containers := podSpec(daemonSet)["containers"]; !reflect.DeepEqual(containers, expected) {
+		t.Errorf("expected %v, got %v", expected, containers)
+	}
+	if remover.apply(daemonSet) {
+		t.Error("expected removing the containers again to change nothing")
+	}
+
+	if _, index, err := compileContainerRemover([]string{"agent", "[-"}); err == nil || index != 1 {
+		t.Errorf("expected the second pattern to be invalid, got %d: %v", index, err)
+	}
+}
+
+const removeContainersStatement = `
+data "manifest_fetch" "test" {
+	url               = "%s/workload"
+	remove_containers = [%q]
+}
+`
@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	crdsInclude = "include"
+	crdsOnly    = "only"
+	crdsExclude = "exclude"
+)
+
+var crdModes = []string{crdsInclude, crdsOnly, crdsExclude}
+
+// Selects documents by whether they're a `CustomResourceDefinition`, so the definitions can be applied before the
+// custom resources depending on them
+type crdSelector struct {
+	// Whether only definitions are selected, rather than everything but them
+	only bool
+}
+
+// Compiles the mode into a selector, failing if the mode is unknown. A nil selector, which allows every document, is
+// returned when definitions are included with everything else.
+func compileCRDSelector(mode string) (*crdSelector, error) {
+	switch mode {
+	case "", crdsInclude:
+		return nil, nil
+	case crdsOnly, crdsExclude:
+		return &crdSelector{only: mode == crdsOnly}, nil
+	default:
+		return nil, fmt.Errorf("%q must be one of: %s", mode, strings.Join(crdModes, ", "))
+	}
+}
+
+func (s *crdSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+
+	isDefinition := strings.HasPrefix(stringValue(manifest["apiVersion"]), "apiextensions.k8s.io/") && manifest["kind"] == "CustomResourceDefinition"
+	return isDefinition == s.only
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_CRDs(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(crdsStatement, server.URL, "only"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", definitionDocument)),
+			},
+			{
+				Config: fmt.Sprintf(crdsStatement, server.URL, "exclude"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", customResourceDocument)),
+			},
+			{
+				Config: fmt.Sprintf(crdsStatement, server.URL, "include"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+			},
+			{
+				Config:      fmt.Sprintf(crdsStatement, server.URL, "first"),
+				ExpectError: regexp.MustCompile(`Invalid CRD mode`),
+			},
+		},
+	})
+}
+
+func TestCRDSelector(t *testing.T) {
+	definition := map[any]any{"apiVersion": "apiextensions.k8s.io/v1beta1", "kind": "CustomResourceDefinition"}
+	resource := map[any]any{"apiVersion": "cert-manager.io/v1", "kind": "Certificate"}
+
+	only, _ := compileCRDSelector(crdsOnly)
+	if !only.allows(definition) || only.allows(resource) {
+		t.Error("expected only the definition to be selected")
+	}
+	exclude, _ := compileCRDSelector(crdsExclude)
+	if exclude.allows(definition) || !exclude.allows(resource) {
+		t.Error("expected everything but the definition to be selected")
+	}
+	if include, err := compileCRDSelector(""); include != nil || err != nil {
+		t.Errorf("expected no selector by default, got %v: %v", include, err)
+	}
+}
+
+const crdsStatement = `
+data "manifest_fetch" "test" {
+	url  = "%s/operator"
+	crds = %q
+}
+`
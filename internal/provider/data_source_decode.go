@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = (*decodeDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*decodeDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*decodeDataSource)(nil)
+
+func NewDecodeDataSource() datasource.DataSource {
+	return &decodeDataSource{}
+}
+
+type decodeDataSource struct {
+	provider *providerData
+}
+
+func (d *decodeDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_decode"
+}
+
+func (d *decodeDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *decodeDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Splits manifests from a string, such as the output of `templatefile` or of another resource, and optionally removes attributes from them, as the `manifest_fetch` data source does for fetched manifests. Unlike splitting on `---` in HCL, documents are split as YAML, so `---` within values and documents made up of comments are handled correctly.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"content": {
+				MarkdownDescription: "The manifests to decode, as YAML documents, a JSON object, or a JSON array of objects, detected from the content itself.",
+				Type:                types.StringType,
+				Required:            true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *decodeDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model decodeModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *decodeDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model decodeModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sources := []archiveFile{{content: []byte(model.Content.Value)}}
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, sources)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+type decodeModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Content            types.String `tfsdk:"content"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model decodeModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Decode(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: decodeStatement,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_decode.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_decode.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: script\ndata:\n  run.sh: |\n    echo start\n    ---\n    echo done\n"),
+					resource.TestCheckResourceAttr("data.manifest_decode.test", "manifests.1", "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"),
+				),
+			},
+		},
+	})
+}
+
+const decodeStatement = `
+data "manifest_decode" "test" {
+	content = <<-EOT
+		# Generated
+		---
+		apiVersion: v1
+		kind: ConfigMap
+		metadata:
+		  name: script
+		  uid: "1234"
+		data:
+		  run.sh: |
+		    echo start
+		    ---
+		    echo done
+		---
+		apiVersion: v1
+		kind: Secret
+		metadata:
+		  name: token
+		---
+		apiVersion: v1
+		kind: Service
+		metadata:
+		  name: web
+	EOT
+	filtered_attributes = ["metadata.uid"]
+	only_resources = ["v1/ConfigMap", "v1/Service"]
+}
+`
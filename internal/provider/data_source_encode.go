@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = (*encodeDataSource)(nil)
+
+func NewEncodeDataSource() datasource.DataSource {
+	return &encodeDataSource{}
+}
+
+type encodeDataSource struct{}
+
+func (d *encodeDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_encode"
+}
+
+func (d *encodeDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Encodes manifests written in HCL into a stream of YAML documents, the inverse of the `manifest_decode` data source. Manifests are encoded as `kubectl` does, and as the `manifest_fetch` data source does when `canonicalize` is set, so the result doesn't depend on how the manifests were written.",
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				Description: "A hash of the resulting content.",
+				Type:        types.StringType,
+				Computed:    true,
+			},
+			"manifests": {
+				MarkdownDescription: "The manifests to encode, in order, each encoded as JSON with `jsonencode` (e.g. `[for manifest in local.manifests : jsonencode(manifest)]`). Due to a limitation the Terraform Plugin Framework, objects can't be passed directly. YAML is accepted too, and each entry may contain several manifests, such as a `List` or several documents.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Required: true,
+			},
+			"content": {
+				MarkdownDescription: "The manifests as YAML documents separated by `---`, with the keys of every mapping sorted and values quoted consistently.",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+		},
+	}, nil
+}
+
+func (d *encodeDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model encodeModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var documents []string
+	for i, manifest := range parseTfList(ctx, model.Manifests, func(manifest string) string { return manifest }) {
+		encoded, err := encodeManifests([]byte(manifest))
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("manifests").AtListIndex(i), "Error encoding manifest", fmt.Sprintf("Error encoding manifest: %s", err))
+			return
+		}
+		documents = append(documents, encoded...)
+	}
+
+	content := strings.Join(documents, "---\n")
+	model.Content = types.String{Value: content}
+	model.ID = types.String{Value: sha256Hex([]byte(content))}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Decodes the manifests within the content, whether JSON or YAML, and encodes each of them canonically
+func encodeManifests(content []byte) ([]string, error) {
+	content, err := normalizeText(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []string
+	err = unmarshalAllManifests(content, formatAuto, nil, func(manifest map[any]any, _ []byte) error {
+		encoded, err := marshalCanonical(manifest)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, string(encoded))
+		return nil
+	})
+	return documents, err
+}
+
+type encodeModel struct {
+	ID        types.String `tfsdk:"id"`
+	Manifests types.List   `tfsdk:"manifests"`
+	Content   types.String `tfsdk:"content"`
+}
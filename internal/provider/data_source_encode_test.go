@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Encode(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: encodeStatement,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_encode.test", "content", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  labels:\n    app: web\n  name: web\n  namespace: app\nspec:\n  replicas: 2\n"),
+				),
+			},
+		},
+	})
+}
+
+const encodeStatement = `
+locals {
+	manifests = [
+		{
+			kind       = "Namespace"
+			apiVersion = "v1"
+			metadata   = { name = "app" }
+		},
+		{
+			apiVersion = "apps/v1"
+			kind       = "Deployment"
+			metadata = {
+				name      = "web"
+				namespace = "app"
+				labels    = { app = "web" }
+			}
+			spec = { replicas = 2 }
+		},
+	]
+}
+
+data "manifest_encode" "test" {
+	manifests = [for manifest in local.manifests : jsonencode(manifest)]
+}
+`
+
+func TestEncodeManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			content:  `{"metadata":{"name":"config"},"kind":"ConfigMap","apiVersion":"v1","data":{"enabled":"true","count":"1000000"}}`,
+			expected: []string{"apiVersion: v1\ndata:\n  count: \"1000000\"\n  enabled: \"true\"\nkind: ConfigMap\nmetadata:\n  name: config\n"},
+		},
+		{
+			name:     "numbers",
+			content:  `{"apiVersion":"apps/v1","kind":"Deployment","spec":{"replicas":1000000,"ratio":0.5}}`,
+			expected: []string{"apiVersion: apps/v1\nkind: Deployment\nspec:\n  ratio: 0.5\n  replicas: 1000000\n"},
+		},
+		{
+			name:     "yaml",
+			content:  "kind: Secret\napiVersion: v1\nmetadata: {name: token}\n---\nkind: Service\napiVersion: v1\n",
+			expected: []string{"apiVersion: v1\nkind: Secret\nmetadata:\n  name: token\n", "apiVersion: v1\nkind: Service\n"},
+		},
+		{
+			name:     "list",
+			content:  `{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"ConfigMap"},{"apiVersion":"v1","kind":"Secret"}]}`,
+			expected: []string{"apiVersion: v1\nkind: ConfigMap\n", "apiVersion: v1\nkind: Secret\n"},
+		},
+		{
+			name:    "invalid",
+			content: `{"apiVersion":`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			documents, err := encodeManifests([]byte(test.content))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", documents)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(documents, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, documents)
+			}
+		})
+	}
+}
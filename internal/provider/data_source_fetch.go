@@ -1,61 +1,405 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"gopkg.in/yaml.v2"
 )
 
 var _ datasource.DataSource = (*fetchDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*fetchDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*fetchDataSource)(nil)
 
 func NewFetchDataSource() datasource.DataSource {
 	return &fetchDataSource{}
 }
 
-type fetchDataSource struct{}
+type fetchDataSource struct {
+	provider *providerData
+}
 
 func (d *fetchDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_fetch"
 }
 
+func (d *fetchDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
 func (d *fetchDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return fetchSchema(), nil
+}
+
+// The schema shared between the data source and the resource
+func fetchSchema() tfsdk.Schema {
 	return tfsdk.Schema{
-		MarkdownDescription: "Fetches and optionally removes attributes from the retrieved manifest(s). The server must return with a 200 status code.",
+		MarkdownDescription: "Fetches and optionally removes attributes from the retrieved manifest(s). The server must return with a 200 status code. Manifests may be YAML documents, JSON objects, JSON arrays of objects, or newline-delimited JSON, and content compressed with gzip, zstd, bzip2, or xz is decompressed automatically.",
 		Attributes: map[string]tfsdk.Attribute{
 			"id": {
-				Description: "The URL used for the request.",
+				Description: "A hash of the URL, the digest of the fetched content, and the attributes which filter or transform it. It changes whenever the content or how it is filtered changes, but not with attributes which only affect how it is fetched, such as `parallelism`.",
+				Type:        types.StringType,
+				Computed:    true,
+			},
+			"content_sha256": {
+				Description: "The hex-encoded SHA-256 digest of the fetched content, prior to any filtering.",
 				Type:        types.StringType,
 				Computed:    true,
 			},
 			"url": {
-				Description: "The URL for the manifest. Supported schemes are `http` and `https`.",
+				MarkdownDescription: "The URL for the manifest. Supported schemes are `http`, `https`, `file`, `oci` (e.g. `oci://ghcr.io/example/manifests:v1.0.0`), `s3` (e.g. `s3://bucket/key?region=us-west-2`, using the default AWS credential chain; `endpoint` and `version_id` may also be set as query parameters), `gs` (e.g. `gs://bucket/object`, using Application Default Credentials; `endpoint` and `generation` may also be set as query parameters), `azblob` (e.g. `azblob://account/container/blob`, using a SAS token from the query parameters or DefaultAzureCredential; `endpoint` may also be set as a query parameter), and `sftp` (e.g. `sftp://user@files.example.com/manifests/app.yaml`, using the provider's `sftp_auth` or the SSH agent). Conflicts with `urls`, `path`, `git`, `github_release`, `cluster`, and `crawl`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"path": {
+				Description: "The path to a local manifest file, relative to the working directory. Conflicts with `url`, `urls`, `git`, `github_release`, `cluster`, and `crawl`.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"urls": {
+				MarkdownDescription: "Multiple URLs whose manifests are combined, in order, into a single set. Each URL supports the same schemes as `url`, and `manifests_by_file` is keyed by URL. Conflicts with `url`, `path`, `git`, `github_release`, `cluster`, and `crawl`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"fallback_urls": {
+				Description: "Additional URLs to try, in order, when the primary URL fails. Useful for pointing at mirrors of the primary URL.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"hedge_delay": {
+				Description: "When set, the fallback URLs are requested in parallel, each one starting after the previous request has been outstanding for this duration (e.g. `500ms`). The first successful response is used.",
 				Type:        types.StringType,
-				Required:    true,
+				Optional:    true,
+			},
+			"parallelism": {
+				MarkdownDescription: "The maximum number of URLs, assets, or files to fetch and decode at once when there are several of them, such as with `urls` or an archive. Defaults to `4`.",
+				Type:                types.Int64Type,
+				Optional:            true,
+			},
+			"chunk_size": {
+				MarkdownDescription: "The number of manifests in each chunk of `manifest_chunks`. Unless set, `manifest_chunks` is empty.",
+				Type:                types.Int64Type,
+				Optional:            true,
+			},
+			"triggers": {
+				MarkdownDescription: "Arbitrary values which, when changed, force the content to be fetched again. For the data source, this only has an effect when the provider `cache` is configured, as the values are part of the cache key, and a warning is raised otherwise.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"pin": {
+				MarkdownDescription: "Only fetch the content again when the configuration or `triggers` change. The resource skips refreshing entirely, while the data source serves the content from the provider `cache` regardless of its freshness, warning when there is no `cache`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"file_glob": {
+				MarkdownDescription: "When the retrieved content is a zip archive or a tarball, optionally compressed, only the files whose path within the archive matches the glob are decoded, in lexical order. A `**` segment matches any number of directories (e.g. `deploy/**/*.yaml`). Defaults to every `.yaml`, `.yml`, and `.json` file.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"body_encoding": {
+				MarkdownDescription: "The encoding the manifests are wrapped in, decoded before any decompression or parsing. The only supported encoding is `base64`, which also accepts JSON objects with base64 `content` as returned by the GitHub contents API.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"format": {
+				MarkdownDescription: "The format of the manifests, either `yaml`, `json`, or `auto`. When `auto`, the format is selected by the extension of files within archives or directories, then by the `Content-Type` of the response, falling back to detecting it from the content itself. Defaults to `auto`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"variables": {
+				MarkdownDescription: "Values substituted for the `envsubst`-style placeholders within the fetched content before it is decoded, keyed by the name of their variable. Placeholders are written as `${NAME}`, or as `${NAME:-default}` or `${NAME:=default}` to use a default when the variable isn't set. Placeholders of variables which aren't set and have no default are left untouched. Values are substituted as-is, so they must be quoted within the placeholder's document when they aren't valid YAML by themselves.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"canonicalize": {
+				MarkdownDescription: "Re-encode every manifest as `kubectl` does, with the keys of every mapping sorted, values quoted consistently, and long strings never wrapped, so the content and its digest don't depend on the formatting of the upstream documents. Otherwise, untouched YAML documents are passed through as-is, preserving their formatting and comments, while the modified manifests keep the order of their keys.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"preserve_comments": {
+				MarkdownDescription: "Keep the comments of the upstream YAML documents in the manifests modified by transformations, by updating the document's node tree rather than re-encoding the manifest. Comments stay attached to the values which remain, including those whose values were changed, while comments of removed values are dropped. The indentation of modified manifests may still change. Untouched documents are passed through as-is regardless. Conflicts with `canonicalize`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"order": {
+				MarkdownDescription: "The order of the resulting manifests, either `document` or `dependency`. When `document`, the manifests are in the order of the documents they were decoded from. When `dependency`, they are sorted so each comes after those it depends on, such as `Namespace` and `CustomResourceDefinition` manifests first, followed by service accounts and RBAC, then workloads, and webhook configurations last, which avoids failures on the first apply when the manifests are applied in order. Manifests of the same kind keep the order of their documents. Defaults to `document`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"sort_by": {
+				MarkdownDescription: "The fields to sort the resulting manifests by, in turn, out of `kind`, `name`, and `namespace`, so the order doesn't change when the upstream documents are reordered. Manifests without a name or namespace sort first. When `order` is `dependency`, the manifests of each step of the dependency order are sorted by the fields. Manifests which are equal in every field keep the order of their documents.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"deduplicate": {
+				MarkdownDescription: "Remove the manifests which are identical to an earlier one, which happens when combining overlapping files, and decide what happens to the manifests with the same `apiVersion`, `kind`, namespace, and name but different content. Either `exact`, which keeps every one of them, `keep_last`, which only keeps the last of them, or `error`, which fails the fetch. Every manifest is kept when unset.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"fail_on_duplicates": {
+				MarkdownDescription: "Fail when several manifests have the same `apiVersion`, `kind`, namespace, and name but different content, which usually means the bundle is broken and would otherwise only be noticed when applying it. Identical manifests are allowed, and the check happens after `deduplicate` is applied.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"crds": {
+				MarkdownDescription: "Whether `CustomResourceDefinition` manifests are returned, either `include`, which returns them along with every other manifest, `only`, which only returns them, or `exclude`, which returns every other manifest. Useful for applying the definitions of an operator, such as cert-manager or the Prometheus operator, in an earlier stage than the custom resources depending on them. Defaults to `include`.",
+				Type:                types.StringType,
+				Optional:            true,
 			},
 			"filtered_attributes": {
-				Description: "The attributes to remove from the manifest.",
+				MarkdownDescription: "The dot-separated paths of the attributes to remove from the manifest, such as `metadata.uid`. Elements of lists are selected by index or with a wildcard, such as `spec.containers[0].resources` or `spec.containers[*].imagePullPolicy`. Keys containing dots are quoted, such as `metadata.annotations.\"kubectl.kubernetes.io/last-applied-configuration\"`, or have their dots escaped with a backslash.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"strip_server_fields": {
+				MarkdownDescription: "Remove the attributes populated by the API server from every manifest: `status`, `metadata.creationTimestamp`, `metadata.uid`, `metadata.resourceVersion`, `metadata.generation`, and `metadata.managedFields`. Applied in addition to `filtered_attributes`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"strip_helm_metadata": {
+				MarkdownDescription: "Remove the labels and annotations added by Helm from the manifests and the pod templates of workloads, so manifests rendered from a Helm chart can be adopted by Terraform. Every key under `helm.sh/` or `meta.helm.sh/` is removed, such as `helm.sh/chart` and the hook annotations, along with the `app.kubernetes.io/managed-by` and `heritage` labels when they're set to `Helm`. Maps left empty are removed as well.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"strip_ca_bundles": {
+				MarkdownDescription: "Remove the `caBundle` of the webhooks of `ValidatingWebhookConfiguration` and `MutatingWebhookConfiguration` manifests, of `APIService` manifests, and of the conversion webhooks of `CustomResourceDefinition` manifests. These are usually injected by the cluster at runtime, such as by the cainjector of cert-manager, so keeping them causes a permanent diff.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"decode_secret_data": {
+				MarkdownDescription: "Move the values of the `data` of `Secret` manifests into their `stringData`, decoding them from base64, so they can be read and merged with other values in Terraform. Values which aren't valid UTF-8 text, such as binary keystores, are left in `data`. The decoded values are stored in the state as plaintext, just as the encoded ones are. Conflicts with `encode_secret_data`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"encode_secret_data": {
+				MarkdownDescription: "Move the values of the `stringData` of `Secret` manifests into their `data`, encoding them as base64, which is how the API server returns them. Conflicts with `decode_secret_data`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"redact_secrets": {
+				MarkdownDescription: "Replace every value of the `data` and `stringData` of `Secret` manifests with `REDACTED`, encoded as base64 in `data`, so the secrets of a bundle can be inspected or applied without storing their values in the state. The redacted keys are listed in `redacted_secret_keys`. Secrets are redacted once every other transformation is applied.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"strip_finalizers": {
+				MarkdownDescription: "Remove the `metadata.finalizers` of the manifests, which otherwise leave them stuck while being destroyed once the controller removing the finalizers is gone. Every manifest is stripped unless `strip_finalizers_resources` is set.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"strip_finalizers_resources": {
+				MarkdownDescription: "The resource types whose finalizers are removed by `strip_finalizers`, using the same syntax as `only_resources`, such as `v1/PersistentVolumeClaim` or `!v1/Namespace`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"prune_empty": {
+				MarkdownDescription: "Remove the maps and lists left empty once the attributes within them are removed by `filtered_attributes`, `filtered_attributes_by_kind`, or `strip_server_fields`, such as the `metadata` of a manifest whose only attribute was filtered. Maps and lists which were already empty, such as `emptyDir: {}`, are kept.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"strict_filters": {
+				MarkdownDescription: "Fail when any path of `filtered_attributes` or `filtered_attributes_by_kind` removes nothing from every manifest, rather than only warning about it. Paths which match nothing are usually typos, such as `metadta.creationTimestamp`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"drop_nulls": {
+				MarkdownDescription: "Remove the attributes with null values, such as `creationTimestamp: null`, from anywhere within the manifests. Null elements of lists are kept.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"namespace": {
+				MarkdownDescription: "Move every namespaced manifest into the namespace by setting its `metadata.namespace`, as the namespace transformer of kustomize does. The service accounts bound by `RoleBinding` and `ClusterRoleBinding` subjects are moved along with them when their namespace is unset or `default`. Resources which aren't namespaced, such as `ClusterRole` or `CustomResourceDefinition`, are left untouched, including the custom resources matching `cluster_scoped_resources`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"default_namespace": {
+				MarkdownDescription: "Set the `metadata.namespace` of the namespaced manifests which don't have one, leaving the others where they are. The service accounts bound by `RoleBinding` and `ClusterRoleBinding` subjects without a namespace are moved along with them. Conflicts with `namespace`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"cluster_scoped_resources": {
+				MarkdownDescription: "The custom resources which aren't namespaced, so `namespace` and `default_namespace` leave them untouched. The resources must be in the format `{apiVersion}/{kind}`, where either part may contain shell patterns, such as `cert-manager.io/*/ClusterIssuer`. The built-in resources which aren't namespaced are always known.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"name_prefix": {
+				MarkdownDescription: "Prepend the prefix to the `metadata.name` of every manifest, so the same bundle can be installed several times side by side. `Namespace`, `CustomResourceDefinition`, and `APIService` manifests keep their names, as they're derived from what they define.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"name_suffix": {
+				MarkdownDescription: "Append the suffix to the `metadata.name` of every manifest, like `name_prefix`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"rename_references": {
+				MarkdownDescription: "Whether the references to renamed manifests are renamed along with them when `name_prefix` or `name_suffix` is set. The `roleRef` and `ServiceAccount` subjects of role bindings, the service accounts, config maps, secrets, image pull secrets, and persistent volume claims used by workloads, and the services used by stateful sets, ingresses, webhooks, and API services are renamed. References to resources outside the returned manifests are left untouched.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"overlays": {
+				MarkdownDescription: "Local manifests deeply merged on top of the fetched manifests with the same `apiVersion`, `kind`, and `metadata.name`, such as to tweak upstream manifests for an environment. Each entry is either YAML or JSON, which may contain several documents, or the path to a `.yaml`, `.yml`, or `.json` file relative to the working directory. Maps are merged recursively, `null` values remove the attribute, and lists are replaced as a whole. An overlay with a `metadata.namespace` only matches manifests in that namespace. Overlays are merged after the patches, and a warning lists those which matched no manifest.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"convert_deprecated_api_versions": {
+				MarkdownDescription: "Convert the manifests using well-known deprecated API versions into the versions replacing them, restructuring the fields which changed, so old bundles can be applied to current clusters. `Ingress` manifests of `extensions/v1beta1` and `networking.k8s.io/v1beta1` are converted into `networking.k8s.io/v1`, nesting the service of each backend and setting the `pathType` of each path to `ImplementationSpecific` unless set. `CustomResourceDefinition` manifests of `apiextensions.k8s.io/v1beta1` are converted into `apiextensions.k8s.io/v1`, moving the schema, subresources, and printer columns into each version, and keeping the versions without a schema accepting any field. Conversions happen before `api_version_overrides` is applied.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"api_version_overrides": {
+				MarkdownDescription: "The `apiVersion` to set on the manifests of resource types, keyed by resource type in the format `{apiVersion}/{kind}`, where either part may contain shell patterns as with `only_resources`, such as `policy/v1beta1/PodDisruptionBudget = \"policy/v1\"`. When several keys match a manifest, the first in lexical order is used. Only the `apiVersion` is rewritten, and it is rewritten before any other transformation, so the new version is the one matched by `filtered_attributes_by_kind` and the targets of patches, while the selectors, such as `only_resources`, match the original one.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"service_type_overrides": {
+				MarkdownDescription: "The `spec.type` of `Service` manifests, keyed by their name, or `*` for every service without its own key, such as to turn the `LoadBalancer` services of a bundle into `ClusterIP` ones for an internal-only cluster. The type must be `ClusterIP`, `NodePort`, or `LoadBalancer`, and the fields the type doesn't support, such as the `nodePort` of each port for `ClusterIP` or `loadBalancerIP` for `NodePort`, are removed. The names are those of the fetched manifests, before `name_prefix` and `name_suffix` are applied, and a warning lists the names which matched no service.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"replicas": {
+				MarkdownDescription: "The number of replicas of workloads, such as `Deployment` or `StatefulSet`, keyed by `{kind}/{name}`, such as `Deployment/cert-manager`. The names are those of the fetched manifests, before `name_prefix` and `name_suffix` are applied. The `spec.replicas` of the matching manifests is set to the number, and a warning lists the keys which matched no manifest.",
+				Type: types.MapType{
+					ElemType: types.Int64Type,
+				},
+				Optional: true,
+			},
+			"pin_images_to_digest": {
+				MarkdownDescription: "Replace the tag of every container and init container image in the pod templates with the digest it currently points to, such as `quay.io/jetstack/cert-manager-controller@sha256:...`, for reproducible deploys. The digests are resolved from the registries after `image_rewrites` are applied, using the provider's registry credentials. Images which are already pinned are left untouched, and resolving fails in offline mode.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"remove_containers": {
+				MarkdownDescription: "The names of the containers and init containers to remove from the pod template of every workload, such as bundled telemetry sidecars. Each entry is either an exact name or a shell pattern, such as `*-exporter`. Containers are removed after the patches and overlays are applied, and removing every container of a workload leaves it invalid.",
+				Type:                types.ListType{ElemType: types.StringType},
+				Optional:            true,
+			},
+			"image_pull_secrets": {
+				MarkdownDescription: "The names of secrets to append to the `imagePullSecrets` of every pod template, such as `Deployment`, `StatefulSet`, `DaemonSet`, `Job`, `CronJob`, or bare `Pod`, which are needed to pull images mirrored into private registries. Secrets a workload already references aren't repeated, and the names are left untouched by `name_prefix` and `name_suffix`.",
+				Type:                types.ListType{ElemType: types.StringType},
+				Optional:            true,
+			},
+			"priority_class_name": {
+				MarkdownDescription: "The `priorityClassName` to set in the pod template of every workload, such as `Deployment`, `StatefulSet`, `DaemonSet`, `Job`, `CronJob`, or bare `Pod`, replacing any the workload already has. The workloads can be narrowed with `priority_class_target`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"set_attributes": {
+				MarkdownDescription: "Values to set in every returned manifest, keyed by the path of the attribute, using the same syntax as `filtered_attributes`, such as `spec.replicas` or `metadata.annotations.\"example.com/owner\"`. Values are decoded as YAML, so `3` and `true` are set as a number and a boolean, `{a: b}` as a map, and quoted values, such as `'3'`, as strings. Maps along the path are created when missing, while lists are not. Applied after every attribute is removed.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"filtered_attributes_by_kind": {
+				MarkdownDescription: "The paths of the attributes to remove from the manifests of specific resource types, using the same syntax as `filtered_attributes`. Keyed by resource type in the format `{apiVersion}/{kind}`, where either part may contain shell patterns as with `only_resources`, such as `admissionregistration.k8s.io/v1/*WebhookConfiguration`. The paths under the `*` key are removed from the manifests matching none of the other keys. Applied in addition to `filtered_attributes`.",
+				Type: types.MapType{
+					ElemType: types.ListType{
+						ElemType: types.StringType,
+					},
+				},
+				Optional: true,
+			},
+			"allowed_attributes": {
+				MarkdownDescription: "The paths of the only attributes to keep in the manifest, using the same syntax as `filtered_attributes`, such as `spec.replicas` or `spec.template.spec.containers[*].image`. Every other attribute is removed, except for `apiVersion`, `kind`, and `metadata.name`, which are always kept. Maps and lists left empty are removed as well. When both are set, `filtered_attributes` is applied to the attributes which are kept.",
 				Type: types.ListType{
 					ElemType: types.StringType,
 				},
 				Optional: true,
 			},
 			"only_resources": {
-				Description: "Only return the specified resource types. The resources must be in the format `{apiVersion}/{kind}`.",
+				MarkdownDescription: "Only return the specified resource types. The resources must be in the format `{apiVersion}/{kind}`, where either part may contain shell patterns (e.g. `apps/v1/*` or `monitoring.coreos.com/*/*`). A `*` API version matches every API version, such as in `*/Deployment`. Entries prefixed with `!` (e.g. `!v1/Namespace`) exclude the resources they match, even when another entry matches them, and every other resource is returned when all entries are prefixed with `!`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"name_selector": {
+				MarkdownDescription: "Only return the manifests whose `metadata.name` matches one of the entries. Each entry is either an exact name, a shell pattern (e.g. `cert-manager-*`), or a regular expression enclosed in slashes (e.g. `/^cert-manager-(webhook|cainjector)$/`). Manifests without a name are never returned.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"namespaces": {
+				MarkdownDescription: "Only return the manifests whose `metadata.namespace` matches one of the entries, which may be shell patterns (e.g. `team-*`). Entries prefixed with `!` (e.g. `!kube-system`) exclude the namespaces they match, even when another entry matches them, and every other namespace is returned when all entries are prefixed with `!`. Manifests without a namespace are handled by `include_cluster_scoped`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"include_cluster_scoped": {
+				MarkdownDescription: "Whether manifests without a `metadata.namespace`, such as cluster-scoped resources, are returned when `namespaces` is set. Defaults to `true`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"label_selector": {
+				MarkdownDescription: "Only return the manifests whose `metadata.labels` match the label selector, using the same syntax as `kubectl` (e.g. `app.kubernetes.io/part-of=istio,component!=pilot`).",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"annotation_selector": {
+				MarkdownDescription: "Only return the manifests whose `metadata.annotations` meet every requirement. A requirement is either the presence of an annotation (e.g. `helm.sh/hook`), its absence (e.g. `!helm.sh/hook`), or its value (e.g. `example.com/managed=true` or `example.com/managed!=false`).",
 				Type: types.ListType{
 					ElemType: types.StringType,
 				},
 				Optional: true,
 			},
+			"cel_filter": {
+				MarkdownDescription: "Only return the manifests for which the [CEL](https://github.com/google/cel-spec) expression evaluates to `true`. The manifest is available as `object` (e.g. `object.kind == 'Service' && object.spec.type == 'LoadBalancer'`). Accessing a field which doesn't exist is an error, so optional fields should be checked with `has` first.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"jsonpath_filter": {
+				MarkdownDescription: "Only return the manifests in which the JSONPath expression, using the same syntax as `kubectl` (e.g. `{.spec.template.spec.containers[?(@.name==\"webhook\")]}`), matches at least one value.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"jsonpath_extract": {
+				MarkdownDescription: "A JSONPath expression, using the same syntax as `kubectl` (e.g. `{.spec.template.spec.containers[*].image}`), whose values are extracted from the resulting manifests into `extracted_values`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
 			"manifests": {
-				Description: "The resulting manifests to be applied. Due to a limitation the Terraform Plugin Framework, these must be parsed with `yamldecode` prior to being passed to `kubernetes_manifest`.",
+				Description: "The resulting manifests to be applied. Lists, such as the `List` returned by `kubectl get -o yaml`, are expanded into a manifest for each of their items. YAML documents which aren't modified by any transformation are returned as-is, keeping their comments and formatting, unless `canonicalize` is set, and modified documents keep their comments when `preserve_comments` is set. Due to a limitation the Terraform Plugin Framework, these must be parsed with `yamldecode` prior to being passed to `kubernetes_manifest`.",
 				// TODO: update to `types.Dynamic` pending hashicorp/terraform-plugin-framework#147
 				// https://github.com/hashicorp/terraform-plugin-framework/issues/147
 				Type: types.ListType{
@@ -63,8 +407,69 @@ func (d *fetchDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnos
 				},
 				Computed: true,
 			},
+			"manifests_by_file": {
+				MarkdownDescription: "The resulting manifests keyed by the path of the file they were read from, relative to the root of the archive, repository, or artifact, or by their URL when fetched with `urls` or `crawl`. Empty unless the content was read from files or several URLs, such as those of an archive, a `git` repository, an OCI artifact, or a `crawl`.",
+				Type: types.MapType{
+					ElemType: types.ListType{
+						ElemType: types.StringType,
+					},
+				},
+				Computed: true,
+			},
+			"manifests_by_kind": {
+				MarkdownDescription: "The resulting manifests keyed by their `kind`, in order, such as `CustomResourceDefinition` or `Deployment`. Useful for passing different kinds of manifests to different modules. Manifests without a kind are omitted.",
+				Type: types.MapType{
+					ElemType: types.ListType{
+						ElemType: types.StringType,
+					},
+				},
+				Computed: true,
+			},
+			"extracted_values": {
+				MarkdownDescription: "The values matched by `jsonpath_extract` in each of the resulting manifests, in order. Strings are extracted as-is, while every other value is encoded as JSON. Empty unless `jsonpath_extract` is set.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Computed: true,
+			},
+			"redacted_secret_keys": {
+				MarkdownDescription: "The keys of the `Secret` manifests whose values were redacted by `redact_secrets`, in order, in the format `{namespace}/{name}/{key}`, or `{name}/{key}` for secrets without a namespace. Empty unless `redact_secrets` is set.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Computed: true,
+			},
+			"manifest_chunks": {
+				MarkdownDescription: "The resulting manifests split, in order, into lists of at most `chunk_size` manifests. Useful for spreading very large sets of manifests across several `for_each` groups.",
+				Type: types.ListType{
+					ElemType: types.ListType{
+						ElemType: types.StringType,
+					},
+				},
+				Computed: true,
+			},
 		},
-	}, nil
+		Blocks: map[string]tfsdk.Block{
+			"git":                   gitSourceBlock,
+			"github_release":        githubReleaseSourceBlock,
+			"cluster":               clusterSourceBlock,
+			"crawl":                 crawlSourceBlock,
+			"verify_signature":      verifySignatureBlock,
+			"match_fields":          matchFieldsBlock,
+			"filter":                filterBlock,
+			"env_injections":        envInjectionsBlock,
+			"image_rewrites":        imageRewritesBlock,
+			"json_patches":          jsonPatchesBlock,
+			"patch":                 patchBlock,
+			"replacements":          replacementsBlock,
+			"priority_class_target": priorityClassTargetBlock,
+			"template":              templateBlock,
+		},
+	}
+}
+
+func (d *fetchDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateFilters(ctx, req.Config)...)
 }
 
 func (d *fetchDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
@@ -75,68 +480,921 @@ func (d *fetchDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 		return
 	}
 
+	// Without a cache, the data source fetches the content on every read, so there is nothing to pin or invalidate
+	if d.provider == nil || d.provider.cache == nil {
+		if !model.Triggers.Null && !model.Triggers.Unknown && len(model.Triggers.Elems) > 0 {
+			resp.Diagnostics.AddAttributeWarning(path.Root("triggers"), "Ineffective triggers", "The provider has no cache configured, so the content is fetched on every read and triggers have no effect. Configure the provider cache, or use the manifest_fetch resource instead.")
+		}
+		if model.Pin.Value {
+			resp.Diagnostics.AddAttributeWarning(path.Root("pin"), "Ineffective pin", "The provider has no cache configured, so the content is fetched on every read and can't be pinned. Configure the provider cache, or use the manifest_fetch resource instead.")
+		}
+	}
+
+	_, diags = model.fetch(ctx, d.provider, nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Fetches the manifests and populates the computed attributes of the model. If validators from a previous response
+// are provided, a conditional request is made and the computed attributes are left untouched when the content has not
+// been modified.
+func (model *modelV0) fetch(ctx context.Context, provider *providerData, previous *cacheValidators) (*fetchResponse, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	hasURL, hasPath, hasGit := !model.URL.Null && !model.URL.Unknown, !model.Path.Null && !model.Path.Unknown, len(model.Git) > 0
+	hasRelease, hasCluster, hasCrawl := len(model.GitHubRelease) > 0, len(model.Cluster) > 0, len(model.Crawl) > 0
+	sourceURLs := parseTfList(ctx, model.URLs, func(url string) string { return url })
+	hasURLs := len(sourceURLs) > 0
+	if countTrue(hasURL, hasURLs, hasPath, hasGit, hasRelease, hasCluster, hasCrawl) != 1 {
+		diags.AddError("Invalid source", "Exactly one of url, urls, path, git, github_release, cluster, or crawl must be set.")
+		return nil, diags
+	}
+
 	url := model.URL.Value
-	filteredAttributes := parseTfList(ctx, model.FilteredAttributes, func(attribute string) []string {
-		return strings.Split(attribute, ".")
-	})
-	onlyResources := parseTfList(ctx, model.OnlyResources, func(resource string) string { return resource })
-	if len(onlyResources) == 0 {
-		onlyResources = nil
+	if hasURLs {
+		url = strings.Join(sourceURLs, "\n")
+	} else if hasPath {
+		url = model.Path.Value
+	} else if hasGit {
+		url = model.Git[0].Repository.Value
+	} else if hasRelease {
+		url = model.GitHubRelease[0].Owner.Value + "/" + model.GitHubRelease[0].Repository.Value
+	} else if hasCluster {
+		url = model.Cluster[0].APIVersion.Value + "/" + model.Cluster[0].Kind.Value
+	} else if hasCrawl {
+		url = model.Crawl[0].IndexURL.Value
+	}
+	filters, filterDiags := compileFilters(ctx, *model)
+	diags.Append(filterDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if isSet(model.BodyEncoding) && !contains(bodyEncodings, model.BodyEncoding.Value) {
+		diags.AddAttributeError(path.Root("body_encoding"), "Invalid body encoding", fmt.Sprintf("Invalid body encoding %q, must be one of: %s", model.BodyEncoding.Value, strings.Join(bodyEncodings, ", ")))
+		return nil, diags
+	}
+	if isSet(model.Format) && !contains(formats, model.Format.Value) {
+		diags.AddAttributeError(path.Root("format"), "Invalid format", fmt.Sprintf("Invalid format %q, must be one of: %s", model.Format.Value, strings.Join(formats, ", ")))
+		return nil, diags
+	}
+	if isSet(model.Order) && !contains(orders, model.Order.Value) {
+		diags.AddAttributeError(path.Root("order"), "Invalid order", fmt.Sprintf("Invalid order %q, must be one of: %s", model.Order.Value, strings.Join(orders, ", ")))
+		return nil, diags
+	}
+	if isSet(model.Deduplicate) && !contains(deduplicateModes, model.Deduplicate.Value) {
+		diags.AddAttributeError(path.Root("deduplicate"), "Invalid deduplication", fmt.Sprintf("Invalid deduplication %q, must be one of: %s", model.Deduplicate.Value, strings.Join(deduplicateModes, ", ")))
+		return nil, diags
+	}
+	sortBy := parseTfList(ctx, model.SortBy, func(field string) string { return field })
+	for i, field := range sortBy {
+		if !contains(sortFields, field) {
+			diags.AddAttributeError(path.Root("sort_by").AtListIndex(i), "Invalid sort field", fmt.Sprintf("Invalid sort field %q, must be one of: %s", field, strings.Join(sortFields, ", ")))
+			return nil, diags
+		}
+		if contains(sortBy[:i], field) {
+			diags.AddAttributeError(path.Root("sort_by").AtListIndex(i), "Invalid sort field", fmt.Sprintf("Invalid sort field %q, each field can only be listed once", field))
+			return nil, diags
+		}
+	}
+
+	parallelism := defaultParallelism
+	if !model.Parallelism.Null && !model.Parallelism.Unknown {
+		if model.Parallelism.Value < 1 {
+			diags.AddAttributeError(path.Root("parallelism"), "Invalid parallelism", fmt.Sprintf("Invalid parallelism %d, must be at least 1", model.Parallelism.Value))
+			return nil, diags
+		}
+		parallelism = int(model.Parallelism.Value)
+	}
+
+	chunkSize := 0
+	if !model.ChunkSize.Null && !model.ChunkSize.Unknown {
+		if model.ChunkSize.Value < 1 {
+			diags.AddAttributeError(path.Root("chunk_size"), "Invalid chunk size", fmt.Sprintf("Invalid chunk size %d, must be at least 1", model.ChunkSize.Value))
+			return nil, diags
+		}
+		chunkSize = int(model.ChunkSize.Value)
+	}
+
+	var hedgeDelay time.Duration
+	if !model.HedgeDelay.Null && !model.HedgeDelay.Unknown {
+		delay, err := time.ParseDuration(model.HedgeDelay.Value)
+		if err != nil {
+			diags.AddAttributeError(path.Root("hedge_delay"), "Invalid hedge delay", fmt.Sprintf("Invalid hedge delay: %s", err))
+			return nil, diags
+		}
+		hedgeDelay = delay
+	}
+
+	urls := append([]string{url}, parseTfList(ctx, model.FallbackURLs, func(url string) string { return url })...)
+
+	fetcher := newFetcher(provider)
+	fetcher.parallelism = parallelism
+
+	triggers := map[string]string{}
+	diags.Append(model.Triggers.ElementsAs(ctx, &triggers, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	ctx = withCacheOptions(ctx, cacheOptions{pin: model.Pin.Value, triggers: triggers})
+
+	var response *fetchResponse
+	if previous != nil {
+		// Fall back to an unconditional request if the previous URL is unavailable
+		response, _ = fetcher.fetch(ctx, previous.URL, previous)
+		if response != nil && response.notModified {
+			return response, diags
+		}
+	}
+
+	if response == nil && hasURLs {
+		var errs []error
+		response, errs = fetcher.fetchAll(ctx, sourceURLs)
+		if response == nil {
+			for i, err := range errs {
+				if err != nil {
+					addFetchError(&diags, sourceURLs[i], err, true)
+				}
+			}
+			return nil, diags
+		}
+	}
+
+	if response == nil && !hasURL {
+		var err error
+		switch {
+		case hasPath:
+			response, err = readFile(url)
+		case hasGit:
+			response, err = fetcher.fetchGit(ctx, model.Git[0])
+		case hasRelease:
+			var tag string
+			response, tag, err = fetcher.fetchGitHubRelease(ctx, model.GitHubRelease[0])
+			model.GitHubRelease[0].ResolvedTag = types.String{Value: tag}
+		case hasCluster:
+			response, err = fetcher.fetchCluster(ctx, model.Cluster[0])
+		case hasCrawl:
+			response, err = fetcher.fetchCrawl(ctx, model.Crawl[0])
+		}
+		if err != nil {
+			addFetchError(&diags, url, err, false)
+			return nil, diags
+		}
+	}
+
+	if response == nil {
+		var errs []error
+		if hedgeDelay > 0 {
+			response, errs = fetcher.fetchHedged(ctx, urls, hedgeDelay)
+		} else {
+			response, errs = fetcher.fetchSequential(ctx, urls)
+		}
+		if response == nil {
+			for i, err := range errs {
+				addFetchError(&diags, urls[i], err, len(urls) > 1)
+			}
+			return nil, diags
+		}
+	}
+
+	for _, config := range model.VerifySignature {
+		if err := verifySignature(ctx, fetcher, config, response.body); err != nil {
+			diags.AddAttributeError(path.Root("verify_signature"), "Signature verification failed", fmt.Sprintf("Signature verification failed: %s", err))
+			return nil, diags
+		}
 	}
 
-	client := &http.Client{}
+	body, contentType := response.body, response.contentType
+	if model.BodyEncoding.Value == bodyEncodingBase64 {
+		// The Content-Type describes the encoded body rather than the manifests within it
+		contentType = ""
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		decoded, err := decodeBase64Body(body)
+		if err != nil {
+			diags.AddAttributeError(path.Root("body_encoding"), "Error decoding response body", fmt.Sprintf("Error decoding response body: %s", err))
+			return nil, diags
+		}
+		body = decoded
+	}
+
+	body, err := decompress(body, response.contentEncoding)
 	if err != nil {
-		resp.Diagnostics.AddError("Error creating request", fmt.Sprintf("Error creating request: %s", err))
-		return
+		diags.AddError("Error decompressing response body", fmt.Sprintf("Error decompressing response body: %s", err))
+		return nil, diags
 	}
 
-	response, err := client.Do(request)
+	// Archives are verified as a whole, then only their matching files are decoded
+	files := response.files
+	if isArchive(body) {
+		files, err = extractArchive(body, model.FileGlob.Value)
+		if err != nil {
+			diags.AddAttributeError(path.Root("file_glob"), "Error extracting archive", fmt.Sprintf("Error extracting archive: %s", err))
+			return nil, diags
+		}
+	}
+
+	// Each file is decoded separately so its manifests can be attributed to it
+	sources := files
+	if sources == nil {
+		sources = []archiveFile{{content: body}}
+	}
+
+	_, decodeDiags := model.decodeSources(ctx, provider, fetcher, filters, sources, contentType, parallelism, sortBy, chunkSize)
+	diags.Append(decodeDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	contentDigest := sha256Hex(response.body)
+
+	id, err := fetchID(url, model.transformations(), contentDigest)
 	if err != nil {
-		resp.Diagnostics.AddError("Error making request", fmt.Sprintf("Error making request: %s", err))
-		return
+		diags.AddError("Error computing ID", fmt.Sprintf("Failed to hash the configuration: %s", err))
+		return nil, diags
 	}
-	defer response.Body.Close()
+	model.ID = types.String{Value: id}
+	model.ContentSHA256 = types.String{Value: contentDigest}
 
-	if response.StatusCode != 200 {
-		resp.Diagnostics.AddError("Received non-success response code", fmt.Sprintf("Received non-success response code: %d", response.StatusCode))
-		return
+	return response, diags
+}
+
+// Decodes the manifests within the sources, then filters, orders, and deduplicates them before populating the computed
+// attributes of the model with them. Sources are decoded concurrently, but the manifests keep the order of the sources.
+func (model *modelV0) decodeSources(ctx context.Context, provider *providerData, fetcher *fetcher, filters *compiledFilters, sources []archiveFile, contentType string, parallelism int, sortBy []string, chunkSize int) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// Templates are rendered and placeholders substituted into copies, as the fetched files may be cached
+	if filters.template != nil || filters.variables != nil {
+		prepared := make([]archiveFile, len(sources))
+		for i, source := range sources {
+			content, err := filters.template.render(source.name, source.content)
+			if err != nil {
+				diags.AddAttributeError(path.Root("template"), "Error rendering template", fmt.Sprintf("Error rendering template: %s", err))
+				return nil, diags
+			}
+			prepared[i] = archiveFile{name: source.name, content: filters.variables.substitute(content)}
+		}
+		sources = prepared
 	}
 
-	// Attempt to decode regardless of the content type
-	filterableManifests := []map[any]any{}
-	if err := unmarshalAllManifests(response.Body, onlyResources, &filterableManifests); err != nil {
-		resp.Diagnostics.AddError("Error parsing response body", fmt.Sprintf("Error parsing response body: %s", err))
-		return
+	// Past the provider's spill threshold, decoded documents are held on disk until they're all merged
+	spool := newDocumentSpool(provider.spillOptions())
+	defer spool.close()
+
+	// References and replaced values may come from documents in any source, and images are resolved all at once, so
+	// every document is collected before any is transformed
+	if filters.filter.collects() {
+		for _, source := range sources {
+			format, _ := selectFormat(model.Format.Value, contentType, source.name)
+			filters.filter.collectAll(source.content, format, filters.selector)
+		}
+	}
+	if filters.filter.replacer != nil {
+		if index, err := filters.filter.replacer.resolve(); err != nil {
+			diags.AddAttributeError(path.Root("replacements").AtListIndex(index), "Invalid replacement", fmt.Sprintf("Invalid replacement: %s", err))
+			return nil, diags
+		}
+	}
+	if filters.filter.pins != nil {
+		if provider != nil && provider.offline {
+			diags.AddAttributeError(path.Root("pin_images_to_digest"), "Error resolving images", "Images can't be pinned to digests in offline mode")
+			return nil, diags
+		}
+		if err := filters.filter.pins.resolve(ctx, fetcher.registryClient(), parallelism); err != nil {
+			diags.AddAttributeError(path.Root("pin_images_to_digest"), "Error resolving images", fmt.Sprintf("Error resolving images: %s", err))
+			return nil, diags
+		}
 	}
 
-	// Filter the invalid fields from any manifests
-	for _, manifest := range filterableManifests {
-		for _, attribute := range filteredAttributes {
-			removeAttribute(manifest, attribute)
+	decodedSources := make([][]decodedManifest, len(sources))
+	decodeErrors := make([]string, len(sources))
+	forEachConcurrently(len(sources), parallelism, func(i int) {
+		source := sources[i]
+		format, reason := selectFormat(model.Format.Value, contentType, source.name)
+		decoded, err := decodeManifests(source.content, format, filters.selector, filters.filter, filters.extract, spool)
+		if err != nil {
+			detail := fmt.Sprintf("Error parsing response body: %s", err)
+			if reason != "" {
+				detail = fmt.Sprintf("Error parsing response body: the content is not valid %s, %s: %s", strings.ToUpper(format), reason, err)
+			}
+			if source.name != "" {
+				detail = fmt.Sprintf("Error parsing %s: %s", source.name, strings.TrimPrefix(detail, "Error parsing response body: "))
+			}
+			decodeErrors[i] = detail
+			return
+		}
+		decodedSources[i] = decoded
+	})
+
+	// Merge in the original order so the result doesn't depend on which source finished first
+	var ordered []orderedManifest
+	for i := range sources {
+		if decodeErrors[i] != "" {
+			diags.AddError("Error parsing response body", decodeErrors[i])
+			return nil, diags
+		}
+
+		for _, decoded := range decodedSources[i] {
+			ordered = append(ordered, orderedManifest{source: i, decoded: decoded})
+		}
+	}
+	sortManifests(ordered, model.Order.Value, sortBy)
+	for i := range ordered {
+		content, err := spool.read(ordered[i].decoded.ref)
+		if err != nil {
+			diags.AddError("Error reading decoded manifests", fmt.Sprintf("Error reading decoded manifests: %s", err))
+			return nil, diags
+		}
+		ordered[i].content = content
+	}
+	ordered, err := deduplicateManifests(ordered, model.Deduplicate.Value)
+	if err != nil {
+		diags.AddAttributeError(path.Root("deduplicate"), "Conflicting duplicate manifests", fmt.Sprintf("Conflicting duplicate manifests: %s", err))
+		return nil, diags
+	}
+	if model.FailOnDuplicates.Value {
+		if conflicts := conflictingManifests(ordered); len(conflicts) > 0 {
+			diags.AddAttributeError(path.Root("fail_on_duplicates"), "Conflicting duplicate manifests", fmt.Sprintf("The following manifests are defined more than once with different content, which usually means the bundle is broken: %s", strings.Join(conflicts, ", ")))
+			return nil, diags
 		}
 	}
 
-	// Convert the manifests back to YAML
 	var manifests []string
-	for _, manifest := range filterableManifests {
-		encoded, _ := yaml.Marshal(manifest)
-		manifests = append(manifests, string(encoded))
+	manifestsByFile := map[string][]string{}
+	manifestsByKind := map[string][]string{}
+	extractedValues := []string{}
+	redactedKeys := []string{}
+	for _, entry := range ordered {
+		manifest := entry.content
+		manifests = append(manifests, manifest)
+		if name := sources[entry.source].name; name != "" {
+			manifestsByFile[name] = append(manifestsByFile[name], manifest)
+		}
+		if kind := entry.decoded.kind; kind != "" {
+			manifestsByKind[kind] = append(manifestsByKind[kind], manifest)
+		}
+		extractedValues = append(extractedValues, entry.decoded.extracted...)
+		redactedKeys = append(redactedKeys, entry.decoded.redacted...)
+	}
+
+	diags.Append(filters.reportUnmatched()...)
+	if diags.HasError() {
+		return nil, diags
 	}
 
 	manifestsState := types.List{}
-	diags = tfsdk.ValueFrom(ctx, manifests, types.List{ElemType: types.StringType}.Type(ctx), &manifestsState)
-	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
-		return
+	diags.Append(tfsdk.ValueFrom(ctx, manifests, types.List{ElemType: types.StringType}.Type(ctx), &manifestsState)...)
+	manifestsByFileState := types.Map{}
+	diags.Append(tfsdk.ValueFrom(ctx, manifestsByFile, types.MapType{ElemType: types.ListType{ElemType: types.StringType}}, &manifestsByFileState)...)
+	manifestsByKindState := types.Map{}
+	diags.Append(tfsdk.ValueFrom(ctx, manifestsByKind, types.MapType{ElemType: types.ListType{ElemType: types.StringType}}, &manifestsByKindState)...)
+	manifestChunksState := types.List{}
+	diags.Append(tfsdk.ValueFrom(ctx, chunkManifests(manifests, chunkSize), types.ListType{ElemType: types.ListType{ElemType: types.StringType}}, &manifestChunksState)...)
+	extractedValuesState := types.List{}
+	diags.Append(tfsdk.ValueFrom(ctx, extractedValues, types.ListType{ElemType: types.StringType}, &extractedValuesState)...)
+	redactedKeysState := types.List{}
+	diags.Append(tfsdk.ValueFrom(ctx, redactedKeys, types.ListType{ElemType: types.StringType}, &redactedKeysState)...)
+	if diags.HasError() {
+		return nil, diags
 	}
 
-	model.ID = types.String{Value: url}
 	model.Manifests = manifestsState
+	model.ManifestsByFile = manifestsByFileState
+	model.ManifestsByKind = manifestsByKindState
+	model.ManifestChunks = manifestChunksState
+	model.ExtractedValues = extractedValuesState
+	model.RedactedKeys = redactedKeysState
 
-	diags = resp.State.Set(ctx, model)
-	resp.Diagnostics.Append(diags...)
+	return manifests, diags
+}
+
+// Compiles the selectors and transformations of the configuration, so mistakes are reported while planning rather than
+// once the content is fetched. Values which aren't known yet are only validated when fetching.
+func validateFilters(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	if !config.Raw.IsFullyKnown() {
+		return nil
+	}
+
+	var model modelV0
+	diags := config.Get(ctx, &model)
+	if diags.HasError() {
+		return diags
+	}
+
+	_, filterDiags := compileFilters(ctx, model)
+	diags.Append(filterDiags...)
+	return diags
+}
+
+// The selectors and transformations compiled from the model, shared by every source
+type compiledFilters struct {
+	selector  *documentSelector
+	filter    *documentFilter
+	extract   *jsonPathQuery
+	variables *variableSubstituter
+	template  *templateRenderer
+
+	stripServerFields bool
+	strict            bool
+	paths             attributePaths
+}
+
+// Compiles the selectors and transformations of the model, merging in the filter block. The model is a copy, so the
+// merged values never reach the state.
+func compileFilters(ctx context.Context, model modelV0) (*compiledFilters, diag.Diagnostics) {
+	paths, diags := mergeFilterBlock(&model)
+	if diags.HasError() {
+		return nil, diags
+	}
+	filteredAttributes := parseTfList(ctx, model.FilteredAttributes, func(attribute string) string { return attribute })
+	if model.StripServerFields.Value {
+		filteredAttributes = append(filteredAttributes, serverFields...)
+	}
+	attributes, err := compileAttributeFilter(filteredAttributes, model.PruneEmpty.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("filtered_attributes"), "Invalid attribute path", fmt.Sprintf("Invalid attribute path: %s", err))
+		return nil, diags
+	}
+	finalizers, err := compileFinalizerStripper(model.StripFinalizers.Value, parseTfList(ctx, model.FinalizerResources, func(resource string) string { return resource }))
+	if err != nil {
+		diags.AddAttributeError(path.Root("strip_finalizers_resources"), "Invalid resource pattern", fmt.Sprintf("Invalid resource pattern: %s", err))
+		return nil, diags
+	}
+	kindPaths := map[string][]string{}
+	diags.Append(model.FilteredByKind.ElementsAs(ctx, &kindPaths, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	kinds, kindPattern, err := compileKindAttributeFilter(kindPaths, model.PruneEmpty.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("filtered_attributes_by_kind").AtMapKey(kindPattern), "Invalid attribute path", fmt.Sprintf("Invalid attribute path: %s", err))
+		return nil, diags
+	}
+	setValues := map[string]string{}
+	diags.Append(model.SetAttributes.ElementsAs(ctx, &setValues, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	set, setPath, err := compileAttributeSetter(setValues)
+	if err != nil {
+		diags.AddAttributeError(paths.of("set_attributes").AtMapKey(setPath), "Invalid attribute value", fmt.Sprintf("Invalid attribute value for %q: %s", setPath, err))
+		return nil, diags
+	}
+	replicaCounts := map[string]int64{}
+	diags.Append(model.Replicas.ElementsAs(ctx, &replicaCounts, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	replicas, replicasKey, err := compileReplicaOverrides(replicaCounts)
+	if err != nil {
+		diags.AddAttributeError(path.Root("replicas").AtMapKey(replicasKey), "Invalid replicas", fmt.Sprintf("Invalid replicas: %s", err))
+		return nil, diags
+	}
+	apiVersions := map[string]string{}
+	diags.Append(model.APIVersions.ElementsAs(ctx, &apiVersions, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	versions, versionPattern, err := compileAPIVersionOverrides(apiVersions)
+	if err != nil {
+		diags.AddAttributeError(path.Root("api_version_overrides").AtMapKey(versionPattern), "Invalid API version override", fmt.Sprintf("Invalid API version override: %s", err))
+		return nil, diags
+	}
+	serviceTypes := map[string]string{}
+	diags.Append(model.ServiceTypes.ElementsAs(ctx, &serviceTypes, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	services, serviceName, err := compileServiceTypeOverrides(serviceTypes)
+	if err != nil {
+		diags.AddAttributeError(path.Root("service_type_overrides").AtMapKey(serviceName), "Invalid service type", fmt.Sprintf("Invalid service type: %s", err))
+		return nil, diags
+	}
+	if model.Canonicalize.Value && model.PreserveComments.Value {
+		diags.AddAttributeError(path.Root("preserve_comments"), "Conflicting encodings", "Only one of canonicalize or preserve_comments can be set.")
+		return nil, diags
+	}
+	if model.DecodeSecretData.Value && model.EncodeSecretData.Value {
+		diags.AddAttributeError(path.Root("encode_secret_data"), "Conflicting secret data", "Only one of decode_secret_data or encode_secret_data can be set.")
+		return nil, diags
+	}
+	clusterScopedResources, err := compileResourceFilter(parseTfList(ctx, model.ClusterScoped, func(resource string) string { return resource }))
+	if err != nil {
+		diags.AddAttributeError(paths.of("cluster_scoped_resources"), "Invalid resource pattern", fmt.Sprintf("Invalid resource pattern: %s", err))
+		return nil, diags
+	}
+	if isSet(model.Namespace) && isSet(model.DefaultNamespace) {
+		diags.AddAttributeError(path.Root("default_namespace"), "Conflicting namespaces", "Only one of namespace or default_namespace can be set.")
+		return nil, diags
+	}
+	namespaceAttribute, namespaceValue, onlyMissing := "namespace", model.Namespace.Value, isSet(model.DefaultNamespace)
+	if onlyMissing {
+		namespaceAttribute, namespaceValue = "default_namespace", model.DefaultNamespace.Value
+	}
+	namespace, err := compileNamespaceTransformer(namespaceValue, onlyMissing, clusterScopedResources)
+	if err != nil {
+		diags.AddAttributeError(path.Root(namespaceAttribute), "Invalid namespace", fmt.Sprintf("Invalid namespace: %s", err))
+		return nil, diags
+	}
+	nameAffixes, err := compileNameTransformer(model.NamePrefix.Value, model.NameSuffix.Value, model.RenameReferences.Value)
+	if err != nil {
+		diags.AddAttributeError(path.Root("name_prefix"), "Invalid name prefix or suffix", fmt.Sprintf("Invalid name prefix or suffix: %s", err))
+		return nil, diags
+	}
+	secrets, index, err := compileImagePullSecretInjector(parseTfList(ctx, model.ImagePullSecrets, func(secret string) string { return secret }))
+	if err != nil {
+		diags.AddAttributeError(path.Root("image_pull_secrets").AtListIndex(index), "Invalid image pull secret", fmt.Sprintf("Invalid image pull secret: %s", err))
+		return nil, diags
+	}
+	priority, field, err := compilePriorityClassSetter(model.PriorityClassName.Value, model.PriorityClassTarget)
+	if err != nil {
+		priorityPath := path.Root("priority_class_name")
+		if field != "" {
+			priorityPath = path.Root("priority_class_target").AtListIndex(0).AtName(field)
+		}
+		diags.AddAttributeError(priorityPath, "Invalid priority class", fmt.Sprintf("Invalid priority class: %s", err))
+		return nil, diags
+	}
+	patches, patchPath, err := compileJSONPatcher(ctx, model.JSONPatches, model.Patches)
+	if err != nil {
+		diags.AddAttributeError(patchPath, "Invalid patch", fmt.Sprintf("Invalid patch: %s", err))
+		return nil, diags
+	}
+	overlays, index, err := compileOverlayMerger(parseTfList(ctx, model.Overlays, func(overlay string) string { return overlay }))
+	if err != nil {
+		diags.AddAttributeError(path.Root("overlays").AtListIndex(index), "Invalid overlay", fmt.Sprintf("Invalid overlay: %s", err))
+		return nil, diags
+	}
+	containers, index, err := compileContainerRemover(parseTfList(ctx, model.RemoveContainers, func(container string) string { return container }))
+	if err != nil {
+		diags.AddAttributeError(path.Root("remove_containers").AtListIndex(index), "Invalid container pattern", fmt.Sprintf("Invalid container pattern: %s", err))
+		return nil, diags
+	}
+	replacer, replacementPath, err := compileReplacer(ctx, model.Replacements)
+	if err != nil {
+		diags.AddAttributeError(replacementPath, "Invalid replacement", fmt.Sprintf("Invalid replacement: %s", err))
+		return nil, diags
+	}
+	env, envPath, err := compileEnvInjector(ctx, model.EnvInjections)
+	if err != nil {
+		diags.AddAttributeError(envPath, "Invalid environment injection", fmt.Sprintf("Invalid environment injection: %s", err))
+		return nil, diags
+	}
+	images, index, err := compileImageRewriter(model.ImageRewrites)
+	if err != nil {
+		diags.AddAttributeError(path.Root("image_rewrites").AtListIndex(index), "Invalid image rewrite", fmt.Sprintf("Invalid image rewrite: %s", err))
+		return nil, diags
+	}
+	allowed, err := compileAllowedAttributes(parseTfList(ctx, model.AllowedAttributes, func(attribute string) string { return attribute }))
+	if err != nil {
+		diags.AddAttributeError(paths.of("allowed_attributes"), "Invalid attribute path", fmt.Sprintf("Invalid attribute path: %s", err))
+		return nil, diags
+	}
+	crds, err := compileCRDSelector(model.CRDs.Value)
+	if err != nil {
+		diags.AddAttributeError(path.Root("crds"), "Invalid CRD mode", fmt.Sprintf("Invalid CRD mode: %s", err))
+		return nil, diags
+	}
+	onlyResources, err := compileResourceFilter(parseTfList(ctx, model.OnlyResources, func(resource string) string { return resource }))
+	if err != nil {
+		diags.AddAttributeError(paths.of("only_resources"), "Invalid resource pattern", fmt.Sprintf("Invalid resource pattern: %s", err))
+		return nil, diags
+	}
+	names, err := compileNameSelector(parseTfList(ctx, model.NameSelector, func(name string) string { return name }))
+	if err != nil {
+		diags.AddAttributeError(paths.of("name_selector"), "Invalid name selector", fmt.Sprintf("Invalid name selector: %s", err))
+		return nil, diags
+	}
+	// Cluster-scoped resources are kept unless explicitly excluded
+	clusterScoped := model.IncludeClusterScoped.Null || model.IncludeClusterScoped.Unknown || model.IncludeClusterScoped.Value
+	namespaces, err := compileNamespaceSelector(parseTfList(ctx, model.Namespaces, func(namespace string) string { return namespace }), clusterScoped)
+	if err != nil {
+		diags.AddAttributeError(paths.of("namespaces"), "Invalid namespace pattern", fmt.Sprintf("Invalid namespace pattern: %s", err))
+		return nil, diags
+	}
+	labels, err := compileLabelSelector(model.LabelSelector.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("label_selector"), "Invalid label selector", fmt.Sprintf("Invalid label selector: %s", err))
+		return nil, diags
+	}
+	annotations, err := compileAnnotationSelector(parseTfList(ctx, model.AnnotationSelector, func(requirement string) string { return requirement }))
+	if err != nil {
+		diags.AddAttributeError(paths.of("annotation_selector"), "Invalid annotation selector", fmt.Sprintf("Invalid annotation selector: %s", err))
+		return nil, diags
+	}
+	fields, index, err := compileFieldSelector(model.MatchFields)
+	if err != nil {
+		diags.AddAttributeError(paths.of("match_fields").AtListIndex(index), "Invalid field match", fmt.Sprintf("Invalid field match: %s", err))
+		return nil, diags
+	}
+	jsonPath, err := compileJSONPath(model.JSONPathFilter.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("jsonpath_filter"), "Invalid JSONPath filter", fmt.Sprintf("Invalid JSONPath filter: %s", err))
+		return nil, diags
+	}
+	extract, err := compileJSONPath(model.JSONPathExtract.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("jsonpath_extract"), "Invalid JSONPath extraction", fmt.Sprintf("Invalid JSONPath extraction: %s", err))
+		return nil, diags
+	}
+	expression, err := compileCELFilter(model.CELFilter.Value)
+	if err != nil {
+		diags.AddAttributeError(paths.of("cel_filter"), "Invalid CEL filter", fmt.Sprintf("Invalid CEL filter: %s", err))
+		return nil, diags
+	}
+	variableValues := map[string]string{}
+	diags.Append(model.Variables.ElementsAs(ctx, &variableValues, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	variables, variableName, err := compileVariableSubstituter(variableValues)
+	if err != nil {
+		diags.AddAttributeError(path.Root("variables").AtMapKey(variableName), "Invalid variable", fmt.Sprintf("Invalid variable: %s", err))
+		return nil, diags
+	}
+	renderer, rendererDiags := compileTemplateRenderer(ctx, model.Template)
+	diags.Append(rendererDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	filters := &compiledFilters{
+		selector: &documentSelector{
+			resources:   onlyResources,
+			crds:        crds,
+			names:       names,
+			namespaces:  namespaces,
+			labels:      labels,
+			annotations: annotations,
+			fields:      fields,
+			jsonPath:    jsonPath,
+			expression:  expression,
+		},
+		filter: &documentFilter{
+			dropNulls:  model.DropNulls.Value,
+			convert:    model.ConvertAPIVersions.Value,
+			versions:   versions,
+			allowed:    allowed,
+			attributes: attributes,
+			kinds:      kinds,
+			finalizers: finalizers,
+			stripHelm:  model.StripHelmMetadata.Value,
+			stripCAs:   model.StripCABundles.Value,
+			patches:    patches,
+			overlays:   overlays,
+			containers: containers,
+			replacer:   replacer,
+			replicas:   replicas,
+			services:   services,
+			env:        env,
+			namespace:  namespace,
+			names:      nameAffixes,
+			secrets:    secrets,
+			priority:   priority,
+			secretData: newSecretDataConverter(model.DecodeSecretData.Value, model.EncodeSecretData.Value),
+			redact:     model.RedactSecrets.Value,
+			canonical:  model.Canonicalize.Value,
+			comments:   model.PreserveComments.Value,
+			images:     images,
+			pins:       compileImageDigestPinner(model.PinImages.Value, images),
+			set:        set,
+		},
+		extract:           extract,
+		variables:         variables,
+		template:          renderer,
+		stripServerFields: model.StripServerFields.Value,
+		strict:            model.StrictFilters.Value,
+		paths:             paths,
+	}
+	return filters, diags
+}
+
+// Reports the filtered attribute paths which didn't remove anything from any manifest, as warnings unless strict.
+// Paths which remove nothing are usually typos, except for the server fields which are simply absent from most
+// manifests.
+func (f *compiledFilters) reportUnmatched() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var unmatchedAttributes []string
+	for _, attribute := range f.filter.attributes.unmatched() {
+		if !f.stripServerFields || !contains(serverFields, attribute) {
+			unmatchedAttributes = append(unmatchedAttributes, attribute)
+		}
+	}
+	// Attributes are reported in a fixed order, so the warnings don't change between runs
+	for _, attribute := range []struct {
+		name      string
+		unmatched []string
+	}{{"filtered_attributes", unmatchedAttributes}, {"filtered_attributes_by_kind", f.filter.kinds.unmatched()}} {
+		attribute, unmatched := attribute.name, attribute.unmatched
+		if len(unmatched) == 0 {
+			continue
+		}
+
+		detail := fmt.Sprintf("The following attribute paths didn't match any manifest, so nothing was removed: %s", strings.Join(unmatched, ", "))
+		if f.strict {
+			diags.AddAttributeError(f.paths.of(attribute), "Unmatched attribute paths", detail)
+		} else {
+			diags.AddAttributeWarning(f.paths.of(attribute), "Unmatched attribute paths", detail)
+		}
+	}
+	if unmatched := f.filter.overlays.unmatched(); len(unmatched) > 0 {
+		diags.AddAttributeWarning(path.Root("overlays"), "Unmatched overlays", fmt.Sprintf("The following overlays didn't match any manifest, so they weren't merged: %s", strings.Join(unmatched, ", ")))
+	}
+	if unmatched := f.filter.replicas.unmatched(); len(unmatched) > 0 {
+		diags.AddAttributeWarning(path.Root("replicas"), "Unmatched replicas", fmt.Sprintf("The following workloads didn't match any manifest, so their replicas weren't set: %s", strings.Join(unmatched, ", ")))
+	}
+	if unmatched := f.filter.services.unmatched(); len(unmatched) > 0 {
+		diags.AddAttributeWarning(path.Root("service_type_overrides"), "Unmatched service types", fmt.Sprintf("The following services didn't match any manifest, so their types weren't set: %s", strings.Join(unmatched, ", ")))
+	}
+
+	return diags
+}
+
+// A decoded manifest held in the spool, along with the values extracted from it, the secret keys redacted from it, and
+// the fields it is ordered and deduplicated by
+type decodedManifest struct {
+	ref        documentRef
+	extracted  []string
+	redacted   []string
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+}
+
+// Decodes the manifests within the content, transforming them with the filter before converting them back to YAML and
+// storing them in the spool. Values are extracted from the filtered manifests when a query is provided.
+func decodeManifests(content []byte, format string, selector *documentSelector, filter *documentFilter, extract *jsonPathQuery, spool *documentSpool) ([]decodedManifest, error) {
+	content, err := normalizeText(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each manifest is filtered and re-encoded by a pool of workers as soon as it is decoded, so only a handful are
+	// held in memory at a time. Results are stored by index to preserve the order of the documents.
+	type encodeJob struct {
+		index    int
+		manifest map[any]any
+		source   []byte
+	}
+
+	var (
+		mu        sync.Mutex
+		manifests []decodedManifest
+		encodeErr error
+		wg        sync.WaitGroup
+	)
+
+	workers := runtime.GOMAXPROCS(0)
+	jobs := make(chan encodeJob, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				modified, err := filter.apply(job.manifest)
+				redacted := filter.redactSecrets(job.manifest)
+				modified = modified || len(redacted) > 0
+
+				var ref documentRef
+				var extracted []string
+				if err == nil {
+					extracted, err = extract.extract(job.manifest)
+				}
+				if err == nil {
+					ref, err = spoolManifest(spool, job.manifest, job.source, modified, filter.canonicalizes(), filter.preservesComments())
+				}
+				metadata, _ := job.manifest["metadata"].(map[any]any)
+				decoded := decodedManifest{ref, extracted, redacted, stringValue(job.manifest["apiVersion"]), stringValue(job.manifest["kind"]), stringValue(metadata["namespace"]), stringValue(metadata["name"])}
+
+				mu.Lock()
+				if err != nil && encodeErr == nil {
+					encodeErr = err
+				}
+				manifests[job.index] = decoded
+				mu.Unlock()
+			}
+		}()
+	}
+
+	index := 0
+	err = unmarshalAllManifests(content, format, selector, func(manifest map[any]any, source []byte) error {
+		mu.Lock()
+		manifests = append(manifests, decodedManifest{})
+		mu.Unlock()
+
+		jobs <- encodeJob{index, manifest, source}
+		index++
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+
+	return manifests, nil
+}
+
+// Stores the manifest in the spool. Unless the manifest is canonicalized, untouched YAML documents are passed through
+// as-is, preserving their formatting and comments, while every other manifest is re-encoded, keeping the order of the
+// keys in its source, along with its comments when they are preserved.
+func spoolManifest(spool *documentSpool, manifest map[any]any, source []byte, modified, canonical, comments bool) (documentRef, error) {
+	if canonical {
+		encoded, err := marshalCanonical(manifest)
+		if err != nil {
+			return documentRef{}, err
+		}
+		return spool.add(string(encoded))
+	}
+	if !modified && len(manifest) > 0 && source != nil {
+		return spool.add(string(source))
+	}
+	if comments && source != nil {
+		encoded, err := marshalWithComments(manifest, source)
+		if err != nil {
+			return documentRef{}, err
+		}
+		return spool.add(string(encoded))
+	}
+
+	encoded, err := marshalOrdered(manifest, source)
+	if err != nil {
+		return documentRef{}, err
+	}
+	return spool.add(string(encoded))
+}
+
+// Splits the manifests into chunks of at most the size, preserving their order. No chunks are produced when the size
+// is zero.
+func chunkManifests(manifests []string, size int) [][]string {
+	chunks := [][]string{}
+	if size == 0 {
+		return chunks
+	}
+
+	for start := 0; start < len(manifests); start += size {
+		end := start + size
+		if end > len(manifests) {
+			end = len(manifests)
+		}
+		chunks = append(chunks, manifests[start:end])
+	}
+
+	return chunks
+}
+
+// Derives a stable identifier from the URL, the configuration of how its content is turned into manifests, and the
+// digest of the content. The configuration is hashed in its JSON form, whose map keys are sorted.
+func fetchID(url string, transformations modelV0, contentDigest string) (string, error) {
+	config, err := json.Marshal(transformations)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", url, contentDigest)
+	hash.Write(config)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Returns a copy of the model with only the attributes which decode, filter, or transform the content, leaving out
+// where it is fetched from, how it is fetched, and the computed attributes
+func (model modelV0) transformations() modelV0 {
+	transformations := model
+	transformations.ID = types.String{}
+	transformations.URL = types.String{}
+	transformations.URLs = types.List{}
+	transformations.Path = types.String{}
+	transformations.FallbackURLs = types.List{}
+	transformations.HedgeDelay = types.String{}
+	transformations.Parallelism = types.Int64{}
+	transformations.Triggers = types.Map{}
+	transformations.Pin = types.Bool{}
+	transformations.Manifests = types.List{}
+	transformations.ManifestsByFile = types.Map{}
+	transformations.ManifestsByKind = types.Map{}
+	transformations.ManifestChunks = types.List{}
+	transformations.ExtractedValues = types.List{}
+	transformations.RedactedKeys = types.List{}
+	transformations.ContentSHA256 = types.String{}
+	transformations.Git = nil
+	transformations.GitHubRelease = nil
+	transformations.Cluster = nil
+	transformations.Crawl = nil
+	transformations.VerifySignature = nil
+
+	return transformations
 }
 
 func parseTfList[T any](ctx context.Context, raw types.List, parser func(string) T) []T {
@@ -152,6 +1410,17 @@ func parseTfList[T any](ctx context.Context, raw types.List, parser func(string)
 	return parsed
 }
 
+func countTrue(values ...bool) int {
+	count := 0
+	for _, value := range values {
+		if value {
+			count++
+		}
+	}
+
+	return count
+}
+
 func contains[T comparable](arr []T, needle T) bool {
 	for _, element := range arr {
 		if element == needle {
@@ -162,44 +1431,271 @@ func contains[T comparable](arr []T, needle T) bool {
 	return false
 }
 
-// Unmarshals all manifests in the response
-func unmarshalAllManifests(reader io.Reader, allowedResources []string, manifests *[]map[any]any) error {
-	decoder := yaml.NewDecoder(reader)
-	decoder.SetStrict(true)
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for {
-		var manifest map[any]any
+	return keys
+}
 
-		if err := decoder.Decode(&manifest); err != nil {
-			if err != io.EOF {
-				return err
+// Decodes the manifests in the format one at a time, passing each of the selected manifests to visit along with the
+// YAML it was decoded from. The source is nil for JSON manifests. When the format is auto, JSON is detected by the
+// content starting with an object or array.
+func unmarshalAllManifests(content []byte, format string, selector *documentSelector, visit func(map[any]any, []byte) error) error {
+	var allowed func(manifest map[any]any, source []byte) error
+	allowed = func(manifest map[any]any, source []byte) error {
+		// Lists are expanded into their items, which are selected and visited as though they were separate documents
+		if items, ok := listItems(manifest); ok {
+			for _, item := range items {
+				if err := allowed(item, nil); err != nil {
+					return err
+				}
 			}
-			break
+			return nil
+		}
+
+		if selected, err := selector.selects(manifest); err != nil || !selected {
+			return err
 		}
+		return visit(manifest, source)
+	}
 
-		if allowedResources == nil || contains(allowedResources, fmt.Sprintf("%s/%s", manifest["apiVersion"], manifest["kind"])) {
-			*manifests = append(*manifests, manifest)
+	// JSON is decoded separately since arrays and newline-delimited objects are not valid YAML manifests. Flow-style
+	// YAML starts identically, so it is decoded as YAML when the first value is not valid JSON.
+	trimmed := bytes.TrimSpace(content)
+	looksLikeJSON := len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+	if format == formatJSON || (format != formatYAML && looksLikeJSON) {
+		decoded, err := unmarshalJSONManifests(trimmed, func(manifest map[any]any) error { return allowed(manifest, nil) })
+		if err == nil {
+			return nil
+		} else if format == formatJSON || decoded > 0 {
+			return err
+		}
+	}
+
+	// Each document is decoded separately so its source can be passed through when it isn't modified
+	for _, document := range splitYAMLDocuments(content) {
+		var manifest map[any]any
+		if err := yaml.UnmarshalStrict(document, &manifest); err != nil {
+			return err
+		}
+
+		// Flow-style documents are always re-encoded, so every manifest is in block style
+		source := trimDocument(document)
+		if source[0] == '{' {
+			source = nil
+		}
+
+		if err := allowed(manifest, source); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func removeAttribute(manifest map[any]any, path []string) {
-	if len(path) == 1 {
-		delete(manifest, path[0])
-		return
+// Splits a YAML stream into its documents. Like the YAML decoder, content before the first separator is only a
+// document when it contains more than comments, while every separator starts a document, even an empty one.
+func splitYAMLDocuments(content []byte) [][]byte {
+	var documents [][]byte
+
+	start, explicit := 0, false
+	finish := func(end int) {
+		if document := content[start:end]; explicit || hasYAMLContent(document) {
+			documents = append(documents, document)
+		}
+	}
+
+	for offset := 0; offset < len(content); {
+		end := bytes.IndexByte(content[offset:], '\n') + 1
+		if end == 0 {
+			end = len(content) - offset
+		}
+		line := content[offset : offset+end]
+
+		switch {
+		case isYAMLDocumentStart(line):
+			finish(offset)
+			// Content following the separator on the same line belongs to the document
+			start, explicit = offset+3, true
+		case isYAMLDocumentEnd(line):
+			finish(offset)
+			start, explicit = offset+end, false
+		}
+
+		offset += end
+	}
+	finish(len(content))
+
+	return documents
+}
+
+func isYAMLDocumentStart(line []byte) bool {
+	line = bytes.TrimRight(line, "\r\n")
+	return bytes.Equal(line, []byte("---")) || bytes.HasPrefix(line, []byte("--- ")) || bytes.HasPrefix(line, []byte("---\t"))
+}
+
+func isYAMLDocumentEnd(line []byte) bool {
+	line = bytes.TrimRight(line, "\r\n")
+	return bytes.Equal(line, []byte("...")) || bytes.HasPrefix(line, []byte("... ")) || bytes.HasPrefix(line, []byte("...\t"))
+}
+
+// Whether the document contains anything other than whitespace and comments
+func hasYAMLContent(document []byte) bool {
+	for _, line := range bytes.Split(document, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 && line[0] != '#' {
+			return true
+		}
 	}
+	return false
+}
 
-	if sub, ok := manifest[path[0]].(map[any]any); ok {
-		removeAttribute(sub, path[1:])
+// Removes the blank lines around a document, ensuring it ends with a single newline
+func trimDocument(document []byte) []byte {
+	lines := bytes.Split(document, []byte("\n"))
+	for len(lines) > 0 && len(bytes.TrimSpace(lines[0])) == 0 {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && len(bytes.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	return append(bytes.Join(lines, []byte("\n")), '\n')
+}
+
+// Decodes a stream of JSON values, each of which is either a manifest or an array of manifests, passing each manifest
+// to visit as it is decoded. The number of manifests decoded before any error is returned alongside it.
+func unmarshalJSONManifests(content []byte, visit func(map[any]any) error) (int, error) {
+	decoded := 0
+	decodeDocument := func(document json.RawMessage) error {
+		// JSON is a subset of YAML, producing the same representation as YAML input
+		var manifest map[any]any
+		if err := yaml.UnmarshalStrict(document, &manifest); err != nil {
+			return err
+		}
+
+		decoded++
+		return visit(manifest)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err != io.EOF {
+				return decoded, err
+			}
+			return decoded, nil
+		}
+
+		if raw[0] != '[' {
+			if err := decodeDocument(raw); err != nil {
+				return decoded, err
+			}
+			continue
+		}
+
+		// Arrays are walked element by element rather than unmarshalled as a whole
+		elements := json.NewDecoder(bytes.NewReader(raw))
+		if _, err := elements.Token(); err != nil {
+			return decoded, err
+		}
+		for elements.More() {
+			var document json.RawMessage
+			if err := elements.Decode(&document); err != nil {
+				return decoded, err
+			}
+			if err := decodeDocument(document); err != nil {
+				return decoded, err
+			}
+		}
 	}
 }
 
 type modelV0 struct {
-	ID                 types.String `tfsdk:"id"`
-	URL                types.String `tfsdk:"url"`
-	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
-	OnlyResources      types.List   `tfsdk:"only_resources"`
-	Manifests          types.List   `tfsdk:"manifests"`
+	ID                   types.String `tfsdk:"id"`
+	URL                  types.String `tfsdk:"url"`
+	URLs                 types.List   `tfsdk:"urls"`
+	Path                 types.String `tfsdk:"path"`
+	FallbackURLs         types.List   `tfsdk:"fallback_urls"`
+	HedgeDelay           types.String `tfsdk:"hedge_delay"`
+	Parallelism          types.Int64  `tfsdk:"parallelism"`
+	ChunkSize            types.Int64  `tfsdk:"chunk_size"`
+	Triggers             types.Map    `tfsdk:"triggers"`
+	Pin                  types.Bool   `tfsdk:"pin"`
+	FileGlob             types.String `tfsdk:"file_glob"`
+	Format               types.String `tfsdk:"format"`
+	Order                types.String `tfsdk:"order"`
+	SortBy               types.List   `tfsdk:"sort_by"`
+	Deduplicate          types.String `tfsdk:"deduplicate"`
+	FailOnDuplicates     types.Bool   `tfsdk:"fail_on_duplicates"`
+	CRDs                 types.String `tfsdk:"crds"`
+	BodyEncoding         types.String `tfsdk:"body_encoding"`
+	FilteredAttributes   types.List   `tfsdk:"filtered_attributes"`
+	StripServerFields    types.Bool   `tfsdk:"strip_server_fields"`
+	StripFinalizers      types.Bool   `tfsdk:"strip_finalizers"`
+	StripHelmMetadata    types.Bool   `tfsdk:"strip_helm_metadata"`
+	StripCABundles       types.Bool   `tfsdk:"strip_ca_bundles"`
+	DecodeSecretData     types.Bool   `tfsdk:"decode_secret_data"`
+	EncodeSecretData     types.Bool   `tfsdk:"encode_secret_data"`
+	RedactSecrets        types.Bool   `tfsdk:"redact_secrets"`
+	Canonicalize         types.Bool   `tfsdk:"canonicalize"`
+	PreserveComments     types.Bool   `tfsdk:"preserve_comments"`
+	Variables            types.Map    `tfsdk:"variables"`
+	FinalizerResources   types.List   `tfsdk:"strip_finalizers_resources"`
+	PruneEmpty           types.Bool   `tfsdk:"prune_empty"`
+	DropNulls            types.Bool   `tfsdk:"drop_nulls"`
+	StrictFilters        types.Bool   `tfsdk:"strict_filters"`
+	SetAttributes        types.Map    `tfsdk:"set_attributes"`
+	Namespace            types.String `tfsdk:"namespace"`
+	DefaultNamespace     types.String `tfsdk:"default_namespace"`
+	ClusterScoped        types.List   `tfsdk:"cluster_scoped_resources"`
+	NamePrefix           types.String `tfsdk:"name_prefix"`
+	NameSuffix           types.String `tfsdk:"name_suffix"`
+	RenameReferences     types.Bool   `tfsdk:"rename_references"`
+	PinImages            types.Bool   `tfsdk:"pin_images_to_digest"`
+	RemoveContainers     types.List   `tfsdk:"remove_containers"`
+	ImagePullSecrets     types.List   `tfsdk:"image_pull_secrets"`
+	PriorityClassName    types.String `tfsdk:"priority_class_name"`
+	Overlays             types.List   `tfsdk:"overlays"`
+	Replicas             types.Map    `tfsdk:"replicas"`
+	ServiceTypes         types.Map    `tfsdk:"service_type_overrides"`
+	APIVersions          types.Map    `tfsdk:"api_version_overrides"`
+	ConvertAPIVersions   types.Bool   `tfsdk:"convert_deprecated_api_versions"`
+	FilteredByKind       types.Map    `tfsdk:"filtered_attributes_by_kind"`
+	AllowedAttributes    types.List   `tfsdk:"allowed_attributes"`
+	OnlyResources        types.List   `tfsdk:"only_resources"`
+	NameSelector         types.List   `tfsdk:"name_selector"`
+	Namespaces           types.List   `tfsdk:"namespaces"`
+	IncludeClusterScoped types.Bool   `tfsdk:"include_cluster_scoped"`
+	LabelSelector        types.String `tfsdk:"label_selector"`
+	AnnotationSelector   types.List   `tfsdk:"annotation_selector"`
+	CELFilter            types.String `tfsdk:"cel_filter"`
+	JSONPathFilter       types.String `tfsdk:"jsonpath_filter"`
+	JSONPathExtract      types.String `tfsdk:"jsonpath_extract"`
+	Manifests            types.List   `tfsdk:"manifests"`
+	ManifestsByFile      types.Map    `tfsdk:"manifests_by_file"`
+	ManifestsByKind      types.Map    `tfsdk:"manifests_by_kind"`
+	ManifestChunks       types.List   `tfsdk:"manifest_chunks"`
+	ExtractedValues      types.List   `tfsdk:"extracted_values"`
+	RedactedKeys         types.List   `tfsdk:"redacted_secret_keys"`
+	ContentSHA256        types.String `tfsdk:"content_sha256"`
+
+	Git                 []gitSourceModel           `tfsdk:"git"`
+	GitHubRelease       []githubReleaseSourceModel `tfsdk:"github_release"`
+	Cluster             []clusterSourceModel       `tfsdk:"cluster"`
+	Crawl               []crawlSourceModel         `tfsdk:"crawl"`
+	VerifySignature     []verifySignatureModel     `tfsdk:"verify_signature"`
+	MatchFields         []matchFieldModel          `tfsdk:"match_fields"`
+	Filter              []filterBlockModel         `tfsdk:"filter"`
+	EnvInjections       []envInjectionModel        `tfsdk:"env_injections"`
+	ImageRewrites       []imageRewriteModel        `tfsdk:"image_rewrites"`
+	JSONPatches         []jsonPatchModel           `tfsdk:"json_patches"`
+	Patches             []patchModel               `tfsdk:"patch"`
+	Replacements        []replacementModel         `tfsdk:"replacements"`
+	PriorityClassTarget []patchTargetModel         `tfsdk:"priority_class_target"`
+	Template            []templateModel            `tfsdk:"template"`
 }
@@ -1,14 +1,24 @@
 package provider
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestDataSource_SingleDocument_Unfiltered(t *testing.T) {
@@ -23,6 +33,7 @@ func TestDataSource_SingleDocument_Unfiltered(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
 					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "0"),
 				),
 			},
 		},
@@ -67,6 +78,60 @@ func TestDataSource_SingleDocument_Filtered(t *testing.T) {
 	})
 }
 
+func TestDataSource_StripServerFields(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(stripServerFieldsStatement, server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nmetadata:\n  annotations:\n    hello: world\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_PruneEmpty(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(pruneEmptyStatement, server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nspec:\n  some: key\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_DropNulls(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(dropNullsStatement, server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nmetadata:\n  annotations:\n    hello: world\nspec:\n  some: key\nstatus:\n  abc: def\n  bool: true\n"),
+				),
+			},
+		},
+	})
+}
+
 func TestDataSource_MultipleDocuments_Filtered(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
@@ -75,18 +140,723 @@ func TestDataSource_MultipleDocuments_Filtered(t *testing.T) {
 		ProtoV6ProviderFactories: providerFactories(),
 		Steps: []resource.TestStep{
 			{
-				Config: fmt.Sprintf(filteredResourceStatement, server.URL, "multiple"),
+				Config: fmt.Sprintf(filteredResourceStatement, server.URL, "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", "apiVersion: testing.k8s.io/v1\nkind: test\nmetadata: {}\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", multipleDocument3)),
+			},
+		},
+	})
+}
+
+func TestDataSource_OnlyResources(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(onlyResourcesStatement, server.URL, "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1)),
+			},
+		},
+	})
+}
+
+func TestDataSource_OnlyResources_Wildcard(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(onlyResourcesPatternStatement, server.URL, "multiple", "*/test"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument2)),
+			},
+			{
+				Config: fmt.Sprintf(onlyResourcesPatternStatement, server.URL, "multiple", "testing.k8s.io/*/T*"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1)),
+			},
+		},
+	})
+}
+
+func TestDataSource_OnlyResources_Negation(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(onlyResourcesPatternStatement, server.URL, "multiple", "!testing.k8s.io/v1/Test"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument2)),
+			},
+			{
+				Config: fmt.Sprintf(onlyResourcesPatternStatement, server.URL, "multiple", `testing.k8s.io/*/*", "!*/test`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1)),
+			},
+		},
+	})
+}
+
+func TestDataSource_NameSelector(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(nameSelectorStatement, server.URL, "named", "cert-manager-webhook"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager-webhook"))),
+			},
+			{
+				Config: fmt.Sprintf(nameSelectorStatement, server.URL, "named", "cert-manager-*"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+			},
+			{
+				Config: fmt.Sprintf(nameSelectorStatement, server.URL, "named", "/^cert-manager(-cainjector)?$/"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-cainjector"))),
+			},
+		},
+	})
+}
+
+func TestDataSource_NameSelector_Invalid(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(nameSelectorStatement, "https://example.com", "manifest.yaml", "/(/"),
+				ExpectError: regexp.MustCompile(`Invalid name selector: "/\(/" is not a valid regular expression`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Namespaces(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(namespacesStatement, server.URL, `"team-*", "!team-b"`, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namespacedDocument("team-a")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namespacedDocument(""))),
+			},
+			{
+				Config: fmt.Sprintf(namespacesStatement, server.URL, `"!kube-system"`, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namespacedDocument("team-a")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namespacedDocument("team-b"))),
+			},
+		},
+	})
+}
+
+func TestDataSource_LabelSelector(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(labelSelectorStatement, server.URL, "app.kubernetes.io/part-of=istio,component!=pilot"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", labeledDocument("gateway"))),
+			},
+			{
+				Config:      fmt.Sprintf(labelSelectorStatement, server.URL, "component in (pilot"),
+				ExpectError: regexp.MustCompile("Invalid label selector"),
+			},
+		},
+	})
+}
+
+func TestDataSource_AnnotationSelector(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(annotationSelectorStatement, server.URL, `"!helm.sh/hook"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", annotatedDocument("deployment", "")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", singleDocument)),
+			},
+			{
+				Config: fmt.Sprintf(annotationSelectorStatement, server.URL, `"helm.sh/hook=pre-install"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", annotatedDocument("job", "pre-install"))),
+			},
+			{
+				Config:      fmt.Sprintf(annotationSelectorStatement, server.URL, `"=true"`),
+				ExpectError: regexp.MustCompile(`Invalid annotation selector: "=true" has no annotation key`),
+			},
+		},
+	})
+}
+
+func TestDataSource_OnlyResources_InvalidPattern(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(onlyResourcesPatternStatement, "https://example.com", "manifest.yaml", "apps/v1/[Deployment"),
+				ExpectError: regexp.MustCompile(`Invalid resource pattern: "apps/v1/\[Deployment" is not a valid pattern`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Failure(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, server.URL, "failure"),
+				ExpectError: regexp.MustCompile("Received non-success response code: 500"),
+			},
+		},
+	})
+}
+
+func TestDataSource_ContentDigest(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	var unfilteredID string
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "content_sha256", sha256Hex([]byte(singleDocument))),
+					resource.TestCheckResourceAttrWith("data.manifest_fetch.test", "id", func(value string) error {
+						unfilteredID = value
+						return nil
+					}),
+				),
+			},
+			{
+				Config: fmt.Sprintf(filteredResourceStatement, server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "content_sha256", sha256Hex([]byte(singleDocument))),
+					resource.TestCheckResourceAttrWith("data.manifest_fetch.test", "id", func(value string) error {
+						if value == "" || value == unfilteredID {
+							return fmt.Errorf("expected the ID to change with the filters, got %q", value)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestFetchID(t *testing.T) {
+	model := modelV0{
+		URL:                types.String{Value: "https://example.com/manifests.yaml"},
+		FilteredAttributes: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "metadata.uid"}}},
+		Variables:          types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{"b": types.String{Value: "2"}, "a": types.String{Value: "1"}}},
+	}
+	id := func(model modelV0, contentDigest string) string {
+		id, err := fetchID(model.URL.Value, model.transformations(), contentDigest)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return id
+	}
+	expected := id(model, "digest")
+
+	fetchedDifferently := model
+	fetchedDifferently.Parallelism = types.Int64{Value: 8}
+	fetchedDifferently.Triggers = types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{"version": types.String{Value: "1"}}}
+	fetchedDifferently.Manifests = types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: singleDocument}}}
+	if actual := id(fetchedDifferently, "digest"); actual != expected {
+		t.Errorf("expected attributes which don't transform the content to keep the ID %s, got %s", expected, actual)
+	}
+
+	filteredDifferently := model
+	filteredDifferently.StripFinalizers = types.Bool{Value: true}
+	for name, actual := range map[string]string{
+		"filters": id(filteredDifferently, "digest"),
+		"content": id(model, "other"),
+		"url":     id(modelV0{URL: types.String{Value: "https://example.com/other.yaml"}, FilteredAttributes: model.FilteredAttributes, Variables: model.Variables}, "digest"),
+	} {
+		if actual == expected {
+			t.Errorf("expected the ID to change with the %s", name)
+		}
+	}
+}
+
+func TestDataSource_FileURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(multipleDocuments), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(onlyResourcesStatement, "file:/", filepath.ToSlash(path)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Path(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(path, []byte(singleDocument), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(pathResourceStatement, filepath.ToSlash(path)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nmetadata:\n  annotations:\n    hello: world\nspec:\n  some: key\n"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(pathResourceStatement, filepath.ToSlash(path)+".missing"),
+				ExpectError: regexp.MustCompile("Error reading file"),
+			},
+		},
+	})
+}
+
+func TestDataSource_MissingSource(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      `data "manifest_fetch" "test" {}`,
+				ExpectError: regexp.MustCompile(`Exactly\s+one\s+of\s+url,\s+urls,\s+path,\s+git,\s+github_release,\s+cluster,\s+or\s+crawl\s+must\s+be\s+set`),
+			},
+		},
+	})
+}
+
+func TestDataSource_FallbackURLs(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(fallbackResourceStatement, server.URL, "failure", server.URL, "missing", server.URL, "single"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_FallbackURLs_Failure(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(fallbackResourceStatement, server.URL, "failure", server.URL, "failure", server.URL, "missing"),
+				ExpectError: regexp.MustCompile(`Received non-success response code: 404 \(http://[^)]+/missing\)`),
+			},
+		},
+	})
+}
+
+func TestDataSource_HedgedFallbackURLs(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(hedgedResourceStatement, server.URL, "slow", server.URL, "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_URLs(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(urlsResourceStatement, server.URL, "single", server.URL, "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "4"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file."+server.URL+"/multiple.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_URLs_Failure(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(urlsResourceStatement, server.URL, "single", server.URL, "missing"),
+				ExpectError: regexp.MustCompile(`Received non-success response code: 404 \(http://[^)]+/missing\)`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Parallelism(t *testing.T) {
+	var active, peak int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+		for {
+			previous := atomic.LoadInt64(&peak)
+			if current <= previous || atomic.CompareAndSwapInt64(&peak, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("%q", fmt.Sprintf("%s/%d", server.URL, i)))
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(parallelismResourceStatement, strings.Join(urls, ", "), 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "6"),
+					func(*terraform.State) error {
+						if peak := atomic.LoadInt64(&peak); peak != 2 {
+							return fmt.Errorf("expected 2 concurrent requests, got %d", peak)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_InvalidParallelism(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(parallelismResourceStatement, `"https://example.com/manifest.yaml"`, 0),
+				ExpectError: regexp.MustCompile(`Invalid\s+parallelism\s+0,\s+must\s+be\s+at\s+least\s+1`),
+			},
+		},
+	})
+}
+
+func TestDataSource_ChunkSize(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(chunkSizeResourceStatement, server.URL, "multiple", 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.0.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.0.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.0.1", multipleDocument2),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.1.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.1.0", multipleDocument3),
+				),
+			},
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "multiple"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifest_chunks.#", "0"),
+			},
+		},
+	})
+}
+
+func TestDataSource_ManifestsByKind(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "operator"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_kind.%", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_kind.CustomResourceDefinition.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_kind.CustomResourceDefinition.0", definitionDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_kind.Certificate.0", customResourceDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_InvalidChunkSize(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(chunkSizeResourceStatement, "https://example.com", "manifest.yaml", 0),
+				ExpectError: regexp.MustCompile(`Invalid\s+chunk\s+size\s+0,\s+must\s+be\s+at\s+least\s+1`),
+			},
+		},
+	})
+}
+
+func TestDataSource_InvalidHedgeDelay(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(strings.Replace(hedgedResourceStatement, "10ms", "soon", 1), server.URL, "slow", server.URL, "multiple"),
+				ExpectError: regexp.MustCompile("Invalid hedge delay"),
+			},
+		},
+	})
+}
+
+func TestDataSource_JSON(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "json"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_JSONArray(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	for _, path := range []string{"json-array", "ndjson"} {
+		t.Run(path, func(t *testing.T) {
+			resource.UnitTest(t, resource.TestCase{
+				ProtoV6ProviderFactories: providerFactories(),
+				Steps: []resource.TestStep{
+					{
+						Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, path),
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument2),
+							resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", multipleDocument3),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestDataSource_ByteOrderMarkAndCRLF(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "windows"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument2),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Base64BodyEncoding(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(bodyEncodingResourceStatement, server.URL, "base64", "base64"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+			{
+				Config: fmt.Sprintf(bodyEncodingResourceStatement, server.URL, "contents", "base64"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(bodyEncodingResourceStatement, server.URL, "multiple", "base64"),
+				ExpectError: regexp.MustCompile("Error decoding response body"),
+			},
+			{
+				Config:      fmt.Sprintf(bodyEncodingResourceStatement, server.URL, "multiple", "hex"),
+				ExpectError: regexp.MustCompile("Invalid body encoding"),
+			},
+		},
+	})
+}
+
+func TestDataSource_FlowStyleYAML(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "flow"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_FilteredListElements(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(filteredPathStatement, server.URL, "formatted", "spec.items[0]"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\nspec:\n  items:\n  - b\n")),
+			},
+			{
+				Config: fmt.Sprintf(filteredPathStatement, server.URL, "annotated", `metadata.annotations.\"kubectl.kubernetes.io/last-applied-configuration\"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  annotations:\n    example.com/kept: \"true\"\n")),
+			},
+			{
+				Config:      fmt.Sprintf(filteredPathStatement, server.URL, "formatted", "spec.items[first]"),
+				ExpectError: regexp.MustCompile(`Invalid attribute path: "spec.items\[first\]" has an invalid list index`),
+			},
+		},
+	})
+}
+
+func TestDataSource_FilteredAttributesByKind(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(filteredByKindStatement, server.URL, "hooks", "batch/v1/Job", "metadata.annotations"),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
-					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: testing.k8s.io/v1\nkind: Test\n"),
-					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", "apiVersion: testing.k8s.io/v1\nkind: test\nmetadata: {}\n"),
-					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", multipleDocument3)),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: job\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", annotatedDocument("deployment", "")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", "apiVersion: testing.k8s.io/v1\nkind: Test\nmetadata:\n  annotations:\n    hello: world\n  creationTimestamp: null\nspec:\n  some: key\n")),
+			},
+			{
+				Config:      fmt.Sprintf(filteredByKindStatement, server.URL, "hooks", "Job", "metadata.annotations"),
+				ExpectError: regexp.MustCompile(`"Job" is not a valid resource pattern`),
 			},
 		},
 	})
 }
 
-func TestDataSource_OnlyResources(t *testing.T) {
+func TestDataSource_AllowedAttributes(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
 
@@ -94,16 +864,21 @@ func TestDataSource_OnlyResources(t *testing.T) {
 		ProtoV6ProviderFactories: providerFactories(),
 		Steps: []resource.TestStep{
 			{
-				Config: fmt.Sprintf(onlyResourcesStatement, server.URL, "multiple"),
+				Config: fmt.Sprintf(allowedAttributesStatement, server.URL, "labeled", "metadata.labels.component"),
 				Check: resource.ComposeTestCheckFunc(
-					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
-					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1)),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  labels:\n    component: pilot\n  name: pilot\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", "apiVersion: testing.k8s.io/v1\nkind: Test\n")),
+			},
+			{
+				Config:      fmt.Sprintf(allowedAttributesStatement, server.URL, "labeled", "spec.items[first]"),
+				ExpectError: regexp.MustCompile(`Invalid attribute path: "spec.items\[first\]" has an invalid list index`),
 			},
 		},
 	})
 }
 
-func TestDataSource_Failure(t *testing.T) {
+func TestDataSource_StrictFilters(t *testing.T) {
 	server := setupMockServer()
 	defer server.Close()
 
@@ -111,13 +886,168 @@ func TestDataSource_Failure(t *testing.T) {
 		ProtoV6ProviderFactories: providerFactories(),
 		Steps: []resource.TestStep{
 			{
-				Config:      fmt.Sprintf(unfilteredResourceStatement, server.URL, "failure"),
-				ExpectError: regexp.MustCompile("Received non-success response code: 500"),
+				Config: fmt.Sprintf(strictFiltersStatement, server.URL, "metadata.creationTimestamp"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1")),
+			},
+			{
+				Config:      fmt.Sprintf(strictFiltersStatement, server.URL, "metadta.creationTimestamp"),
+				ExpectError: regexp.MustCompile(`didn't match any manifest, so nothing was removed: metadta.creationTimestamp`),
+			},
+		},
+	})
+}
+
+func TestDataSource_PassesThroughUntouchedDocuments(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(filteredResourceStatement, server.URL, "formatted"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", formattedDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", "apiVersion: testing.k8s.io/v1\nkind: Test\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	tests := map[string][]string{
+		"kind: A\n":                         {"kind: A\n"},
+		"---\nkind: A\n---\nkind: B\n":      {"\nkind: A\n", "\nkind: B\n"},
+		"# header\n---\nkind: A\n":          {"\nkind: A\n"},
+		"kind: A\n---\n":                    {"kind: A\n", "\n"},
+		"kind: A\n...\n---\nkind: B\n":      {"kind: A\n", "\nkind: B\n"},
+		"--- # comment\nkind: A\n":          {" # comment\nkind: A\n"},
+		"kind: A\ndata: |\n  ---\n  text\n": {"kind: A\ndata: |\n  ---\n  text\n"},
+	}
+
+	for content, expected := range tests {
+		var actual []string
+		for _, document := range splitYAMLDocuments([]byte(content)) {
+			actual = append(actual, string(document))
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%q: expected %q, got %q", content, expected, actual)
+		}
+	}
+}
+
+func TestDataSource_Format_ContentTypeMismatch(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, server.URL, "mislabelled"),
+				ExpectError: regexp.MustCompile(`not\s+valid\s+JSON,\s+as\s+declared\s+by\s+its\s+Content-Type\s+"application/json"`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Format_Override(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(formatResourceStatement, server.URL, "mislabelled", "yaml"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(formatResourceStatement, server.URL, "multiple", "json"),
+				ExpectError: regexp.MustCompile(`not\s+valid\s+JSON,\s+as\s+selected\s+by\s+format`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Format_Invalid(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(formatResourceStatement, server.URL, "multiple", "toml"),
+				ExpectError: regexp.MustCompile("Invalid format"),
 			},
 		},
 	})
 }
 
+func TestUnmarshalAllManifests_StopsAtVisitError(t *testing.T) {
+	contents := map[string]string{
+		formatYAML: multipleDocuments,
+		formatJSON: "[" + strings.Join(multipleJSONDocuments, ",") + "]",
+	}
+
+	for format, content := range contents {
+		visited := 0
+		err := unmarshalAllManifests([]byte(content), format, nil, func(map[any]any, []byte) error {
+			visited++
+			if visited == 2 {
+				return errors.New("stop")
+			}
+			return nil
+		})
+
+		if err == nil || err.Error() != "stop" {
+			t.Errorf("%s: expected the visit error, got %v", format, err)
+		}
+		if visited != 2 {
+			t.Errorf("%s: expected decoding to stop after 2 manifests, visited %d", format, visited)
+		}
+	}
+}
+
+func TestDecodeManifests_PreservesOrder(t *testing.T) {
+	var documents []string
+	for i := 0; i < 200; i++ {
+		documents = append(documents, fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config-%d\n  uid: abc\n", i))
+	}
+
+	// Spill the documents after the first few to ensure they're read back correctly
+	spool := newDocumentSpool(&spillOptions{threshold: 256, dir: t.TempDir()})
+	defer spool.close()
+
+	attributes, _ := compileAttributeFilter([]string{"metadata.uid"}, false)
+	decoded, err := decodeManifests([]byte(strings.Join(documents, "---\n")), formatAuto, nil, &documentFilter{attributes: attributes}, nil, spool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(documents) {
+		t.Fatalf("expected %d manifests, got %d", len(documents), len(decoded))
+	}
+	for i, document := range decoded {
+		manifest, err := spool.read(document.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config-%d\n", i)
+		if manifest != expected {
+			t.Fatalf("manifest %d: expected %q, got %q", i, expected, manifest)
+		}
+	}
+}
+
 func setupMockServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -127,6 +1057,70 @@ func setupMockServer() *httptest.Server {
 		case "/multiple":
 			w.WriteHeader(http.StatusOK)
 			_, _ = w.Write([]byte(multipleDocuments))
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(singleDocument))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(singleJSONDocument))
+		case "/json-array":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("[" + strings.Join(multipleJSONDocuments, ",") + "]"))
+		case "/ndjson":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = w.Write([]byte(strings.Join(multipleJSONDocuments, "\n") + "\n"))
+		case "/mislabelled":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(multipleDocuments))
+		case "/windows":
+			_, _ = w.Write([]byte("\ufeff" + strings.ReplaceAll(multipleDocuments, "\n", "\r\n")))
+		case "/base64":
+			encoded := base64.StdEncoding.EncodeToString([]byte(multipleDocuments))
+			_, _ = w.Write([]byte(encoded[:40] + "\n" + encoded[40:] + "\n"))
+		case "/contents":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"name": "single.yaml", "encoding": "base64", "content": %q}`, base64.StdEncoding.EncodeToString([]byte(singleDocument)))
+		case "/commented":
+			_, _ = w.Write([]byte(commentedDocument))
+		case "/named":
+			_, _ = w.Write([]byte(strings.Join([]string{namedDocument("cert-manager"), namedDocument("cert-manager-webhook"), namedDocument("cert-manager-cainjector")}, "---\n")))
+		case "/namespaced":
+			_, _ = w.Write([]byte(strings.Join([]string{namespacedDocument("team-a"), namespacedDocument("team-b"), namespacedDocument(""), namespacedDocument("kube-system")}, "---\n")))
+		case "/labeled":
+			_, _ = w.Write([]byte(strings.Join([]string{labeledDocument("pilot"), labeledDocument("gateway"), singleDocument}, "---\n")))
+		case "/hooks":
+			_, _ = w.Write([]byte(strings.Join([]string{annotatedDocument("job", "pre-install"), annotatedDocument("deployment", ""), singleDocument}, "---\n")))
+		case "/bundle":
+			_, _ = w.Write([]byte(bundleBinding + "---\n" + bundleServiceAccount))
+		case "/workload":
+			_, _ = w.Write([]byte(workloadDocument))
+		case "/conflicting":
+			_, _ = w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: b\n"))
+		case "/operator":
+			_, _ = w.Write([]byte(definitionDocument + "---\n" + customResourceDocument))
+		case "/template":
+			_, _ = w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"{{ .name }}\"\n"))
+		case "/templated":
+			_, _ = w.Write([]byte(templatedDocument))
+		case "/list":
+			_, _ = w.Write([]byte(listDocument))
+		case "/secret":
+			_, _ = w.Write([]byte(secretDocument))
+		case "/ingress":
+			_, _ = w.Write([]byte(ingressDocument))
+		case "/webhook":
+			_, _ = w.Write([]byte(webhookDocument))
+		case "/finalized":
+			_, _ = w.Write([]byte(finalizedDocument))
+		case "/service":
+			_, _ = w.Write([]byte(serviceDocument))
+		case "/annotated":
+			_, _ = w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  annotations:\n    example.com/kept: \"true\"\n    kubectl.kubernetes.io/last-applied-configuration: '{}'\n"))
+		case "/flow":
+			_, _ = w.Write([]byte("{apiVersion: testing.k8s.io/v1, kind: Test, status: hello}\n"))
+		case "/formatted":
+			_, _ = w.Write([]byte(formattedDocument + "---\n" + multipleDocument1))
 		case "/failure":
 			w.WriteHeader(http.StatusInternalServerError)
 			_, _ = w.Write([]byte("error"))
@@ -164,13 +1158,185 @@ spec:
   un: changed
 `
 
+const bundleBinding = `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: app
+roleRef:
+  kind: Role
+  name: app
+subjects:
+- kind: ServiceAccount
+  name: app
+`
+const bundleServiceAccount = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+`
+const workloadDocument = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - image: quay.io/jetstack/cert-manager-controller:v1.14.0
+        name: controller
+      initContainers:
+      - image: busybox
+        name: init
+`
+
+const definitionDocument = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+`
+
+const customResourceDocument = `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: app
+`
+
+const templatedDocument = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${NAME}
+  namespace: ${NAMESPACE:-default}
+data:
+  script: echo $HOME ${HOME}
+`
+
+const listDocument = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ServiceAccount
+  metadata:
+    name: app
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app
+`
+
+const secretDocument = `apiVersion: v1
+data:
+  password: aHVudGVyMg==
+kind: Secret
+metadata:
+  name: credentials
+`
+
+const ingressDocument = `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: app
+spec:
+  rules:
+  - host: app.example.com
+    http:
+      paths:
+      - backend:
+          serviceName: app
+          servicePort: 80
+        path: /
+`
+
+const webhookDocument = `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: webhook
+webhooks:
+- clientConfig:
+    caBundle: Y2VydGlmaWNhdGU=
+    service:
+      name: webhook
+      namespace: default
+  name: webhook.example.com
+`
+
+const finalizedDocument = `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  finalizers:
+  - kubernetes.io/pvc-protection
+  name: data
+`
+
+const serviceDocument = `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  loadBalancerSourceRanges:
+  - 10.0.0.0/8
+  ports:
+  - nodePort: 30443
+    port: 443
+  type: LoadBalancer
+`
+
 var multipleDocuments = strings.Join([]string{multipleDocument1, multipleDocument2, multipleDocument3}, "\n---\n")
 
+func namedDocument(name string) string {
+	return fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: %s\n", name)
+}
+
+func namespacedDocument(namespace string) string {
+	if namespace == "" {
+		return "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: team-a\n"
+	}
+	return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: %s\n", namespace)
+}
+
+func labeledDocument(component string) string {
+	return fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  labels:\n    app.kubernetes.io/part-of: istio\n    component: %s\n  name: %s\n", component, component)
+}
+
+func annotatedDocument(name, hook string) string {
+	if hook == "" {
+		return fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  annotations:\n    example.com/managed: \"true\"\n  name: %s\n", name)
+	}
+	return fmt.Sprintf("apiVersion: batch/v1\nkind: Job\nmetadata:\n  annotations:\n    helm.sh/hook: %s\n  name: %s\n", hook, name)
+}
+
+// Not in the order or style the YAML encoder would produce
+const formattedDocument = `# The upstream comment is preserved
+kind: Test
+apiVersion: testing.k8s.io/v1
+spec:
+  items: [a, b]
+`
+
+const singleJSONDocument = `{"apiVersion": "testing.k8s.io/v1", "kind": "Test", "metadata": {"annotations": {"hello": "world"}, "creationTimestamp": null}, "spec": {"some": "key"}, "status": {"abc": "def", "bool": true}}`
+
+var multipleJSONDocuments = []string{
+	`{"apiVersion": "testing.k8s.io/v1", "kind": "Test", "status": "hello"}`,
+	`{"apiVersion": "testing.k8s.io/v1", "kind": "test", "metadata": {"creationTimestamp": null}}`,
+	`{"apiVersion": "testing.k8s.io/v1", "kind": "test", "spec": {"un": "changed"}}`,
+}
+
 const unfilteredResourceStatement = `
 data "manifest_fetch" "test" {
 	url = "%s/%s"
 }`
 
+const bodyEncodingResourceStatement = `
+data "manifest_fetch" "test" {
+	url           = "%s/%s"
+	body_encoding = "%s"
+}`
+
+const formatResourceStatement = `
+data "manifest_fetch" "test" {
+	url    = "%s/%s"
+	format = "%s"
+}`
+
 const filteredResourceStatement = `
 data "manifest_fetch" "test" {
 	url = "%s/%s"
@@ -180,6 +1346,58 @@ data "manifest_fetch" "test" {
 	]
 }`
 
+const stripServerFieldsStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/%s"
+	strip_server_fields = true
+	filtered_attributes = ["spec"]
+}`
+
+const pruneEmptyStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/%s"
+	strip_server_fields = true
+	prune_empty         = true
+	filtered_attributes = ["metadata.annotations.hello"]
+}`
+
+const dropNullsStatement = `
+data "manifest_fetch" "test" {
+	url        = "%s/%s"
+	drop_nulls = true
+}`
+
+const strictFiltersStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/single"
+	strict_filters      = true
+	filtered_attributes = ["status", "%s"]
+}`
+
+const filteredPathStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/%s"
+	filtered_attributes = ["%s"]
+}
+`
+
+const filteredByKindStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+	filtered_attributes_by_kind = {
+		"%s" = ["%s"]
+		"*"  = ["status"]
+	}
+}
+`
+
+const allowedAttributesStatement = `
+data "manifest_fetch" "test" {
+	url                = "%s/%s"
+	allowed_attributes = ["%s"]
+}
+`
+
 const onlyResourcesStatement = `
 data "manifest_fetch" "test" {
 	url = "%s/%s"
@@ -188,3 +1406,94 @@ data "manifest_fetch" "test" {
 	]
 }
 `
+
+const onlyResourcesPatternStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+	only_resources = [
+		"%s"
+	]
+}
+`
+
+const nameSelectorStatement = `
+data "manifest_fetch" "test" {
+	url           = "%s/%s"
+	name_selector = ["%s"]
+}
+`
+
+const namespacesStatement = `
+data "manifest_fetch" "test" {
+	url                    = "%s/namespaced"
+	namespaces             = [%s]
+	include_cluster_scoped = %t
+}
+`
+
+const labelSelectorStatement = `
+data "manifest_fetch" "test" {
+	url            = "%s/labeled"
+	label_selector = "%s"
+}
+`
+
+const annotationSelectorStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/hooks"
+	annotation_selector = [%s]
+}
+`
+
+const fallbackResourceStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+	fallback_urls = [
+		"%s/%s",
+		"%s/%s",
+	]
+}
+`
+
+const urlsResourceStatement = `
+data "manifest_fetch" "test" {
+	urls = [
+		"%s/%s",
+		"%s/%s",
+	]
+}
+`
+
+const parallelismResourceStatement = `
+data "manifest_fetch" "test" {
+	urls        = [%s]
+	parallelism = %d
+}
+`
+
+const chunkSizeResourceStatement = `
+data "manifest_fetch" "test" {
+	url        = "%s/%s"
+	chunk_size = %d
+}
+`
+
+const hedgedResourceStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+	fallback_urls = [
+		"%s/%s",
+	]
+	hedge_delay = "10ms"
+}
+`
+
+const pathResourceStatement = `
+data "manifest_fetch" "test" {
+	path = "%s"
+	filtered_attributes = [
+		"status",
+		"metadata.creationTimestamp",
+	]
+}
+`
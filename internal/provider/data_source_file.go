@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = (*fileDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*fileDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*fileDataSource)(nil)
+
+func NewFileDataSource() datasource.DataSource {
+	return &fileDataSource{}
+}
+
+type fileDataSource struct {
+	provider *providerData
+}
+
+func (d *fileDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_file"
+}
+
+func (d *fileDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *fileDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Reads manifests from local files, such as vendored YAML, and optionally removes attributes from them, as the `manifest_fetch` data source does for fetched manifests. Files may contain YAML documents, JSON objects, or JSON arrays of objects, selected by their extension.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"paths": {
+				MarkdownDescription: "The paths of the files to read, relative to the working directory, in order. Each path may be a shell pattern, where a `**` segment matches any number of directories (e.g. `manifests/**/*.yaml`), or a directory, in which case every `.yaml`, `.yml`, and `.json` file within it is read. The files matched by each path are read in lexical order, and files matched by several paths are only read once. Patterns which match no files are an error.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Required: true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *fileDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model fileModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *fileDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model fileModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	files, err := readFilePatterns(parseTfList(ctx, model.Paths, func(path string) string { return path }))
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading file", fmt.Sprintf("Error reading file: %s", err))
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, files)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Reads the files matching each of the patterns, in order, named by their path. Directories are expanded into the
+// manifest files within them.
+func readFilePatterns(patterns []string) ([]archiveFile, error) {
+	var files []archiveFile
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := globFiles(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			if !strings.ContainsAny(pattern, "*?[") {
+				// Report the missing file as it would be reported when reading it
+				_, err := os.Stat(pattern)
+				return nil, err
+			}
+			return nil, fmt.Errorf("%q matches no files", pattern)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			read, err := readManifestFiles(match, "")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, file := range read {
+				// Files are named by their path rather than their name within the directory
+				name := filepath.ToSlash(match)
+				if info.IsDir() {
+					name = path.Join(name, file.name)
+				}
+				if !seen[name] {
+					seen[name] = true
+					files = append(files, archiveFile{name: name, content: file.content})
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// Finds the paths matching the shell pattern in lexical order, where a `**` segment matches any number of directories
+func globFiles(pattern string) ([]string, error) {
+	slashed := path.Clean(filepath.ToSlash(pattern))
+	if !strings.Contains(slashed, "**") {
+		return filepath.Glob(pattern)
+	}
+	if _, err := path.Match(strings.ReplaceAll(slashed, "**", "*"), ""); err != nil {
+		return nil, err
+	}
+
+	// Only the directory before the first segment with a pattern needs to be walked
+	segments := strings.Split(slashed, "/")
+	base := "."
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			if i > 0 {
+				base = path.Join(segments[:i]...)
+			}
+			if strings.HasPrefix(slashed, "/") {
+				base = "/" + base
+			}
+			break
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(base), func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && globMatch(slashed, filepath.ToSlash(file)) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+type fileModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Paths              types.List   `tfsdk:"paths"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model fileModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_File(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "manifests", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifests", "namespace.yaml"), []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifests", "app", "config.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  uid: \"1234\"\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(fileStatement, filepath.ToSlash(dir)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_file.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_file.test", "manifests.0", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"),
+					resource.TestCheckResourceAttr("data.manifest_file.test", "manifests.1", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"),
+				),
+			},
+		},
+	})
+}
+
+const fileStatement = `
+data "manifest_file" "test" {
+	paths = ["%[1]s/manifests/*.yaml", "%[1]s/manifests/**/*.yaml"]
+	filtered_attributes = ["metadata.uid"]
+	only_resources = ["v1/Namespace", "v1/ConfigMap"]
+}
+`
+
+func TestReadFilePatterns(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.yaml":          "a",
+		"b.json":          "b",
+		"notes.txt":       "notes",
+		"nested/c.yml":    "c",
+		"nested/d/e.yaml": "e",
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := filepath.ToSlash(dir)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "file", patterns: []string{root + "/b.json"}, want: []string{"b.json"}},
+		{name: "glob", patterns: []string{root + "/*.yaml"}, want: []string{"a.yaml"}},
+		{name: "directory", patterns: []string{root + "/nested"}, want: []string{"nested/c.yml", "nested/d/e.yaml"}},
+		{name: "recursive", patterns: []string{root + "/**/*.yaml"}, want: []string{"a.yaml", "nested/d/e.yaml"}},
+		{name: "duplicates", patterns: []string{root + "/nested/c.yml", root + "/nested"}, want: []string{"nested/c.yml", "nested/d/e.yaml"}},
+		{name: "no matches", patterns: []string{root + "/*.xml"}, wantErr: true},
+		{name: "missing file", patterns: []string{root + "/missing.yaml"}, wantErr: true},
+		{name: "invalid pattern", patterns: []string{root + "/[.yaml"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := readFilePatterns(test.patterns)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(files))
+			for _, file := range files {
+				rel, err := filepath.Rel(dir, filepath.FromSlash(file.name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("expected %v, got %v", test.want, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+var _ datasource.DataSource = (*fluxHelmReleaseDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*fluxHelmReleaseDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*fluxHelmReleaseDataSource)(nil)
+
+func NewFluxHelmReleaseDataSource() datasource.DataSource {
+	return &fluxHelmReleaseDataSource{}
+}
+
+type fluxHelmReleaseDataSource struct {
+	provider *providerData
+}
+
+func (d *fluxHelmReleaseDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_flux_helm_release"
+}
+
+func (d *fluxHelmReleaseDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *fluxHelmReleaseDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Renders the chart of a [Flux](https://fluxcd.io) `HelmRelease` into the manifests Flux would install, without a cluster, and optionally removes attributes from them. The chart is fetched from the `HelmRepository` or `OCIRepository` the release references, and rendered with its values, including those from the `ConfigMap` and `Secret` manifests of `valuesFrom`. Charts from `GitRepository` and `Bucket` sources, `valuesFiles`, and the `targetPath` of `valuesFrom` aren't supported, and `postRenderers` aren't applied.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"content": {
+				MarkdownDescription: "The YAML documents containing the `HelmRelease`, the source it references, and the `ConfigMap` and `Secret` manifests of its `valuesFrom`, such as the contents of a Flux repository (e.g. `join(\"---\\n\", data.manifest_fetch.flux.manifests)`).",
+				Type:                types.StringType,
+				Required:            true,
+			},
+			"name": {
+				MarkdownDescription: "The name of the `HelmRelease` to render. Required when `content` contains several of them.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"kube_version": {
+				MarkdownDescription: "The Kubernetes version available to templates as `.Capabilities.KubeVersion`, as with the `manifest_helm_template` data source.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"api_versions": {
+				MarkdownDescription: "Additional API versions available to templates as `.Capabilities.APIVersions`, as with the `manifest_helm_template` data source.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *fluxHelmReleaseDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model fluxHelmReleaseModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *fluxHelmReleaseDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model fluxHelmReleaseModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	release, warnings, err := resolveFluxHelmRelease([]byte(model.Content.Value), model.Name.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("content"), "Invalid HelmRelease", fmt.Sprintf("Invalid HelmRelease: %s", err))
+		return
+	}
+	for _, warning := range warnings {
+		resp.Diagnostics.AddAttributeWarning(path.Root("content"), "Unsupported HelmRelease field", warning)
+	}
+	release.KubeVersion = model.KubeVersion
+	release.APIVersions = model.APIVersions
+
+	output, diags := release.render(ctx, d.provider)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, []archiveFile{{content: output}})
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Resolves the chart, values, and release of the HelmRelease with the name, or the only one when the name is empty,
+// from the objects within the content, returning them as the equivalent `helm template` configuration along with
+// warnings about the fields which are ignored
+func resolveFluxHelmRelease(content []byte, name string) (helmTemplateModel, []string, error) {
+	objects := map[string]map[any]any{}
+	var releases []map[any]any
+	err := unmarshalAllManifests(content, formatYAML, nil, func(manifest map[any]any, _ []byte) error {
+		metadata, _ := manifest["metadata"].(map[any]any)
+		objects[fluxObjectKey(stringValue(manifest["kind"]), fluxNamespace(stringValue(metadata["namespace"]), ""), stringValue(metadata["name"]))] = manifest
+
+		if stringValue(manifest["kind"]) == "HelmRelease" && strings.HasPrefix(stringValue(manifest["apiVersion"]), "helm.toolkit.fluxcd.io/") {
+			if name == "" || stringValue(metadata["name"]) == name {
+				releases = append(releases, manifest)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return helmTemplateModel{}, nil, err
+	}
+
+	switch {
+	case len(releases) == 0 && name != "":
+		return helmTemplateModel{}, nil, fmt.Errorf("no HelmRelease named %q was found", name)
+	case len(releases) == 0:
+		return helmTemplateModel{}, nil, errors.New("no HelmRelease was found")
+	case len(releases) > 1 && name != "":
+		return helmTemplateModel{}, nil, fmt.Errorf("several HelmReleases named %q were found", name)
+	case len(releases) > 1:
+		return helmTemplateModel{}, nil, errors.New("several HelmReleases were found, so the one to render must be selected with name")
+	}
+
+	release := releases[0]
+	metadata, _ := release["metadata"].(map[any]any)
+	namespace := fluxNamespace(stringValue(metadata["namespace"]), "")
+	spec, _ := release["spec"].(map[any]any)
+
+	model := helmTemplateModel{
+		Repository:  types.String{Null: true},
+		Version:     types.String{Null: true},
+		Name:        types.String{Value: stringValue(metadata["name"])},
+		Namespace:   types.String{Value: namespace},
+		Set:         types.Map{ElemType: types.StringType, Null: true},
+		SetString:   types.Map{ElemType: types.StringType, Null: true},
+		SkipCRDs:    types.Bool{Null: true},
+		KubeVersion: types.String{Null: true},
+		APIVersions: types.List{ElemType: types.StringType, Null: true},
+	}
+
+	// As with Flux, the release is named after the target namespace and the HelmRelease unless its name is set
+	if targetNamespace := stringValue(spec["targetNamespace"]); targetNamespace != "" {
+		model.Name = types.String{Value: targetNamespace + "-" + model.Name.Value}
+		model.Namespace = types.String{Value: targetNamespace}
+	}
+	if releaseName := stringValue(spec["releaseName"]); releaseName != "" {
+		model.Name = types.String{Value: releaseName}
+	}
+
+	if err := resolveFluxChart(&model, objects, spec, namespace); err != nil {
+		return helmTemplateModel{}, nil, err
+	}
+
+	values, err := resolveFluxValues(objects, spec, namespace)
+	if err != nil {
+		return helmTemplateModel{}, nil, err
+	}
+	model.Values = types.List{ElemType: types.StringType, Elems: values}
+
+	if install, ok := lookupMap(spec, "install"); ok && stringValue(install["crds"]) == "Skip" {
+		model.SkipCRDs = types.Bool{Value: true}
+	}
+
+	var warnings []string
+	if _, ok := spec["postRenderers"]; ok {
+		warnings = append(warnings, "The postRenderers of the HelmRelease aren't applied, so the manifests may differ from those installed by Flux.")
+	}
+	return model, warnings, nil
+}
+
+// Resolves the chart of the HelmRelease from the source it references
+func resolveFluxChart(model *helmTemplateModel, objects map[string]map[any]any, spec map[any]any, namespace string) error {
+	if chartRef, ok := lookupMap(spec, "chartRef"); ok {
+		kind, name := stringValue(chartRef["kind"]), stringValue(chartRef["name"])
+		if kind != "OCIRepository" {
+			return fmt.Errorf("charts from %s sources aren't supported", kind)
+		}
+		source, err := fluxSource(objects, kind, fluxNamespace(stringValue(chartRef["namespace"]), namespace), name)
+		if err != nil {
+			return err
+		}
+
+		sourceSpec, _ := source["spec"].(map[any]any)
+		ref, _ := lookupMap(sourceSpec, "ref")
+		if stringValue(ref["digest"]) != "" {
+			return fmt.Errorf("the digest of OCIRepository %s isn't supported, use a tag or semver instead", name)
+		}
+		model.Chart = types.String{Value: stringValue(sourceSpec["url"])}
+		if version := stringValue(ref["tag"]) + stringValue(ref["semver"]); version != "" {
+			model.Version = types.String{Value: version}
+		}
+		return nil
+	}
+
+	chartSpec, ok := lookupMap(spec, "chart", "spec")
+	if !ok {
+		return errors.New("either spec.chart or spec.chartRef must be set")
+	}
+	if _, ok := chartSpec["valuesFiles"]; ok {
+		return errors.New("the valuesFiles of the chart aren't supported")
+	}
+	if version := stringValue(chartSpec["version"]); version != "" && version != "*" {
+		model.Version = types.String{Value: version}
+	}
+
+	sourceRef, _ := lookupMap(chartSpec, "sourceRef")
+	kind, name := stringValue(sourceRef["kind"]), stringValue(sourceRef["name"])
+	if kind != "HelmRepository" {
+		return fmt.Errorf("charts from %s sources aren't supported", kind)
+	}
+	source, err := fluxSource(objects, kind, fluxNamespace(stringValue(sourceRef["namespace"]), namespace), name)
+	if err != nil {
+		return err
+	}
+
+	sourceSpec, _ := source["spec"].(map[any]any)
+	url, chart := stringValue(sourceSpec["url"]), stringValue(chartSpec["chart"])
+	if stringValue(sourceSpec["type"]) == "oci" || strings.HasPrefix(url, "oci://") {
+		model.Chart = types.String{Value: strings.TrimSuffix(url, "/") + "/" + chart}
+	} else {
+		model.Chart = types.String{Value: chart}
+		model.Repository = types.String{Value: url}
+	}
+	return nil
+}
+
+// Resolves the values of the HelmRelease, in the order they're merged by Flux: each of `valuesFrom`, then `values`
+func resolveFluxValues(objects map[string]map[any]any, spec map[any]any, namespace string) ([]attr.Value, error) {
+	var values []attr.Value
+
+	references, _ := spec["valuesFrom"].([]any)
+	for _, item := range references {
+		reference, _ := item.(map[any]any)
+		kind, name := stringValue(reference["kind"]), stringValue(reference["name"])
+		if stringValue(reference["targetPath"]) != "" {
+			return nil, fmt.Errorf("the targetPath of the values from %s %s isn't supported", kind, name)
+		}
+		key := stringValue(reference["valuesKey"])
+		if key == "" {
+			key = "values.yaml"
+		}
+
+		object, ok := objects[fluxObjectKey(kind, namespace, name)]
+		if !ok {
+			if optional, _ := reference["optional"].(bool); optional {
+				continue
+			}
+			return nil, fmt.Errorf("%s %s/%s, referenced by valuesFrom, wasn't found", kind, namespace, name)
+		}
+
+		value, found, err := fluxValuesKey(kind, object, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s/%s: %w", kind, namespace, name, err)
+		}
+		if !found {
+			if optional, _ := reference["optional"].(bool); optional {
+				continue
+			}
+			return nil, fmt.Errorf("%s %s/%s has no key %q", kind, namespace, name, key)
+		}
+		values = append(values, types.String{Value: value})
+	}
+
+	if inline, ok := spec["values"].(map[any]any); ok {
+		encoded, err := yaml.Marshal(inline)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, types.String{Value: string(encoded)})
+	}
+	return values, nil
+}
+
+// The value of the key within the ConfigMap or Secret, and whether it was found
+func fluxValuesKey(kind string, object map[any]any, key string) (string, bool, error) {
+	switch kind {
+	case "ConfigMap":
+		data, _ := object["data"].(map[any]any)
+		value, ok := data[key].(string)
+		return value, ok, nil
+	case "Secret":
+		if stringData, ok := object["stringData"].(map[any]any); ok {
+			if value, ok := stringData[key].(string); ok {
+				return value, true, nil
+			}
+		}
+		data, _ := object["data"].(map[any]any)
+		encoded, ok := data[key].(string)
+		if !ok {
+			return "", false, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid base64 in key %q: %w", key, err)
+		}
+		return string(decoded), true, nil
+	default:
+		return "", false, fmt.Errorf("values from %s objects aren't supported", kind)
+	}
+}
+
+// Finds the source referenced by a HelmRelease
+func fluxSource(objects map[string]map[any]any, kind, namespace, name string) (map[any]any, error) {
+	source, ok := objects[fluxObjectKey(kind, namespace, name)]
+	if !ok {
+		return nil, fmt.Errorf("%s %s/%s, referenced by the HelmRelease, wasn't found", kind, namespace, name)
+	}
+	return source, nil
+}
+
+// The namespace of an object or reference, defaulting to the namespace of the object referencing it, or `default`
+func fluxNamespace(namespace, fallback string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return "default"
+}
+
+func fluxObjectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+type fluxHelmReleaseModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Content            types.String `tfsdk:"content"`
+	Name               types.String `tfsdk:"name"`
+	KubeVersion        types.String `tfsdk:"kube_version"`
+	APIVersions        types.List   `tfsdk:"api_versions"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model fluxHelmReleaseModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestResolveFluxHelmRelease(t *testing.T) {
+	ctx := context.Background()
+
+	model, warnings, err := resolveFluxHelmRelease([]byte(fluxDocuments), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected a warning about the post renderers, got %q", warnings)
+	}
+
+	options, err := model.renderOptions(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if options.chart != "podinfo" || options.repository != "https://stefanprodan.github.io/podinfo" || options.version != "6.x" {
+		t.Errorf("unexpected chart %s from %s, version %s", options.chart, options.repository, options.version)
+	}
+	if options.name != "monitoring-podinfo" || options.namespace != "monitoring" || !options.includeCRDs {
+		t.Errorf("unexpected release %s in %s, including CRDs %t", options.name, options.namespace, options.includeCRDs)
+	}
+
+	var values []string
+	if diags := model.Values.ElementsAs(ctx, &values, false); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if expected := []string{"replicaCount: 2\n", "ingress:\n  enabled: true\n", "ui:\n  message: hello\n"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %q, got %q", expected, values)
+	}
+}
+
+func TestResolveFluxHelmRelease_Render(t *testing.T) {
+	chart, err := loader.Load(writeTestChart(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	archive, err := chartutil.Save(chart, t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.yaml":
+			fmt.Fprintf(w, "apiVersion: v1\nentries:\n  app:\n  - apiVersion: v2\n    name: app\n    version: 1.0.0\n    urls:\n    - %s/app-1.0.0.tgz\n", server.URL)
+		case "/app-1.0.0.tgz":
+			http.ServeFile(w, r, archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	content := fmt.Sprintf("apiVersion: source.toolkit.fluxcd.io/v1beta2\nkind: HelmRepository\nmetadata:\n  name: charts\nspec:\n  url: %s\n---\n", server.URL) +
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-values\ndata:\n  values.yaml: |\n    port: 8080\n---\n" +
+		"apiVersion: helm.toolkit.fluxcd.io/v2beta1\nkind: HelmRelease\nmetadata:\n  name: app\nspec:\n  targetNamespace: apps\n  chart:\n    spec:\n      chart: app\n      version: 1.x\n      sourceRef:\n        kind: HelmRepository\n        name: charts\n  valuesFrom:\n  - kind: ConfigMap\n    name: app-values\n  values:\n    replicas: 3\n"
+	model, _, err := resolveFluxHelmRelease([]byte(content), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	model.KubeVersion = types.String{Value: "1.25.0"}
+
+	output, diags := model.render(context.Background(), nil)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	expected := "---\n# Source: app/templates/config.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: apps-app\n  namespace: apps\ndata:\n  replicas: \"3\"\n  port: \"8080\"\n  kubeVersion: \"v1.25.0\"\n" +
+		"---\n# Source: app/templates/job.yaml\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: apps-app-migrate\n  annotations:\n    helm.sh/hook: pre-install\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestResolveFluxHelmRelease_OCIRepository(t *testing.T) {
+	model, _, err := resolveFluxHelmRelease([]byte(fluxOCIDocuments), "podinfo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if model.Chart.Value != "oci://ghcr.io/stefanprodan/charts/podinfo" || model.Version.Value != "6.5.0" || !model.Repository.Null {
+		t.Errorf("unexpected chart %s, version %s, and repository %s", model.Chart, model.Version, model.Repository)
+	}
+	if model.Name.Value != "podinfo" || model.Namespace.Value != "default" || !model.SkipCRDs.Value {
+		t.Errorf("unexpected release %s in %s, skipping CRDs %s", model.Name, model.Namespace, model.SkipCRDs)
+	}
+}
+
+func TestResolveFluxHelmRelease_Errors(t *testing.T) {
+	for name, test := range map[string]struct {
+		content string
+		name    string
+		err     string
+	}{
+		"no release":       {"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: values\n", "", "no HelmRelease was found"},
+		"unknown name":     {fluxDocuments, "other", `no HelmRelease named "other" was found`},
+		"several releases": {fluxDocuments + "---\n" + fluxOCIDocuments, "", "several HelmReleases were found, so the one to render must be selected with name"},
+		"missing source":   {fluxOCIDocuments[:len(fluxOCIDocuments)-len(fluxOCIRepository)], "", "OCIRepository default/podinfo, referenced by the HelmRelease, wasn't found"},
+		"git source":       {"apiVersion: helm.toolkit.fluxcd.io/v2beta1\nkind: HelmRelease\nmetadata:\n  name: app\nspec:\n  chart:\n    spec:\n      chart: ./charts/app\n      sourceRef:\n        kind: GitRepository\n        name: app\n", "", "charts from GitRepository sources aren't supported"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := resolveFluxHelmRelease([]byte(test.content), test.name); err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+const fluxDocuments = `apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: HelmRepository
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  url: https://stefanprodan.github.io/podinfo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: podinfo-values
+  namespace: apps
+data:
+  values.yaml: |
+    replicaCount: 2
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: podinfo-ingress
+  namespace: apps
+data:
+  ingress: aW5ncmVzczoKICBlbmFibGVkOiB0cnVlCg==
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: podinfo
+  namespace: apps
+spec:
+  targetNamespace: monitoring
+  chart:
+    spec:
+      chart: podinfo
+      version: 6.x
+      sourceRef:
+        kind: HelmRepository
+        name: podinfo
+        namespace: flux-system
+  valuesFrom:
+  - kind: ConfigMap
+    name: podinfo-values
+  - kind: Secret
+    name: podinfo-ingress
+    valuesKey: ingress
+  - kind: ConfigMap
+    name: missing
+    optional: true
+  values:
+    ui:
+      message: hello
+  postRenderers:
+  - kustomize:
+      patches: []
+`
+
+const fluxOCIRepository = `---
+apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: OCIRepository
+metadata:
+  name: podinfo
+spec:
+  url: oci://ghcr.io/stefanprodan/charts/podinfo
+  ref:
+    semver: 6.5.0
+`
+
+const fluxOCIDocuments = `apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: podinfo
+spec:
+  chartRef:
+    kind: OCIRepository
+    name: podinfo
+  install:
+    crds: Skip
+` + fluxOCIRepository
@@ -0,0 +1,447 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/strvals"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+var _ datasource.DataSource = (*helmTemplateDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*helmTemplateDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*helmTemplateDataSource)(nil)
+
+func NewHelmTemplateDataSource() datasource.DataSource {
+	return &helmTemplateDataSource{}
+}
+
+type helmTemplateDataSource struct {
+	provider *providerData
+}
+
+func (d *helmTemplateDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_helm_template"
+}
+
+func (d *helmTemplateDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *helmTemplateDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Renders a [Helm](https://helm.sh) chart into manifests without a cluster, as `helm template` does, and optionally removes attributes from them. Unlike installing the chart with the `helm` provider, nothing is tracked by Helm, so the manifests can be applied like any other. Chart hooks are returned along with every other manifest.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"chart": {
+				MarkdownDescription: "The chart to render, either its name within `repository`, an OCI reference (e.g. `oci://ghcr.io/example/charts/app`), or the path to a local chart directory or archive. Remote charts are fetched like any other URL, using the `registry_auth`, `cache`, and `max_concurrency` of the provider.",
+				Type:                types.StringType,
+				Required:            true,
+			},
+			"repository": {
+				Description: "The URL of the chart repository to fetch the chart from. Unset for OCI references and local charts.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"version": {
+				Description: "The version constraint of the chart to fetch. Defaults to the latest version.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"name": {
+				MarkdownDescription: "The name of the release, available to templates as `.Release.Name`. Defaults to `release-name`, as with `helm template`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"namespace": {
+				MarkdownDescription: "The namespace of the release, available to templates as `.Release.Namespace`. Defaults to `default`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"values": {
+				MarkdownDescription: "YAML documents of values, merged in order, equivalent to `--values`. Useful with `yamlencode` or `file`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"set": {
+				MarkdownDescription: "Values to set, keyed by their path (e.g. `controller.replicaCount`), equivalent to `--set`. Takes precedence over `values`.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"set_string": {
+				MarkdownDescription: "Values to set as strings, keyed by their path, equivalent to `--set-string`. Takes precedence over `values`.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"skip_crds": {
+				MarkdownDescription: "Whether the `CustomResourceDefinition` manifests of the `crds` directory of the chart are left out. Defaults to `false`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"kube_version": {
+				MarkdownDescription: "The Kubernetes version available to templates as `.Capabilities.KubeVersion`, equivalent to `--kube-version`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"api_versions": {
+				MarkdownDescription: "Additional API versions available to templates as `.Capabilities.APIVersions` (e.g. `monitoring.coreos.com/v1`), equivalent to `--api-versions`.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *helmTemplateDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model helmTemplateModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *helmTemplateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model helmTemplateModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	output, diags := model.render(ctx, d.provider)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, []archiveFile{{content: output}})
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Renders the chart in-process, as `helm template` does, returning the rendered manifests
+func (model helmTemplateModel) render(ctx context.Context, provider *providerData) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	options, err := model.renderOptions(ctx)
+	if err != nil {
+		diags.AddError("Error rendering chart", fmt.Sprintf("Error merging values: %s", err))
+		return nil, diags
+	}
+
+	output, err := options.render(ctx, provider)
+	if err != nil {
+		diags.AddError("Error rendering chart", fmt.Sprintf("Error rendering chart: %s", err))
+		return nil, diags
+	}
+	return output, diags
+}
+
+// The options of a chart rendered with `helm template`, with the values merged as Helm merges them
+type helmRenderOptions struct {
+	chart       string
+	repository  string
+	version     string
+	name        string
+	namespace   string
+	values      map[string]any
+	includeCRDs bool
+	kubeVersion string
+	apiVersions []string
+}
+
+// Resolves the options of `helm template` equivalent to the configuration, merging each document of values over those
+// before it, then applying `set` and `set_string` in the order of their paths
+func (model helmTemplateModel) renderOptions(ctx context.Context) (helmRenderOptions, error) {
+	options := helmRenderOptions{
+		chart:       model.Chart.Value,
+		repository:  model.Repository.Value,
+		version:     model.Version.Value,
+		name:        "release-name",
+		namespace:   "default",
+		values:      map[string]any{},
+		includeCRDs: !model.SkipCRDs.Value,
+		kubeVersion: model.KubeVersion.Value,
+		apiVersions: parseTfList(ctx, model.APIVersions, func(version string) string { return version }),
+	}
+	if isSet(model.Name) {
+		options.name = model.Name.Value
+	}
+	if isSet(model.Namespace) {
+		options.namespace = model.Namespace.Value
+	}
+
+	for i, document := range parseTfList(ctx, model.Values, func(values string) string { return values }) {
+		values, err := chartutil.ReadValues([]byte(document))
+		if err != nil {
+			return helmRenderOptions{}, fmt.Errorf("invalid values %d: %w", i, err)
+		}
+		options.values = chartutil.CoalesceTables(values, options.values)
+	}
+	for _, flag := range []struct {
+		name   string
+		values types.Map
+		parse  func(string, map[string]any) error
+	}{{"set", model.Set, strvals.ParseInto}, {"set_string", model.SetString, strvals.ParseIntoString}} {
+		values := map[string]string{}
+		if diags := flag.values.ElementsAs(ctx, &values, false); diags.HasError() {
+			return helmRenderOptions{}, fmt.Errorf("invalid %s values", flag.name)
+		}
+		for _, name := range sortedKeys(values) {
+			if err := flag.parse(name+"="+values[name], options.values); err != nil {
+				return helmRenderOptions{}, fmt.Errorf("invalid %s value %s: %w", flag.name, name, err)
+			}
+		}
+	}
+
+	return options, nil
+}
+
+// Renders the chart without a cluster, returning the manifests of the release followed by those of its hooks
+func (options helmRenderOptions) render(ctx context.Context, provider *providerData) ([]byte, error) {
+	install := action.NewInstall(&action.Configuration{
+		Log: func(format string, v ...any) {
+			tflog.Debug(ctx, fmt.Sprintf(format, v...))
+		},
+	})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = options.name
+	install.Namespace = options.namespace
+	install.IncludeCRDs = options.includeCRDs
+	install.APIVersions = chartutil.VersionSet(options.apiVersions)
+	if options.kubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(options.kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube_version: %w", err)
+		}
+		install.KubeVersion = kubeVersion
+	}
+
+	chart, err := options.loadChart(ctx, newFetcher(provider))
+	if err != nil {
+		return nil, err
+	}
+	if dependencies := chart.Metadata.Dependencies; dependencies != nil {
+		if err := action.CheckDependencies(chart, dependencies); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := install.RunWithContext(ctx, chart, options.values)
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	fmt.Fprintln(&output, strings.TrimSpace(release.Manifest))
+	for _, hook := range release.Hooks {
+		fmt.Fprintf(&output, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return output.Bytes(), nil
+}
+
+// Loads the chart from its local path, or fetches it through the provider, so its registry credentials, cache, and
+// request limits apply rather than the configuration of the Helm CLI
+func (options helmRenderOptions) loadChart(ctx context.Context, f *fetcher) (*chart.Chart, error) {
+	var archive []byte
+	var err error
+	switch {
+	case options.repository != "":
+		archive, err = f.fetchRepositoryChart(ctx, options.repository, options.chart, options.version)
+	case strings.HasPrefix(options.chart, "oci://"):
+		archive, err = f.fetchOCIChart(ctx, options.chart, options.version)
+	default:
+		return loader.Load(options.chart)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.LoadArchive(bytes.NewReader(archive))
+}
+
+// Fetches the archive of the chart, resolving its version against the index of the repository as Helm does
+func (f *fetcher) fetchRepositoryChart(ctx context.Context, repository, name, version string) ([]byte, error) {
+	indexURL, err := repo.ResolveReferenceURL(repository, "index.yaml")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := f.fetchURL(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var index repo.IndexFile
+	if err := k8syaml.Unmarshal(raw, &index); err != nil {
+		return nil, fmt.Errorf("invalid index of repository %s: %w", repository, err)
+	}
+	for chartName, versions := range index.Entries {
+		valid := versions[:0]
+		for _, chartVersion := range versions {
+			if chartVersion != nil {
+				valid = append(valid, chartVersion)
+			}
+		}
+		index.Entries[chartName] = valid
+	}
+	index.SortEntries()
+
+	chartVersion, err := index.Get(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("chart %s %s wasn't found in repository %s: %w", name, version, repository, err)
+	}
+	if len(chartVersion.URLs) == 0 {
+		return nil, fmt.Errorf("chart %s %s of repository %s has no URLs", name, chartVersion.Version, repository)
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(repository, chartVersion.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+	return f.fetchURL(ctx, chartURL)
+}
+
+// Fetches the archive of the chart from an OCI registry. As with Helm, the version is the tag of the chart, either
+// exactly or as a constraint resolved against the tags of the repository, defaulting to the latest stable version.
+func (f *fetcher) fetchOCIChart(ctx context.Context, chart, version string) ([]byte, error) {
+	key := chart
+	if version != "" {
+		key += "?" + neturl.Values{"version": {version}}.Encode()
+	}
+
+	response, err := f.fetchCached(ctx, key, func() (*fetchResponse, error) {
+		ref, err := parseImageReference(strings.TrimPrefix(chart, "oci://"))
+		if err != nil {
+			return nil, newFetchError("Invalid chart", "%s", err)
+		}
+
+		registry := f.registryClient()
+		explicitTag := ref.digest != "" || strings.Contains(path.Base(chart), ":")
+		if version != "" || !explicitTag {
+			ref.tag, err = resolveChartTag(ctx, registry, ref, version)
+			if err != nil {
+				return nil, newFetchError("Error resolving chart version", "%s", err)
+			}
+		}
+
+		raw, _, err := registry.manifest(ctx, ref)
+		if err != nil {
+			return nil, newFetchError("Error pulling chart", "%s", err)
+		}
+		var manifest ociManifest
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			return nil, newFetchError("Error pulling chart", "failed to parse manifest for %s: %s", ref, err)
+		}
+		for _, layer := range manifest.Layers {
+			if layer.MediaType == mediaTypeHelmChart {
+				content, err := registry.blob(ctx, ref, layer.Digest)
+				if err != nil {
+					return nil, newFetchError("Error pulling chart", "%s", err)
+				}
+				return &fetchResponse{url: chart, body: content}, nil
+			}
+		}
+		return nil, newFetchError("Error pulling chart", "%s is not a Helm chart", ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.body, nil
+}
+
+// Resolves the tag of the highest version of the chart matching the constraint, or the version itself when it is
+// exact. Since tags can't contain `+`, the build metadata of versions is separated by `_` instead.
+func resolveChartTag(ctx context.Context, registry *registryClient, ref imageReference, version string) (string, error) {
+	if version != "" {
+		if _, err := semver.StrictNewVersion(version); err == nil {
+			return strings.ReplaceAll(version, "+", "_"), nil
+		}
+	} else {
+		version = "*"
+	}
+
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	tags, err := registry.tags(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	var latestTag string
+	for _, tag := range tags {
+		candidate, err := semver.NewVersion(strings.ReplaceAll(tag, "_", "+"))
+		if err != nil || !constraint.Check(candidate) {
+			continue
+		}
+		if latest == nil || candidate.GreaterThan(latest) {
+			latest, latestTag = candidate, tag
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no version of %s/%s matches %q", ref.registry, ref.repository, version)
+	}
+	return latestTag, nil
+}
+
+type helmTemplateModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Chart              types.String `tfsdk:"chart"`
+	Repository         types.String `tfsdk:"repository"`
+	Version            types.String `tfsdk:"version"`
+	Name               types.String `tfsdk:"name"`
+	Namespace          types.String `tfsdk:"namespace"`
+	Values             types.List   `tfsdk:"values"`
+	Set                types.Map    `tfsdk:"set"`
+	SetString          types.Map    `tfsdk:"set_string"`
+	SkipCRDs           types.Bool   `tfsdk:"skip_crds"`
+	KubeVersion        types.String `tfsdk:"kube_version"`
+	APIVersions        types.List   `tfsdk:"api_versions"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model helmTemplateModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestDataSource_HelmTemplate(t *testing.T) {
+	dir := writeTestChart(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(helmTemplateStatement, dir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_helm_template.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_helm_template.test", "manifests.0", "# Source: app/templates/config.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n  namespace: apps\ndata:\n  replicas: \"3\"\n  port: \"8080\"\n  kubeVersion: \"v1.25.0\"\n"),
+					resource.TestCheckResourceAttr("data.manifest_helm_template.test", "manifests.1", "# Source: app/templates/job.yaml\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: web-migrate\n  annotations:\n    helm.sh/hook: pre-install\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestHelmRenderOptions(t *testing.T) {
+	ctx := context.Background()
+	model := helmTemplateModel{
+		Chart:       types.String{Value: "cert-manager"},
+		Repository:  types.String{Value: "https://charts.jetstack.io"},
+		Version:     types.String{Value: "v1.10.1"},
+		Name:        types.String{Null: true},
+		Namespace:   types.String{Value: "cert-manager"},
+		Values:      types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "installCRDs: true\nreplicaCount: 1\nglobal:\n  priorityClassName: high\n"}, types.String{Value: "global:\n  logLevel: 4\n"}}},
+		Set:         types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{"replicaCount": types.String{Value: "2"}, "global.leaderElection.namespace": types.String{Value: "cert-manager"}}},
+		SetString:   types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{"podLabels.version": types.String{Value: "2"}}},
+		SkipCRDs:    types.Bool{Null: true},
+		KubeVersion: types.String{Null: true},
+		APIVersions: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "monitoring.coreos.com/v1"}}},
+	}
+
+	options, err := model.renderOptions(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := helmRenderOptions{
+		chart:      "cert-manager",
+		repository: "https://charts.jetstack.io",
+		version:    "v1.10.1",
+		name:       "release-name",
+		namespace:  "cert-manager",
+		values: map[string]any{
+			"installCRDs":  true,
+			"replicaCount": int64(2),
+			"global": map[string]any{
+				"priorityClassName": "high",
+				"logLevel":          float64(4),
+				"leaderElection":    map[string]any{"namespace": "cert-manager"},
+			},
+			"podLabels": map[string]any{"version": "2"},
+		},
+		includeCRDs: true,
+		apiVersions: []string{"monitoring.coreos.com/v1"},
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("expected %#v, got %#v", expected, options)
+	}
+
+	model.Values = types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "- not a map\n"}}}
+	if _, err := model.renderOptions(ctx); err == nil {
+		t.Error("expected an error for values which aren't a map")
+	}
+}
+
+func TestHelmRenderOptions_Render(t *testing.T) {
+	options := helmRenderOptions{
+		chart:       writeTestChart(t),
+		name:        "web",
+		namespace:   "apps",
+		values:      map[string]any{"port": 8080},
+		includeCRDs: true,
+		kubeVersion: "v1.25.0",
+	}
+
+	output, err := options.render(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "---\n# Source: app/templates/config.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n  namespace: apps\ndata:\n  replicas: \"1\"\n  port: \"8080\"\n  kubeVersion: \"v1.25.0\"\n" +
+		"---\n# Source: app/templates/job.yaml\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: web-migrate\n  annotations:\n    helm.sh/hook: pre-install\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+
+	options.kubeVersion = "latest"
+	if _, err := options.render(context.Background(), nil); err == nil {
+		t.Error("expected an error for an invalid kube_version")
+	}
+}
+
+func TestHelmRenderOptions_LoadChart_Repository(t *testing.T) {
+	archive := writeTestChartArchive(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			// The latest version is a pre-release, so it is only chosen when the constraint allows it
+			_, _ = w.Write([]byte("apiVersion: v1\nentries:\n  app:\n  - name: app\n    version: 1.1.0-rc.1\n    urls:\n    - app-1.1.0-rc.1.tgz\n  - name: app\n    version: 1.0.0\n    urls:\n    - app-1.0.0.tgz\n  - name: app\n    version: 0.9.0\n    urls:\n    - app-0.9.0.tgz\n"))
+		case "/charts/app-1.0.0.tgz":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	f := newFetcher(nil)
+	for _, version := range []string{"", "1.x", "1.0.0"} {
+		options := helmRenderOptions{chart: "app", repository: server.URL + "/charts", version: version}
+		chart, err := options.loadChart(context.Background(), f)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", version, err)
+		}
+		if chart.Metadata.Version != "1.0.0" {
+			t.Errorf("%q: expected version 1.0.0, got %s", version, chart.Metadata.Version)
+		}
+	}
+	options := helmRenderOptions{chart: "app", repository: server.URL + "/charts", version: "2.x"}
+	if _, err := options.loadChart(context.Background(), newFetcher(nil)); err == nil {
+		t.Error("expected an error for a version which doesn't exist")
+	}
+}
+
+func TestHelmRenderOptions_LoadChart_OCI(t *testing.T) {
+	archive := writeTestChartArchive(t)
+	digest := "sha256:" + sha256Hex(archive)
+	manifest, _ := json.Marshal(ociManifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    ociDescriptor{MediaType: "application/vnd.cncf.helm.config.v1+json", Digest: "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", Size: 2},
+		Layers:    []ociDescriptor{{MediaType: mediaTypeHelmChart, Digest: digest, Size: int64(len(archive))}},
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/charts/app/tags/list":
+			_, _ = w.Write([]byte(`{"name": "charts/app", "tags": ["0.9.0", "1.0.0", "1.1.0-rc.1", "latest"]}`))
+		case "/v2/charts/app/manifests/1.0.0":
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			_, _ = w.Write(manifest)
+		case "/v2/charts/app/blobs/" + digest:
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	provider := &providerData{registryCredentials: map[string]registryCredentials{host: {username: "user", password: "secret"}}}
+	for _, version := range []string{"", "1.x", "1.0.0"} {
+		options := helmRenderOptions{chart: "oci://" + host + "/charts/app", version: version}
+		chart, err := options.loadChart(context.Background(), newFetcher(provider))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", version, err)
+		}
+		if chart.Metadata.Version != "1.0.0" {
+			t.Errorf("%q: expected version 1.0.0, got %s", version, chart.Metadata.Version)
+		}
+	}
+
+	options := helmRenderOptions{chart: "oci://" + host + "/charts/app", version: "1.0.0"}
+	if _, err := options.loadChart(context.Background(), newFetcher(nil)); err == nil {
+		t.Error("expected an error without the credentials of the provider")
+	}
+}
+
+// Packages the test chart, returning the archive
+func writeTestChartArchive(t *testing.T) []byte {
+	chart, err := loader.Load(writeTestChart(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	path, err := chartutil.Save(chart, t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	archive, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return archive
+}
+
+// Writes a chart with a ConfigMap and a hook into a temporary directory, returning its path
+func writeTestChart(t *testing.T) string {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"Chart.yaml":            "apiVersion: v2\nname: app\nversion: 1.0.0\n",
+		"values.yaml":           "replicas: 1\nport: 80\n",
+		"templates/config.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n  namespace: {{ .Release.Namespace }}\ndata:\n  replicas: {{ .Values.replicas | quote }}\n  port: {{ .Values.port | quote }}\n  kubeVersion: {{ .Capabilities.KubeVersion.Version | quote }}\n",
+		"templates/job.yaml":    "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: {{ .Release.Name }}-migrate\n  annotations:\n    helm.sh/hook: pre-install\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const helmTemplateStatement = `
+data "manifest_helm_template" "test" {
+	chart        = "%s"
+	name         = "web"
+	namespace    = "apps"
+	kube_version = "1.25.0"
+	values       = [yamlencode({ replicas = 2, port = 8080 })]
+	set = {
+		replicas = "3"
+	}
+}
+`
@@ -0,0 +1,279 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-jsonnet"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = (*jsonnetDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*jsonnetDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*jsonnetDataSource)(nil)
+
+func NewJsonnetDataSource() datasource.DataSource {
+	return &jsonnetDataSource{}
+}
+
+type jsonnetDataSource struct {
+	provider *providerData
+}
+
+func (d *jsonnetDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_jsonnet"
+}
+
+func (d *jsonnetDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *jsonnetDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Evaluates a [Jsonnet](https://jsonnet.org) program into manifests, such as those of kube-prometheus, and optionally removes attributes from them. The program may evaluate to a manifest, a list of manifests, or an object whose values are manifests, lists of manifests, or further such objects, in which case the manifests are returned in the order of the keys.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"path": {
+				Description: "The path to a local Jsonnet file, relative to the working directory. Conflicts with `url`.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"url": {
+				MarkdownDescription: "The URL of a Jsonnet file, supporting the same schemes as the `manifest_fetch` data source. Imports are only resolved through `jpath`. Conflicts with `path`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"ext_vars": {
+				MarkdownDescription: "The external variables available to the program as strings through `std.extVar`.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"ext_code": {
+				MarkdownDescription: "The external variables available to the program through `std.extVar`, each of which is evaluated as Jsonnet code.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"jpath": {
+				MarkdownDescription: "The directories in which imports are searched for, relative to the working directory. The `vendor` directory next to `path`, as created by [jsonnet-bundler](https://github.com/jsonnet-bundler/jsonnet-bundler), is always searched when it exists, after these directories.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *jsonnetDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model jsonnetModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *jsonnetDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model jsonnetModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if countTrue(isSet(model.Path), isSet(model.URL)) != 1 {
+		resp.Diagnostics.AddError("Invalid source", "Exactly one of path or url must be set.")
+		return
+	}
+
+	variables := make([]map[string]string, 2)
+	for i, values := range []types.Map{model.ExtVars, model.ExtCode} {
+		variables[i] = map[string]string{}
+		resp.Diagnostics.Append(values.ElementsAs(ctx, &variables[i], false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	jpath := parseTfList(ctx, model.JPath, func(dir string) string { return dir })
+
+	var output string
+	var err error
+	if isSet(model.Path) {
+		output, err = makeJsonnetVM(variables[0], variables[1], jsonnetSearchPath(model.Path.Value, jpath)).EvaluateFile(model.Path.Value)
+	} else {
+		response, errs := newFetcher(d.provider).fetchSequential(ctx, []string{model.URL.Value})
+		if response == nil {
+			addFetchError(&resp.Diagnostics, model.URL.Value, errs[0], false)
+			return
+		}
+		output, err = makeJsonnetVM(variables[0], variables[1], jpath).EvaluateAnonymousSnippet(model.URL.Value, string(response.body))
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Error evaluating Jsonnet", fmt.Sprintf("Error evaluating Jsonnet: %s", err))
+		return
+	}
+	sources, err := splitJsonnetOutput([]byte(output))
+	if err != nil {
+		resp.Diagnostics.AddError("Error evaluating Jsonnet", fmt.Sprintf("Error evaluating Jsonnet: %s", err))
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, sources)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Prepends the vendor directory next to the program, if any, to the search path so that the directories of `jpath`
+// take precedence over it, as with `jsonnet -J vendor`
+func jsonnetSearchPath(file string, jpath []string) []string {
+	if vendor := filepath.Join(filepath.Dir(file), "vendor"); isDir(vendor) {
+		return append([]string{vendor}, jpath...)
+	}
+
+	return jpath
+}
+
+// Makes a VM evaluating programs in-process with the external variables, as strings and as code, searching for imports
+// within the directories as `--jpath` does, where the last one takes precedence
+func makeJsonnetVM(extVars, extCode map[string]string, jpath []string) *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	for name, value := range extVars {
+		vm.ExtVar(name, value)
+	}
+	for name, value := range extCode {
+		vm.ExtCode(name, value)
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: jpath})
+	return vm
+}
+
+// Splits the output of a Jsonnet program into the manifests within it. When the program evaluates to an object of
+// manifests, the manifests of each key are named by the key, in the order of the keys.
+func splitJsonnetOutput(output []byte) ([]archiveFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok || isJsonnetManifest(object) {
+		content, err := flattenJsonnetManifests("", value)
+		if err != nil {
+			return nil, err
+		}
+		return []archiveFile{{content: content}}, nil
+	}
+
+	files := make([]archiveFile, 0, len(object))
+	for _, key := range sortedKeys(object) {
+		content, err := flattenJsonnetManifests(key, object[key])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: key, content: content})
+	}
+	return files, nil
+}
+
+// Collects the manifests within the value into a JSON array
+func flattenJsonnetManifests(key string, value any) ([]byte, error) {
+	manifests := []any{}
+
+	var visit func(key string, value any) error
+	visit = func(key string, value any) error {
+		switch value := value.(type) {
+		case nil:
+			return nil
+		case []any:
+			for _, item := range value {
+				if err := visit(key, item); err != nil {
+					return err
+				}
+			}
+			return nil
+		case map[string]any:
+			if isJsonnetManifest(value) {
+				manifests = append(manifests, value)
+				return nil
+			}
+
+			for _, child := range sortedKeys(value) {
+				if err := visit(joinJsonnetKey(key, child), value[child]); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			if key == "" {
+				return fmt.Errorf("the program evaluated to a %T rather than manifests", value)
+			}
+			return fmt.Errorf("the value of %q is a %T rather than a manifest", key, value)
+		}
+	}
+	if err := visit(key, value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(manifests)
+}
+
+// Whether the object is a manifest rather than an object of manifests
+func isJsonnetManifest(object map[string]any) bool {
+	_, hasAPIVersion := object["apiVersion"]
+	_, hasKind := object["kind"]
+	return hasAPIVersion && hasKind
+}
+
+func joinJsonnetKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// Whether the path exists and is a directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+type jsonnetModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Path               types.String `tfsdk:"path"`
+	URL                types.String `tfsdk:"url"`
+	ExtVars            types.Map    `tfsdk:"ext_vars"`
+	ExtCode            types.Map    `tfsdk:"ext_code"`
+	JPath              types.List   `tfsdk:"jpath"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model jsonnetModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Jsonnet(t *testing.T) {
+	dir := writeTestJsonnet(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(jsonnetStatement, filepath.Join(dir, "main.jsonnet")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_jsonnet.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_jsonnet.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: first\ndata: {}\n"),
+					resource.TestCheckResourceAttr("data.manifest_jsonnet.test", "manifests.1", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: second\ndata:\n  replicas: 3\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestMakeJsonnetVM(t *testing.T) {
+	dir := writeTestJsonnet(t)
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "lib.libsonnet"), []byte("{ configMap(name, data):: {} }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := makeJsonnetVM(map[string]string{"name": "second"}, map[string]string{"replicas": "1 + 2"}, []string{other, filepath.Join(dir, "vendor")})
+	output, err := vm.EvaluateFile(filepath.Join(dir, "main.jsonnet"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := `{"a":[{"apiVersion":"v1","data":{},"kind":"ConfigMap","metadata":{"name":"first","uid":"1234"}}],"b":{"apiVersion":"v1","data":{"replicas":3},"kind":"ConfigMap","metadata":{"name":"second","uid":"1234"}}}`; compactJSON(t, output) != expected {
+		t.Errorf("expected %s, got %s", expected, output)
+	}
+
+	if _, err := makeJsonnetVM(nil, nil, []string{filepath.Join(dir, "vendor")}).EvaluateFile(filepath.Join(dir, "main.jsonnet")); err == nil {
+		t.Error("expected an error for an undefined external variable")
+	}
+}
+
+func TestJsonnetSearchPath(t *testing.T) {
+	dir := writeTestJsonnet(t)
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "lib.libsonnet"), []byte("{ configMap(name, data):: { name: name, source: 'jpath' } }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	main := filepath.Join(dir, "main.jsonnet")
+
+	output, err := makeJsonnetVM(map[string]string{"name": "second"}, map[string]string{"replicas": "3"}, jsonnetSearchPath(main, []string{other})).EvaluateFile(main)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := `{"a":[{"name":"first","source":"jpath"}],"b":{"name":"second","source":"jpath"}}`; compactJSON(t, output) != expected {
+		t.Errorf("expected the library of jpath to take precedence over the vendored one, got %s", output)
+	}
+
+	if actual := jsonnetSearchPath(filepath.Join(other, "main.jsonnet"), []string{dir}); !reflect.DeepEqual(actual, []string{dir}) {
+		t.Errorf("expected no vendor directory to be added, got %q", actual)
+	}
+}
+
+// Writes a program importing a library from its vendor directory into a temporary directory
+func writeTestJsonnet(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "vendor"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vendor", "lib.libsonnet"), []byte("{ configMap(name, data):: { apiVersion: 'v1', kind: 'ConfigMap', metadata: { name: name, uid: '1234' }, data: data } }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.jsonnet"), []byte("local lib = import 'lib.libsonnet';\n{ b: lib.configMap(std.extVar('name'), { replicas: std.extVar('replicas') }), a: [lib.configMap('first', {})] }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func compactJSON(t *testing.T, content string) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(content)); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	return compacted.String()
+}
+
+func TestSplitJsonnetOutput(t *testing.T) {
+	files, err := splitJsonnetOutput([]byte(`{"b": {"service": {"apiVersion": "v1", "kind": "Service"}, "account": [{"apiVersion": "v1", "kind": "ServiceAccount"}]}, "a": {"apiVersion": "v1", "kind": "ConfigMap", "data": {"size": 1.5}}, "c": null}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []archiveFile{
+		{name: "a", content: []byte(`[{"apiVersion":"v1","data":{"size":1.5},"kind":"ConfigMap"}]`)},
+		{name: "b", content: []byte(`[{"apiVersion":"v1","kind":"ServiceAccount"},{"apiVersion":"v1","kind":"Service"}]`)},
+		{name: "c", content: []byte(`[]`)},
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(files))
+	}
+	for i := range expected {
+		if files[i].name != expected[i].name || string(files[i].content) != string(expected[i].content) {
+			t.Errorf("expected file %d to be %s %s, got %s %s", i, expected[i].name, expected[i].content, files[i].name, files[i].content)
+		}
+	}
+
+	files, err = splitJsonnetOutput([]byte(`[{"apiVersion": "v1", "kind": "Namespace"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 1 || files[0].name != "" || string(files[0].content) != `[{"apiVersion":"v1","kind":"Namespace"}]` {
+		t.Errorf("unexpected files: %v", files)
+	}
+
+	if _, err := splitJsonnetOutput([]byte(`{"a": {"replicas": 3}}`)); err == nil || err.Error() != `the value of "a.replicas" is a json.Number rather than a manifest` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const jsonnetStatement = `
+data "manifest_jsonnet" "test" {
+	path = "%s"
+	ext_vars = {
+		name = "second"
+	}
+	ext_code = {
+		replicas = "3"
+	}
+	filtered_attributes = ["metadata.uid"]
+}
+`
@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+var _ datasource.DataSource = (*kustomizeDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*kustomizeDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*kustomizeDataSource)(nil)
+
+func NewKustomizeDataSource() datasource.DataSource {
+	return &kustomizeDataSource{}
+}
+
+type kustomizeDataSource struct {
+	provider *providerData
+}
+
+func (d *kustomizeDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kustomize"
+}
+
+func (d *kustomizeDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *kustomizeDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Builds a [kustomization](https://kustomize.io) into manifests, as `kustomize build` does, and optionally removes attributes from them. Exactly one of `path` or `archive_url` must be set.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"path": {
+				MarkdownDescription: "The path to a local directory containing a kustomization, relative to the working directory, or a remote Git target as accepted by kustomize (e.g. `https://github.com/kubernetes-sigs/kustomize//examples/helloWorld?ref=v3.3.1`), which can't be fetched in offline mode. Conflicts with `archive_url`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"archive_url": {
+				MarkdownDescription: "The URL of a zip archive or a tarball, optionally compressed, containing the kustomization, supporting the same schemes as the `manifest_fetch` data source. Conflicts with `path`.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"archive_path": {
+				MarkdownDescription: "The path of the directory containing the kustomization within the archive of `archive_url` (e.g. `kustomize-3.3.1/examples/helloWorld`). Defaults to the root of the archive.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"enable_helm": {
+				MarkdownDescription: "Whether the `helmCharts` of the kustomization are inflated, which requires the `helm` executable to be installed. Defaults to `false`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *kustomizeDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model kustomizeModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *kustomizeDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model kustomizeModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if countTrue(isSet(model.Path), isSet(model.ArchiveURL)) != 1 {
+		resp.Diagnostics.AddError("Invalid source", "Exactly one of path or archive_url must be set.")
+		return
+	}
+
+	target := model.Path.Value
+	if isSet(model.Path) && isRemoteKustomizeTarget(target) && d.provider != nil && d.provider.offline {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Error building kustomization", "Remote kustomizations can't be fetched in offline mode")
+		return
+	}
+	if isSet(model.ArchiveURL) {
+		dir, err := os.MkdirTemp("", "terraform-provider-manifest-kustomize-")
+		if err != nil {
+			resp.Diagnostics.AddError("Error building kustomization", fmt.Sprintf("Error creating temporary directory: %s", err))
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		response, errs := newFetcher(d.provider).fetchSequential(ctx, []string{model.ArchiveURL.Value})
+		if response == nil {
+			addFetchError(&resp.Diagnostics, model.ArchiveURL.Value, errs[0], false)
+			return
+		}
+		if err := extractArchiveToDir(response.body, dir); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("archive_url"), "Error extracting archive", fmt.Sprintf("Error extracting archive: %s", err))
+			return
+		}
+
+		target = filepath.Join(dir, filepath.FromSlash(model.ArchivePath.Value))
+		if relative, err := filepath.Rel(dir, target); err != nil || strings.HasPrefix(relative, "..") {
+			resp.Diagnostics.AddAttributeError(path.Root("archive_path"), "Invalid archive path", fmt.Sprintf("Path %q is outside of the archive", model.ArchivePath.Value))
+			return
+		}
+	}
+
+	output, err := buildKustomization(target, model.EnableHelm.Value)
+	if err != nil {
+		resp.Diagnostics.AddError("Error building kustomization", fmt.Sprintf("Error building kustomization: %s", err))
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, []archiveFile{{content: output}})
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Builds the kustomization in-process, as `kustomize build` does, returning the manifests. Inflating `helmCharts`
+// still runs the `helm` executable, as kustomize itself does.
+func buildKustomization(target string, enableHelm bool) ([]byte, error) {
+	options := krusty.MakeDefaultOptions()
+	// As with `kustomize build`, the resources are ordered by kind so namespaces and definitions precede their uses
+	options.DoLegacyResourceSort = true
+	if enableHelm {
+		options.PluginConfig.HelmConfig.Enabled = true
+		options.PluginConfig.HelmConfig.Command = "helm"
+	}
+
+	resources, err := krusty.MakeKustomizer(options).Run(filesys.MakeFsOnDisk(), target)
+	if err != nil {
+		return nil, err
+	}
+	return resources.AsYaml()
+}
+
+// Whether the target is a remote Git target rather than a local directory, such as
+// `https://github.com/org/repo//dir?ref=v1`, `git@github.com:org/repo`, or `github.com/org/repo/dir`
+func isRemoteKustomizeTarget(target string) bool {
+	if _, err := os.Stat(target); err == nil {
+		return false
+	}
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "git@") || strings.HasPrefix(target, "git::") {
+		return true
+	}
+
+	// Hosts are recognized by the dot within the first segment, which local paths relative to the working directory
+	// don't usually have
+	host, _, _ := strings.Cut(filepath.ToSlash(target), "/")
+	return strings.Contains(host, ".") && strings.Trim(host, ".") != ""
+}
+
+// Extracts every file of the archive into the directory, preserving their paths
+func extractArchiveToDir(content []byte, dir string) error {
+	body, err := decompress(content, "")
+	if err != nil {
+		return err
+	}
+	if !isArchive(body) {
+		return fmt.Errorf("the content is not a zip archive or a tarball")
+	}
+
+	files, err := extractArchive(body, "**/*")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(file.name))
+		if relative, err := filepath.Rel(dir, target); err != nil || strings.HasPrefix(relative, "..") {
+			return fmt.Errorf("%q is outside of the archive", file.name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, file.content, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type kustomizeModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Path               types.String `tfsdk:"path"`
+	ArchiveURL         types.String `tfsdk:"archive_url"`
+	ArchivePath        types.String `tfsdk:"archive_path"`
+	EnableHelm         types.Bool   `tfsdk:"enable_helm"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model kustomizeModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Kustomize(t *testing.T) {
+	dir := writeTestKustomization(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(kustomizeStatement, dir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_kustomize.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_kustomize.test", "manifests.0", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: apps\n"),
+					resource.TestCheckResourceAttr("data.manifest_kustomize.test", "manifests.1", "apiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\nmetadata:\n  name: web-config\n  namespace: apps\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestBuildKustomization(t *testing.T) {
+	output, err := buildKustomization(writeTestKustomization(t), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: apps\n---\napiVersion: v1\ndata:\n  key: value\nkind: ConfigMap\nmetadata:\n  name: web-config\n  namespace: apps\n  uid: \"1234\"\n"
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+
+	if _, err := buildKustomization(t.TempDir(), false); err == nil {
+		t.Error("expected an error for a directory without a kustomization")
+	}
+}
+
+// Writes a kustomization, listing a ConfigMap before the Namespace it belongs to, into a temporary directory
+func writeTestKustomization(t *testing.T) string {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"kustomization.yaml": "namespace: apps\nnamePrefix: web-\nresources:\n- config.yaml\n- namespace.yaml\n",
+		"config.yaml":        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  uid: \"1234\"\ndata:\n  key: value\n",
+		"namespace.yaml":     "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: apps\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIsRemoteKustomizeTarget(t *testing.T) {
+	dir := t.TempDir()
+	for target, expected := range map[string]bool{
+		dir:                   false,
+		"overlays/production": false,
+		"../base":             false,
+		"https://github.com/kubernetes-sigs/kustomize//examples/helloWorld?ref=v3.3.1": true,
+		"git@github.com:kubernetes-sigs/kustomize.git//examples/helloWorld":            true,
+		"github.com/kubernetes-sigs/kustomize/examples/helloWorld":                     true,
+	} {
+		if remote := isRemoteKustomizeTarget(target); remote != expected {
+			t.Errorf("%s: expected remote to be %t, got %t", target, expected, remote)
+		}
+	}
+}
+
+func TestExtractArchiveToDir(t *testing.T) {
+	dir := t.TempDir()
+	archive := mustZip(t, map[string]string{
+		"repo/base/kustomization.yaml": "resources:\n- config.env\n",
+		"repo/base/config.env":         "KEY=value\n",
+	})
+	if err := extractArchiveToDir(archive, dir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for name, expected := range map[string]string{"repo/base/kustomization.yaml": "resources:\n- config.env\n", "repo/base/config.env": "KEY=value\n"} {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("unexpected error reading %s: %s", name, err)
+		}
+		if string(content) != expected {
+			t.Errorf("expected %s to be %q, got %q", name, expected, content)
+		}
+	}
+
+	if err := extractArchiveToDir(mustZip(t, map[string]string{"../escape.yaml": "kind: A\n"}), dir); err == nil {
+		t.Error("expected an error for a file outside of the archive")
+	}
+	if err := extractArchiveToDir([]byte("kind: A\n"), dir); err == nil {
+		t.Error("expected an error for content which isn't an archive")
+	}
+}
+
+const kustomizeStatement = `
+data "manifest_kustomize" "test" {
+	path                = "%s"
+	filtered_attributes = ["metadata.uid"]
+}
+`
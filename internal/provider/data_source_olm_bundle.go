@@ -0,0 +1,386 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+// The annotations of a bundle describing the package it belongs to
+const (
+	bundleAnnotationPackage        = "operators.operatorframework.io.bundle.package.v1"
+	bundleAnnotationChannels       = "operators.operatorframework.io.bundle.channels.v1"
+	bundleAnnotationDefaultChannel = "operators.operatorframework.io.bundle.channel.default.v1"
+)
+
+var _ datasource.DataSource = (*olmBundleDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*olmBundleDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*olmBundleDataSource)(nil)
+
+func NewOLMBundleDataSource() datasource.DataSource {
+	return &olmBundleDataSource{}
+}
+
+type olmBundleDataSource struct {
+	provider *providerData
+}
+
+func (d *olmBundleDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_olm_bundle"
+}
+
+func (d *olmBundleDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *olmBundleDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Pulls an [Operator Lifecycle Manager](https://olm.operatorframework.io) bundle image and extracts the manifests of its `manifests` directory, such as the `ClusterServiceVersion`, the `CustomResourceDefinition` manifests, and RBAC, optionally removing attributes from them. The image is pulled using the provider's registry credentials. With `convert_csv`, the operator can be installed on clusters without OLM.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"image": {
+				MarkdownDescription: "The reference of the bundle image (e.g. `quay.io/operatorhubio/cert-manager:v1.10.1`). When it is an index of several platforms, the `linux/amd64` image is used, falling back to the first one.",
+				Type:                types.StringType,
+				Required:            true,
+			},
+			"convert_csv": {
+				MarkdownDescription: "Replace the `ClusterServiceVersion` with the manifests OLM would create from it: a `Deployment` for each of its deployments, along with a `ServiceAccount`, `Role`, `RoleBinding`, `ClusterRole`, and `ClusterRoleBinding` for each of its permissions, named after the service account. The webhooks and API services of the `ClusterServiceVersion` aren't converted, as they rely on certificates managed by OLM. Requires `namespace`. Defaults to `false`.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"namespace": {
+				MarkdownDescription: "The namespace the operator is installed into when `convert_csv` is set, which the namespaced manifests converted from the `ClusterServiceVersion` are placed in.",
+				Type:                types.StringType,
+				Optional:            true,
+			},
+			"package_name": {
+				Description: "The name of the package the bundle belongs to, as declared by its annotations.",
+				Type:        types.StringType,
+				Computed:    true,
+			},
+			"channels": {
+				Description: "The channels the bundle belongs to, as declared by its annotations.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Computed: true,
+			},
+			"default_channel": {
+				Description: "The default channel of the package, as declared by the annotations of the bundle. Empty when it isn't declared.",
+				Type:        types.StringType,
+				Computed:    true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *olmBundleDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model olmBundleModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if model.ConvertCSV.Value && model.Namespace.Null {
+		resp.Diagnostics.AddAttributeError(path.Root("namespace"), "Missing namespace", "The namespace must be set when convert_csv is set.")
+	}
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *olmBundleDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model olmBundleModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if d.provider != nil && d.provider.offline {
+		resp.Diagnostics.AddError("Error pulling bundle", "Bundle images are unavailable in offline mode")
+		return
+	}
+	ref, err := parseImageReference(model.Image.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("image"), "Invalid image", fmt.Sprintf("Invalid image: %s", err))
+		return
+	}
+
+	files, annotations, err := pullBundle(ctx, newFetcher(d.provider).registryClient(), ref)
+	if err != nil {
+		resp.Diagnostics.AddError("Error pulling bundle", fmt.Sprintf("Error pulling bundle: %s", err))
+		return
+	}
+
+	if model.ConvertCSV.Value {
+		for i, file := range files {
+			converted, warnings, err := convertBundleFile(file.content, model.Namespace.Value)
+			if err != nil {
+				resp.Diagnostics.AddError("Error converting ClusterServiceVersion", fmt.Sprintf("Error converting ClusterServiceVersion in %s: %s", file.name, err))
+				return
+			}
+			for _, warning := range warnings {
+				resp.Diagnostics.AddAttributeWarning(path.Root("convert_csv"), "Incomplete ClusterServiceVersion conversion", warning)
+			}
+			files[i].content = converted
+		}
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, files)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	channels := []string{}
+	for _, channel := range strings.Split(annotations[bundleAnnotationChannels], ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	model.PackageName = types.String{Value: annotations[bundleAnnotationPackage]}
+	model.DefaultChannel = types.String{Value: annotations[bundleAnnotationDefaultChannel]}
+	resp.Diagnostics.Append(tfsdk.ValueFrom(ctx, channels, types.ListType{ElemType: types.StringType}, &model.Channels)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Pulls the manifest files of the bundle image, in lexical order, along with the annotations of its metadata. Files in
+// later layers replace those in earlier ones, as they would in a container.
+func pullBundle(ctx context.Context, registry *registryClient, ref imageReference) ([]archiveFile, map[string]string, error) {
+	raw, _, err := registry.manifest(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest for %s: %w", ref, err)
+	}
+	if len(manifest.Manifests) > 0 {
+		// Bundles only contain files, so the image of any platform will do
+		selected := manifest.Manifests[0]
+		for _, descriptor := range manifest.Manifests {
+			if descriptor.Platform != nil && descriptor.Platform.OS == "linux" && descriptor.Platform.Architecture == "amd64" {
+				selected = descriptor
+				break
+			}
+		}
+
+		ref.digest = selected.Digest
+		if raw, _, err = registry.manifest(ctx, ref); err != nil {
+			return nil, nil, err
+		}
+		manifest = ociManifest{}
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse manifest for %s: %w", ref, err)
+		}
+	}
+
+	contents := map[string][]byte{}
+	isBundleFile := func(name string) bool {
+		return name == "metadata/annotations.yaml" || (strings.HasPrefix(name, "manifests/") && strings.Count(name, "/") == 1 && hasManifestExtension(name))
+	}
+	for _, layer := range manifest.Layers {
+		content, err := registry.blob(ctx, ref, layer.Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		extracted, err := extractTar(content, isGzip(content), isBundleFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to extract layer %s: %w", layer.Digest, err)
+		}
+		for _, file := range extracted {
+			contents[file.name] = file.content
+		}
+	}
+
+	var metadata struct {
+		Annotations map[string]string `yaml:"annotations"`
+	}
+	if content, ok := contents["metadata/annotations.yaml"]; ok {
+		if err := yaml.Unmarshal(content, &metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse metadata/annotations.yaml: %w", err)
+		}
+		delete(contents, "metadata/annotations.yaml")
+	}
+	if len(contents) == 0 {
+		return nil, nil, fmt.Errorf("%s has no manifests directory, so it isn't a bundle", ref)
+	}
+
+	files := make([]archiveFile, 0, len(contents))
+	for name, content := range contents {
+		files = append(files, archiveFile{name: name, content: content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, metadata.Annotations, nil
+}
+
+// Replaces the ClusterServiceVersion manifests within the file with the manifests converted from them. Files without
+// one are returned as-is.
+func convertBundleFile(content []byte, namespace string) ([]byte, []string, error) {
+	var manifests []any
+	var warnings []string
+	converted := false
+	err := unmarshalAllManifests(content, formatAuto, nil, func(manifest map[any]any, _ []byte) error {
+		if stringValue(manifest["kind"]) != "ClusterServiceVersion" || !strings.HasPrefix(stringValue(manifest["apiVersion"]), "operators.coreos.com/") {
+			manifests = append(manifests, stringKeyed(manifest))
+			return nil
+		}
+
+		csvManifests, csvWarnings, err := convertClusterServiceVersion(manifest, namespace)
+		if err != nil {
+			return err
+		}
+		for _, csvManifest := range csvManifests {
+			manifests = append(manifests, stringKeyed(csvManifest))
+		}
+		warnings = append(warnings, csvWarnings...)
+		converted = true
+		return nil
+	})
+	if err != nil || !converted {
+		return content, nil, err
+	}
+
+	encoded, err := json.Marshal(manifests)
+	return encoded, warnings, err
+}
+
+// Converts the install strategy of the ClusterServiceVersion into the manifests OLM would create for it, returning
+// warnings about the parts which can't be converted
+func convertClusterServiceVersion(csv map[any]any, namespace string) ([]map[any]any, []string, error) {
+	metadata, _ := csv["metadata"].(map[any]any)
+	name := stringValue(metadata["name"])
+	spec, _ := csv["spec"].(map[any]any)
+
+	strategy, _ := lookupMap(spec, "install")
+	if kind := stringValue(strategy["strategy"]); kind != "deployment" {
+		return nil, nil, fmt.Errorf("the install strategy %q of %s isn't supported", kind, name)
+	}
+	install, _ := lookupMap(strategy, "spec")
+
+	var manifests []map[any]any
+	serviceAccounts := map[string]bool{}
+	addServiceAccount := func(serviceAccount string) {
+		if !serviceAccounts[serviceAccount] {
+			serviceAccounts[serviceAccount] = true
+			manifests = append(manifests, map[any]any{
+				"apiVersion": "v1",
+				"kind":       "ServiceAccount",
+				"metadata":   map[any]any{"name": serviceAccount, "namespace": namespace},
+			})
+		}
+	}
+
+	for _, scope := range []struct {
+		field     string
+		role      string
+		binding   string
+		namespace bool
+	}{{"permissions", "Role", "RoleBinding", true}, {"clusterPermissions", "ClusterRole", "ClusterRoleBinding", false}} {
+		permissions, _ := install[scope.field].([]any)
+		for _, item := range permissions {
+			permission, _ := item.(map[any]any)
+			serviceAccount := stringValue(permission["serviceAccountName"])
+			if serviceAccount == "" {
+				return nil, nil, fmt.Errorf("the %s of %s must each have a serviceAccountName", scope.field, name)
+			}
+			addServiceAccount(serviceAccount)
+
+			objectMetadata := func() map[any]any {
+				if scope.namespace {
+					return map[any]any{"name": serviceAccount, "namespace": namespace}
+				}
+				return map[any]any{"name": serviceAccount}
+			}
+			rules, _ := permission["rules"].([]any)
+			if rules == nil {
+				rules = []any{}
+			}
+			manifests = append(manifests,
+				map[any]any{
+					"apiVersion": "rbac.authorization.k8s.io/v1",
+					"kind":       scope.role,
+					"metadata":   objectMetadata(),
+					"rules":      rules,
+				},
+				map[any]any{
+					"apiVersion": "rbac.authorization.k8s.io/v1",
+					"kind":       scope.binding,
+					"metadata":   objectMetadata(),
+					"roleRef":    map[any]any{"apiGroup": "rbac.authorization.k8s.io", "kind": scope.role, "name": serviceAccount},
+					"subjects":   []any{map[any]any{"kind": "ServiceAccount", "name": serviceAccount, "namespace": namespace}},
+				},
+			)
+		}
+	}
+
+	deployments, _ := install["deployments"].([]any)
+	for _, item := range deployments {
+		deployment, _ := item.(map[any]any)
+		deploymentMetadata := map[any]any{"name": stringValue(deployment["name"]), "namespace": namespace}
+		if labels, ok := deployment["label"].(map[any]any); ok {
+			deploymentMetadata["labels"] = labels
+		}
+
+		deploymentSpec, _ := deployment["spec"].(map[any]any)
+		if podSpec, ok := lookupMap(deploymentSpec, "template", "spec"); ok {
+			if serviceAccount := stringValue(podSpec["serviceAccountName"]); serviceAccount != "" {
+				addServiceAccount(serviceAccount)
+			}
+		}
+		manifests = append(manifests, map[any]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   deploymentMetadata,
+			"spec":       deploymentSpec,
+		})
+	}
+
+	var warnings []string
+	if webhooks, _ := spec["webhookdefinitions"].([]any); len(webhooks) > 0 {
+		warnings = append(warnings, fmt.Sprintf("The webhooks of %s aren't converted, as they rely on certificates managed by OLM.", name))
+	}
+	if owned, ok := lookupMap(spec, "apiservicedefinitions"); ok {
+		if services, _ := owned["owned"].([]any); len(services) > 0 {
+			warnings = append(warnings, fmt.Sprintf("The API services of %s aren't converted, as they rely on certificates managed by OLM.", name))
+		}
+	}
+	return manifests, warnings, nil
+}
+
+type olmBundleModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Image              types.String `tfsdk:"image"`
+	ConvertCSV         types.Bool   `tfsdk:"convert_csv"`
+	Namespace          types.String `tfsdk:"namespace"`
+	PackageName        types.String `tfsdk:"package_name"`
+	Channels           types.List   `tfsdk:"channels"`
+	DefaultChannel     types.String `tfsdk:"default_channel"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model olmBundleModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestPullBundle(t *testing.T) {
+	registry := setupMockBundleRegistry(t)
+	defer registry.Close()
+
+	ref, err := parseImageReference(strings.TrimPrefix(registry.URL, "http://") + "/example/bundle:v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files, annotations, err := pullBundle(context.Background(), newFetcher(nil).registryClient(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+	if expected := []string{"manifests/app.clusterserviceversion.yaml", "manifests/crd.yaml"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected files %q, got %q", expected, names)
+	}
+	if string(files[1].content) != "kind: CustomResourceDefinition\n" {
+		t.Errorf("expected the file of the last layer, got %q", files[1].content)
+	}
+	if annotations[bundleAnnotationPackage] != "app" || annotations[bundleAnnotationChannels] != "stable,fast" {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+}
+
+func TestConvertClusterServiceVersion(t *testing.T) {
+	converted, warnings, err := convertBundleFile([]byte(clusterServiceVersionDocument), "operators")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "webhooks") {
+		t.Errorf("expected a warning about the webhooks, got %q", warnings)
+	}
+
+	var manifests []map[string]any
+	if err := json.Unmarshal(converted, &manifests); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var kinds []string
+	for _, manifest := range manifests {
+		metadata, _ := manifest["metadata"].(map[string]any)
+		kinds = append(kinds, manifest["kind"].(string)+" "+stringValue(metadata["namespace"])+"/"+stringValue(metadata["name"]))
+	}
+	expected := []string{
+		"ServiceAccount operators/app-operator",
+		"Role operators/app-operator",
+		"RoleBinding operators/app-operator",
+		"ClusterRole /app-operator",
+		"ClusterRoleBinding /app-operator",
+		"Deployment operators/app-controller",
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected %q, got %q", expected, kinds)
+	}
+
+	binding := manifests[4]
+	if subjects := binding["subjects"].([]any); subjects[0].(map[string]any)["namespace"] != "operators" {
+		t.Errorf("expected the subject to be in the namespace, got %v", subjects)
+	}
+	if replicas := manifests[5]["spec"].(map[string]any)["replicas"]; replicas != float64(1) {
+		t.Errorf("expected the deployment spec to be kept, got %v", manifests[5]["spec"])
+	}
+
+	unchanged := []byte("apiVersion: v1\nkind: ConfigMap\n")
+	if converted, _, err := convertBundleFile(unchanged, "operators"); err != nil || string(converted) != string(unchanged) {
+		t.Errorf("expected files without a ClusterServiceVersion to be unchanged, got %q (%v)", converted, err)
+	}
+}
+
+// A registry serving a bundle image for several platforms, where the second layer replaces a file of the first
+func setupMockBundleRegistry(t *testing.T) *httptest.Server {
+	annotations, _ := yaml.Marshal(map[string]any{"annotations": map[string]string{bundleAnnotationPackage: "app", bundleAnnotationChannels: "stable,fast"}})
+	first := mustTarGz(t, map[string]string{
+		"manifests/app.clusterserviceversion.yaml": clusterServiceVersionDocument,
+		"manifests/crd.yaml":                       "kind: Outdated\n",
+		"metadata/annotations.yaml":                string(annotations),
+		"Dockerfile":                               "FROM scratch\n",
+	})
+	second := mustTarGz(t, map[string]string{"manifests/crd.yaml": "kind: CustomResourceDefinition\n"})
+
+	image, _ := json.Marshal(ociManifest{
+		MediaType: mediaTypeDockerManifest,
+		Layers: []ociDescriptor{
+			{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: "sha256:" + sha256Hex(first)},
+			{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: "sha256:" + sha256Hex(second)},
+		},
+	})
+	imageDigest := "sha256:" + sha256Hex(image)
+	index, _ := json.Marshal(map[string]any{
+		"mediaType": mediaTypeOCIIndex,
+		"manifests": []map[string]any{
+			{"mediaType": mediaTypeOCIManifest, "digest": "sha256:0000", "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+			{"mediaType": mediaTypeDockerManifest, "digest": imageDigest, "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+		},
+	})
+
+	contents := map[string][]byte{
+		"/v2/example/bundle/manifests/v1":                      index,
+		"/v2/example/bundle/manifests/" + imageDigest:          image,
+		"/v2/example/bundle/blobs/sha256:" + sha256Hex(first):  first,
+		"/v2/example/bundle/blobs/sha256:" + sha256Hex(second): second,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := contents[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+}
+
+const clusterServiceVersionDocument = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: app.v1.0.0
+spec:
+  install:
+    strategy: deployment
+    spec:
+      permissions:
+      - serviceAccountName: app-operator
+        rules:
+        - apiGroups: [""]
+          resources: [configmaps]
+          verbs: [get, list, watch]
+      clusterPermissions:
+      - serviceAccountName: app-operator
+        rules:
+        - apiGroups: [example.com]
+          resources: [apps]
+          verbs: ["*"]
+      deployments:
+      - name: app-controller
+        spec:
+          replicas: 1
+          selector:
+            matchLabels:
+              app: app
+          template:
+            metadata:
+              labels:
+                app: app
+            spec:
+              serviceAccountName: app-operator
+              containers:
+              - name: manager
+                image: example.com/app:v1.0.0
+  webhookdefinitions:
+  - type: ValidatingAdmissionWebhook
+    generateName: vapp.example.com
+`
@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"carvel.dev/ytt/pkg/cmd/template"
+	"carvel.dev/ytt/pkg/cmd/ui"
+	"carvel.dev/ytt/pkg/files"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = (*yttDataSource)(nil)
+var _ datasource.DataSourceWithConfigure = (*yttDataSource)(nil)
+var _ datasource.DataSourceWithValidateConfig = (*yttDataSource)(nil)
+
+func NewYttDataSource() datasource.DataSource {
+	return &yttDataSource{}
+}
+
+type yttDataSource struct {
+	provider *providerData
+}
+
+func (d *yttDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_ytt"
+}
+
+func (d *yttDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		d.provider = data
+	}
+}
+
+func (d *yttDataSource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		MarkdownDescription: "Renders [ytt](https://carvel.dev/ytt/) templates, such as those published by Carvel-based projects, into manifests, and optionally removes attributes from them. At least one of `paths` or `urls` must be set.",
+		Attributes: withRenderedAttributes(map[string]tfsdk.Attribute{
+			"paths": {
+				Description: "The paths to local template files or directories of them, relative to the working directory.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"urls": {
+				MarkdownDescription: "The URLs of template files, supporting the same schemes as the `manifest_fetch` data source. They are rendered along with `paths`, after them.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"data_values": {
+				MarkdownDescription: "The data values to set as strings, keyed by their path (e.g. `app.namespace`), equivalent to `--data-value`.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"data_values_yaml": {
+				MarkdownDescription: "The data values to set, keyed by their path, with each value decoded as YAML (e.g. `3` or `[a, b]`), equivalent to `--data-value-yaml`.",
+				Type: types.MapType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+			"data_values_files": {
+				MarkdownDescription: "The paths to YAML files of data values, relative to the working directory, equivalent to `--data-values-file`. Values set by `data_values` and `data_values_yaml` take precedence.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Optional: true,
+			},
+		}),
+		Blocks: withRenderedBlocks(map[string]tfsdk.Block{}),
+	}, nil
+}
+
+func (d *yttDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model yttModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(model.filters().validate(ctx, req.Config)...)
+}
+
+func (d *yttDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model yttModel
+	diags := req.Config.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	paths := parseTfList(ctx, model.Paths, func(path string) string { return path })
+	urls := parseTfList(ctx, model.URLs, func(url string) string { return url })
+	if len(paths) == 0 && len(urls) == 0 {
+		resp.Diagnostics.AddError("Invalid source", "At least one of paths or urls must be set.")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "terraform-provider-manifest-ytt-")
+	if err != nil {
+		resp.Diagnostics.AddError("Error rendering templates", fmt.Sprintf("Error creating temporary directory: %s", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fetched, failed, err := fetchToDir(ctx, newFetcher(d.provider), urls, dir, ".yml", ".yaml", ".star", ".txt")
+	if err != nil {
+		addFetchError(&resp.Diagnostics, failed, err, true)
+		return
+	}
+
+	options := template.NewOptions()
+	options.DataValuesFlags.FromFiles = parseTfList(ctx, model.DataValuesFiles, func(file string) string { return file })
+	for _, flag := range []struct {
+		values types.Map
+		target *[]string
+	}{{model.DataValues, &options.DataValuesFlags.KVsFromStrings}, {model.DataValuesYAML, &options.DataValuesFlags.KVsFromYAML}} {
+		values := map[string]string{}
+		resp.Diagnostics.Append(flag.values.ElementsAs(ctx, &values, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		for _, name := range sortedKeys(values) {
+			*flag.target = append(*flag.target, name+"="+values[name])
+		}
+	}
+
+	output, err := renderYtt(options, append(paths, fetched...))
+	if err != nil {
+		resp.Diagnostics.AddError("Error rendering templates", fmt.Sprintf("Error rendering templates: %s", err))
+		return
+	}
+
+	model.Manifests, model.ID, diags = model.filters().decode(ctx, d.provider, []archiveFile{{content: output}})
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, model)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Renders the template files and directories in-process, as `ytt` does, returning the manifests
+func renderYtt(options *template.Options, paths []string) ([]byte, error) {
+	templateFiles, err := files.NewSortedFilesFromPaths(paths, *options.RegularFilesSourceOpts.SymlinkAllowOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	output := options.RunWithFiles(template.Input{Files: templateFiles}, ui.NewCustomWriterTTY(false, io.Discard, io.Discard))
+	if output.Err != nil {
+		return nil, output.Err
+	}
+	return output.DocSet.AsBytes()
+}
+
+type yttModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Paths              types.List   `tfsdk:"paths"`
+	URLs               types.List   `tfsdk:"urls"`
+	DataValues         types.Map    `tfsdk:"data_values"`
+	DataValuesYAML     types.Map    `tfsdk:"data_values_yaml"`
+	DataValuesFiles    types.List   `tfsdk:"data_values_files"`
+	FilteredAttributes types.List   `tfsdk:"filtered_attributes"`
+	OnlyResources      types.List   `tfsdk:"only_resources"`
+	Manifests          types.List   `tfsdk:"manifests"`
+
+	Filter []filterBlockModel `tfsdk:"filter"`
+}
+
+func (model yttModel) filters() renderedFilters {
+	return renderedFilters{FilteredAttributes: model.FilteredAttributes, OnlyResources: model.OnlyResources, Filter: model.Filter}
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"carvel.dev/ytt/pkg/cmd/template"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Ytt(t *testing.T) {
+	dir := writeTestYttTemplates(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(yttStatement, dir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_ytt.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_ytt.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  replicas: \"3\"\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestRenderYtt(t *testing.T) {
+	dir := writeTestYttTemplates(t)
+	valuesFile := filepath.Join(t.TempDir(), "production.yml")
+	if err := os.WriteFile(valuesFile, []byte("name: production\nreplicas: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := template.NewOptions()
+	options.DataValuesFlags.FromFiles = []string{valuesFile}
+	options.DataValuesFlags.KVsFromStrings = []string{"name=web"}
+	output, err := renderYtt(options, []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n  uid: \"1234\"\ndata:\n  replicas: \"2\"\n"; string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+
+	options = template.NewOptions()
+	options.DataValuesFlags.KVsFromYAML = []string{"replicas=[1]"}
+	if _, err := renderYtt(options, []string{dir}); err == nil {
+		t.Error("expected an error for a data value which doesn't match the schema")
+	}
+}
+
+// Writes a schema of data values and a ConfigMap templated with them into a temporary directory
+func writeTestYttTemplates(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "values.yml"), []byte("#@data/values-schema\n---\nname: app\nreplicas: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte("#@ load(\"@ytt:data\", \"data\")\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: #@ data.values.name\n  uid: \"1234\"\ndata:\n  replicas: #@ str(data.values.replicas)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+const yttStatement = `
+data "manifest_ytt" "test" {
+	paths = ["%s"]
+	data_values = {
+		name = "web"
+	}
+	data_values_yaml = {
+		replicas = "3"
+	}
+	filtered_attributes = ["metadata.uid"]
+}
+`
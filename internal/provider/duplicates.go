@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	deduplicateExact    = "exact"
+	deduplicateKeepLast = "keep_last"
+	deduplicateError    = "error"
+)
+
+var deduplicateModes = []string{deduplicateExact, deduplicateKeepLast, deduplicateError}
+
+// The resource type, namespace, and name of the manifest, or an empty string when it has no name
+func (m decodedManifest) identity() string {
+	if m.name == "" {
+		return ""
+	}
+	identity := m.apiVersion + "/" + m.kind + " " + m.name
+	if m.namespace != "" {
+		identity = m.apiVersion + "/" + m.kind + " " + m.namespace + "/" + m.name
+	}
+	return identity
+}
+
+// Removes the manifests which are identical to an earlier one. Depending on the mode, the manifests which share their
+// identity with another but differ in content are either all kept, only the last is kept, or they fail with an error
+// describing them.
+func deduplicateManifests(manifests []orderedManifest, mode string) ([]orderedManifest, error) {
+	if mode == "" {
+		return manifests, nil
+	}
+
+	seen := map[string]bool{}
+	var unique []orderedManifest
+	for _, manifest := range manifests {
+		if !seen[manifest.content] {
+			seen[manifest.content] = true
+			unique = append(unique, manifest)
+		}
+	}
+
+	switch mode {
+	case deduplicateKeepLast:
+		last := map[string]int{}
+		for i, manifest := range unique {
+			if identity := manifest.decoded.identity(); identity != "" {
+				last[identity] = i
+			}
+		}
+
+		var kept []orderedManifest
+		for i, manifest := range unique {
+			if identity := manifest.decoded.identity(); identity == "" || last[identity] == i {
+				kept = append(kept, manifest)
+			}
+		}
+		return kept, nil
+	case deduplicateError:
+		if conflicts := conflictingManifests(unique); len(conflicts) > 0 {
+			return nil, fmt.Errorf("the following manifests are defined more than once with different content: %s", strings.Join(conflicts, ", "))
+		}
+	}
+	return unique, nil
+}
+
+// The identities shared by several manifests with different content, in the order they first appear
+func conflictingManifests(manifests []orderedManifest) []string {
+	contents := map[string]map[string]bool{}
+	var conflicts []string
+	for _, manifest := range manifests {
+		identity := manifest.decoded.identity()
+		if identity == "" {
+			continue
+		}
+
+		if contents[identity] == nil {
+			contents[identity] = map[string]bool{}
+		}
+		contents[identity][manifest.content] = true
+		if len(contents[identity]) == 2 && !contains(conflicts, identity) {
+			conflicts = append(conflicts, identity)
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Deduplicate(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(deduplicateStatement, server.URL, server.URL, "exact"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager"))),
+			},
+			{
+				Config:      fmt.Sprintf(deduplicateStatement, server.URL, server.URL, "first"),
+				ExpectError: regexp.MustCompile(`Invalid deduplication "first"`),
+			},
+		},
+	})
+}
+
+func TestDataSource_FailOnDuplicates(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(failOnDuplicatesStatement, server.URL, "named"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "6"),
+			},
+			{
+				Config:      fmt.Sprintf(failOnDuplicatesStatement, server.URL, "conflicting"),
+				ExpectError: regexp.MustCompile(`defined more than once with different content(.|\n)*v1/ConfigMap app`),
+			},
+		},
+	})
+}
+
+func TestDeduplicateManifests(t *testing.T) {
+	manifests := []orderedManifest{
+		{source: 0, decoded: decodedManifest{apiVersion: "v1", kind: "ConfigMap", namespace: "app", name: "config"}, content: "a"},
+		{source: 1, decoded: decodedManifest{apiVersion: "v1", kind: "ConfigMap", namespace: "app", name: "config"}, content: "a"},
+		{source: 2, decoded: decodedManifest{apiVersion: "v1", kind: "Service", name: "web"}, content: "b"},
+		{source: 3, decoded: decodedManifest{apiVersion: "v1", kind: "ConfigMap", namespace: "app", name: "config"}, content: "c"},
+	}
+
+	for mode, expected := range map[string][]int{
+		deduplicateExact:    {0, 2, 3},
+		deduplicateKeepLast: {2, 3},
+		"":                  {0, 1, 2, 3},
+	} {
+		deduplicated, err := deduplicateManifests(manifests, mode)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", mode, err)
+		}
+
+		var sources []int
+		for _, manifest := range deduplicated {
+			sources = append(sources, manifest.source)
+		}
+		if !reflect.DeepEqual(sources, expected) {
+			t.Errorf("%s: expected %v, got %v", mode, expected, sources)
+		}
+	}
+
+	_, err := deduplicateManifests(manifests, deduplicateError)
+	if err == nil || err.Error() != "the following manifests are defined more than once with different content: v1/ConfigMap app/config" {
+		t.Errorf("expected the conflicting config maps to be reported, got %v", err)
+	}
+}
+
+func TestConflictingManifests(t *testing.T) {
+	manifests := []orderedManifest{
+		{decoded: decodedManifest{apiVersion: "v1", kind: "ConfigMap", name: "config"}, content: "a"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "ConfigMap", name: "config"}, content: "a"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "Service", name: "web"}, content: "b"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "Service", name: "web"}, content: "c"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "Service", name: "web"}, content: "d"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "List"}, content: "e"},
+		{decoded: decodedManifest{apiVersion: "v1", kind: "List"}, content: "f"},
+	}
+	if conflicts := conflictingManifests(manifests); !reflect.DeepEqual(conflicts, []string{"v1/Service web"}) {
+		t.Errorf("expected only the services to conflict, got %v", conflicts)
+	}
+}
+
+const deduplicateStatement = `
+data "manifest_fetch" "test" {
+	urls        = ["%s/named", "%s/named"]
+	deduplicate = %q
+}
+`
+
+const failOnDuplicatesStatement = `
+data "manifest_fetch" "test" {
+	urls               = ["%[1]s/%[2]s", "%[1]s/%[2]s"]
+	fail_on_duplicates = true
+}
+`
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var envInjectionsBlock = tfsdk.Block{
+	MarkdownDescription: "Add environment variables to the containers of the matching workloads, such as proxy variables. Variables which already exist are overridden, including those set from a `valueFrom`, while the others are appended in the order of their names. Every selecting field is a regular expression which must match the whole value, and every workload is selected when none are set.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Attributes: withTargetAttributes(map[string]tfsdk.Attribute{
+		"container": {
+			MarkdownDescription: "A regular expression the name of the containers and init containers must match. Every container is selected when unset.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"env": {
+			MarkdownDescription: "The values of the environment variables, keyed by their names.",
+			Type:                types.MapType{ElemType: types.StringType},
+			Required:            true,
+		},
+	}),
+}
+
+type envInjectionModel struct {
+	Group         types.String `tfsdk:"group"`
+	Version       types.String `tfsdk:"version"`
+	Kind          types.String `tfsdk:"kind"`
+	Name          types.String `tfsdk:"name"`
+	Namespace     types.String `tfsdk:"namespace"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+	Container     types.String `tfsdk:"container"`
+	Env           types.Map    `tfsdk:"env"`
+}
+
+// Sets environment variables in the containers of workloads
+type envInjector struct {
+	injections []envInjection
+}
+
+type envInjection struct {
+	workloads *patchTarget
+	container *regexp.Regexp
+	names     []string
+	values    map[string]string
+}
+
+// Compiles the injections into an injector, failing with the path of the first invalid attribute. A nil injector is
+// returned when there are no injections.
+func compileEnvInjector(ctx context.Context, models []envInjectionModel) (*envInjector, path.Path, error) {
+	if len(models) == 0 {
+		return nil, path.Empty(), nil
+	}
+
+	injector := &envInjector{}
+	for i, model := range models {
+		at := path.Root("env_injections").AtListIndex(i)
+		workloads, field, err := compilePatchTarget(patchTargetModel{model.Group, model.Version, model.Kind, model.Name, model.Namespace, model.LabelSelector})
+		if err != nil {
+			return nil, at.AtName(field), err
+		}
+
+		injection := envInjection{workloads: workloads, values: map[string]string{}}
+		if isSet(model.Container) {
+			if injection.container, err = regexp.Compile("^(?:" + model.Container.Value + ")$"); err != nil {
+				return nil, at.AtName("container"), fmt.Errorf("invalid regular expression: %w", err)
+			}
+		}
+		if diags := model.Env.ElementsAs(ctx, &injection.values, false); diags.HasError() {
+			return nil, at.AtName("env"), fmt.Errorf("the values must be strings")
+		}
+		for name := range injection.values {
+			injection.names = append(injection.names, name)
+		}
+		sort.Strings(injection.names)
+
+		injector.injections = append(injector.injections, injection)
+	}
+	return injector, path.Empty(), nil
+}
+
+// Sets the environment variables in the matching containers of the workload, returning whether any changed
+func (e *envInjector) apply(manifest map[any]any) bool {
+	if e == nil {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil {
+		return false
+	}
+
+	modified := false
+	for _, injection := range e.injections {
+		if !injection.workloads.allows(manifest) {
+			continue
+		}
+
+		for _, container := range podContainers(spec) {
+			if injection.container != nil && !injection.container.MatchString(stringValue(container["name"])) {
+				continue
+			}
+			modified = injection.inject(container) || modified
+		}
+	}
+	return modified
+}
+
+// Sets the environment variables in the container, returning whether any changed
+func (i envInjection) inject(container map[any]any) bool {
+	env, _ := container["env"].([]any)
+
+	modified := false
+	for _, name := range i.names {
+		variable := map[any]any{"name": name, "value": i.values[name]}
+
+		replaced := false
+		for index, existing := range env {
+			if existing, ok := existing.(map[any]any); ok && existing["name"] == name {
+				if existing["value"] != i.values[name] || existing["valueFrom"] != nil {
+					env[index] = variable
+					modified = true
+				}
+				replaced = true
+			}
+		}
+		if !replaced {
+			env = append(env, variable)
+			modified = true
+		}
+	}
+
+	container["env"] = env
+	return modified
+}
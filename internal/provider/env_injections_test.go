@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_EnvInjections(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	injected := strings.Replace(workloadDocument, "        name: controller\n", "        name: controller\n        env:\n        - name: HTTPS_PROXY\n          value: http://proxy:3128\n", 1)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(envInjectionsStatement, server.URL, "controller"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", injected)),
+			},
+			{
+				Config:      fmt.Sprintf(envInjectionsStatement, server.URL, "("),
+				ExpectError: regexp.MustCompile(`Invalid environment injection`),
+			},
+		},
+	})
+}
+
+func TestEnvInjector(t *testing.T) {
+	env := types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{
+		"NO_PROXY":    types.String{Value: "localhost"},
+		"HTTPS_PROXY": types.String{Value: "http://proxy:3128"},
+	}}
+	injector, _, err := compileEnvInjector(context.Background(), []envInjectionModel{{Kind: types.String{Value: "Deployment"}, Container: types.String{Value: "controller"}, Env: env}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	deployment := map[any]any{
+		"kind": "Deployment",
+		"spec": map[any]any{"template": map[any]any{"spec": map[any]any{
+			"containers": []any{
+				map[any]any{"name": "controller", "env": []any{
+					map[any]any{"name": "LOG_LEVEL", "value": "info"},
+					map[any]any{"name": "NO_PROXY", "valueFrom": map[any]any{"configMapKeyRef": map[any]any{"name": "proxy", "key": "exclusions"}}},
+				}},
+				map[any]any{"name": "sidecar"},
+			},
+		}}},
+	}
+	if !injector.apply(deployment) {
+		t.Error("expected the deployment to be modified")
+	}
+
+	containers := podContainers(podSpec(deployment))
+	expected := []any{
+		map[any]any{"name": "LOG_LEVEL", "value": "info"},
+		map[any]any{"name": "NO_PROXY", "value": "localhost"},
+		map[any]any{"name": "HTTPS_PROXY", "value": "http://proxy:3128"},
+	}
+	if !reflect.DeepEqual(containers[0]["env"], expected) {
+		t.Errorf("expected %v, got %v", expected, containers[0]["env"])
+	}
+	if _, ok := containers[1]["env"]; ok {
+		t.Error("expected the sidecar to be left untouched")
+	}
+
+	if injector.apply(deployment) {
+		t.Error("expected injecting the same variables again to change nothing")
+	}
+	if injector.apply(map[any]any{"kind": "StatefulSet", "spec": map[any]any{"template": map[any]any{"spec": map[any]any{"containers": []any{map[any]any{"name": "controller"}}}}}}) {
+		t.Error("expected a workload of another kind to be left untouched")
+	}
+}
+
+const envInjectionsStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/workload"
+
+	env_injections {
+		kind      = "Deployment"
+		container = %q
+		env = {
+			HTTPS_PROXY = "http://proxy:3128"
+		}
+	}
+}
+`
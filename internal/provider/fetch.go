@@ -0,0 +1,375 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// An error encountered while fetching a URL, split into a summary and detail for diagnostics
+type fetchError struct {
+	summary string
+	detail  string
+}
+
+func (e *fetchError) Error() string {
+	return e.detail
+}
+
+func newFetchError(summary string, format string, args ...any) *fetchError {
+	return &fetchError{
+		summary: summary,
+		detail:  fmt.Sprintf(summary+": "+format, args...),
+	}
+}
+
+// Adds the error for a specific URL to the diagnostics, optionally including the URL in the detail
+func addFetchError(diags *diag.Diagnostics, url string, err error, includeURL bool) {
+	summary, detail := "Error fetching manifest", err.Error()
+	if fetchErr, ok := err.(*fetchError); ok {
+		summary = fetchErr.summary
+	}
+
+	if includeURL {
+		detail = fmt.Sprintf("%s (%s)", detail, url)
+	}
+
+	diags.AddError(summary, detail)
+}
+
+// The validators of a previous response, used to make conditional requests
+type cacheValidators struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type fetchResponse struct {
+	url          string
+	body         []byte
+	etag         string
+	lastModified string
+	notModified  bool
+
+	// The Content-Encoding of HTTP responses which was not transparently decoded
+	contentEncoding string
+	contentType     string
+
+	// The files the body was joined from, for sources which read multiple files
+	files []archiveFile
+}
+
+// The validators which can be used to conditionally request the same content
+func (r *fetchResponse) validators() *cacheValidators {
+	if r.etag == "" && r.lastModified == "" {
+		return nil
+	}
+
+	return &cacheValidators{
+		URL:          r.url,
+		ETag:         r.etag,
+		LastModified: r.lastModified,
+	}
+}
+
+// The number of URLs or files processed at once when not configured
+const defaultParallelism = 4
+
+// Retrieves content from the supported sources
+type fetcher struct {
+	client   *http.Client
+	provider *providerData
+
+	// The maximum number of URLs or files to process at once
+	parallelism int
+
+	registryOnce sync.Once
+	registry     *registryClient
+}
+
+func newFetcher(provider *providerData) *fetcher {
+	return &fetcher{
+		client:      provider.httpClient(),
+		provider:    provider,
+		parallelism: defaultParallelism,
+	}
+}
+
+// Retrieves the body of the URL, ensuring the server responded successfully
+func (f *fetcher) fetchURL(ctx context.Context, url string) ([]byte, error) {
+	response, err := f.fetch(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.body, nil
+}
+
+// Retrieves the URL using the source for its scheme. When validators are provided for an HTTP URL, the request is
+// made conditional and a not modified response may be returned without a body.
+func (f *fetcher) fetch(ctx context.Context, rawURL string, previous *cacheValidators) (*fetchResponse, error) {
+	// Conditional requests depend on the previous response, so they can't be shared
+	if previous != nil || f.provider == nil {
+		return f.fetchUncached(ctx, rawURL, previous)
+	}
+
+	return f.provider.memo.do(ctx, memoKey(ctx, rawURL), func() (*fetchResponse, error) {
+		return f.fetchUncached(ctx, rawURL, nil)
+	})
+}
+
+// Retrieves the URL using the source for its scheme, bypassing the memoized responses
+func (f *fetcher) fetchUncached(ctx context.Context, rawURL string, previous *cacheValidators) (*fetchResponse, error) {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil, newFetchError("Invalid URL", "%s", err)
+	}
+
+	// Sources other than HTTP don't use the provider's transport, so they are cached separately
+	switch parsed.Scheme {
+	case "file":
+		return fetchFile(parsed)
+	case "oci":
+		return f.fetchCached(ctx, rawURL, func() (*fetchResponse, error) { return f.fetchOCI(ctx, parsed) })
+	case "s3":
+		return f.fetchCached(ctx, rawURL, func() (*fetchResponse, error) {
+			return f.withRequestSlot(ctx, func() (*fetchResponse, error) { return f.fetchS3(ctx, parsed) })
+		})
+	case "gs":
+		return f.fetchCached(ctx, rawURL, func() (*fetchResponse, error) { return f.fetchGCS(ctx, parsed) })
+	case "azblob":
+		return f.fetchCached(ctx, rawURL, func() (*fetchResponse, error) { return f.fetchAzureBlob(ctx, parsed) })
+	case "sftp":
+		return f.fetchCached(ctx, rawURL, func() (*fetchResponse, error) {
+			return f.withRequestSlot(ctx, func() (*fetchResponse, error) { return f.fetchSFTP(ctx, parsed) })
+		})
+	default:
+		return f.fetchHTTP(ctx, rawURL, previous)
+	}
+}
+
+// Holds a slot of the provider's request limiter while retrieving from a source whose client doesn't use the
+// provider's transport
+func (f *fetcher) withRequestSlot(ctx context.Context, fetch func() (*fetchResponse, error)) (*fetchResponse, error) {
+	release, err := f.provider.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return fetch()
+}
+
+// Retrieves the URL, ensuring the server responded successfully. When validators are provided, the request is made
+// conditional and a not modified response may be returned without a body.
+func (f *fetcher) fetchHTTP(ctx context.Context, url string, previous *cacheValidators) (*fetchResponse, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, newFetchError("Error creating request", "%s", err)
+	}
+
+	if previous != nil {
+		if previous.ETag != "" {
+			request.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			request.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, newFetchError("Error making request", "%s", err)
+	}
+	defer response.Body.Close()
+
+	if previous != nil && response.StatusCode == http.StatusNotModified {
+		return &fetchResponse{url: url, etag: previous.ETag, lastModified: previous.LastModified, notModified: true}, nil
+	}
+
+	if response.StatusCode != 200 {
+		return nil, newFetchError("Received non-success response code", "%d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, newFetchError("Error reading response body", "%s", err)
+	}
+
+	return &fetchResponse{
+		url:          url,
+		body:         body,
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+
+		contentEncoding: response.Header.Get("Content-Encoding"),
+		contentType:     response.Header.Get("Content-Type"),
+	}, nil
+}
+
+// Fetches every URL concurrently, joining their bodies in order as files named by their URL. When any URL fails, the
+// returned errors are for each URL, and are nil for those that succeeded.
+func (f *fetcher) fetchAll(ctx context.Context, urls []string) (*fetchResponse, []error) {
+	files := make([]archiveFile, len(urls))
+	errs := make([]error, len(urls))
+
+	forEachConcurrently(len(urls), f.parallelism, func(i int) {
+		response, err := f.fetch(ctx, urls[i], nil)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+
+		files[i] = archiveFile{name: urls[i], content: response.body}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, errs
+		}
+	}
+
+	return &fetchResponse{url: urls[0], body: joinArchiveFiles(files), files: files}, nil
+}
+
+// Runs the task for each index from 0 to n, with at most limit tasks running at once, returning once all have
+// completed
+func forEachConcurrently(n, limit int, task func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		semaphore <- struct{}{}
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			task(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// Tries each URL in order until one succeeds. The returned errors are for each URL that was attempted.
+func (f *fetcher) fetchSequential(ctx context.Context, urls []string) (*fetchResponse, []error) {
+	var errs []error
+
+	for _, url := range urls {
+		response, err := f.fetch(ctx, url, nil)
+		if err == nil {
+			return response, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return nil, errs
+}
+
+// Starts requesting the next URL each time the delay elapses (or the outstanding request fails), returning the
+// first successful response. The returned errors are for each URL that was attempted.
+func (f *fetcher) fetchHedged(ctx context.Context, urls []string, delay time.Duration) (*fetchResponse, []error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		index    int
+		response *fetchResponse
+		err      error
+	}
+
+	results := make(chan result, len(urls))
+	errs := make([]error, len(urls))
+
+	launched, pending := 0, 0
+	launch := func() {
+		index := launched
+		go func() {
+			response, err := f.fetch(ctx, urls[index], nil)
+			results <- result{index, response, err}
+		}()
+
+		launched++
+		pending++
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	launch()
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if launched < len(urls) {
+				launch()
+				timer.Reset(delay)
+			}
+
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.response, nil
+			}
+			errs[r.index] = r.err
+
+			// Don't wait for the delay if nothing else is in flight
+			if pending == 0 && launched < len(urls) {
+				launch()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(delay)
+			}
+		}
+	}
+
+	return nil, errs[:launched]
+}
+
+// Reads a local file referenced by a file:// URL
+func fetchFile(url *neturl.URL) (*fetchResponse, error) {
+	if url.Host != "" && url.Host != "localhost" {
+		return nil, newFetchError("Invalid URL", "file URLs must not have a host, got %q", url.Host)
+	}
+
+	path := url.Path
+	if path == "" {
+		path = url.Opaque
+	}
+
+	// Drive letters are preceded by a slash in file URLs (e.g. file:///C:/manifest.yaml)
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+
+	return readFile(filepath.FromSlash(path))
+}
+
+// Reads a local file as though it was fetched
+func readFile(path string) (*fetchResponse, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newFetchError("Error reading file", "%s", strings.TrimPrefix(err.Error(), "open "))
+	}
+
+	return &fetchResponse{url: path, body: body}, nil
+}
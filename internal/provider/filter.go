@@ -0,0 +1,1066 @@
+package provider
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// A tree of the filtered attribute paths, compiled once per read and shared by every document. Each document is
+// walked along the tree a single time, regardless of how many paths are filtered.
+type attributeFilter struct {
+	// Whether a path ends at this node, covering the attribute entirely
+	whole bool
+	// The attributes of maps, by key
+	children map[string]*attributeFilter
+	// The elements of lists, by index, and every element of lists
+	elements map[int]*attributeFilter
+	every    *attributeFilter
+	// Whether maps emptied by the filter are removed as well, only set on the root
+	prune bool
+
+	// Whether the attribute ending at this node was removed from any document
+	matched atomic.Bool
+	// The compiled paths and the nodes they end at, only set on the root
+	paths []string
+	ends  []*attributeFilter
+}
+
+// A single step of an attribute path, either the key of a map or an element of a list
+type pathSegment struct {
+	key   string
+	index int
+	list  bool
+	every bool
+}
+
+// Compiles the dot-separated attribute paths into a filter, failing if any list index is malformed. Elements of lists
+// are selected by index or with a wildcard, such as `spec.containers[0].resources` or `spec.containers[*].image`. A
+// nil filter is returned when there are no paths.
+func compileAttributeFilter(paths []string, prune bool) (*attributeFilter, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	root := &attributeFilter{prune: prune}
+	parsed := make([][]pathSegment, len(paths))
+	for i, path := range paths {
+		segments, err := parseAttributePath(path)
+		if err != nil {
+			return nil, err
+		}
+		root.insert(segments)
+		parsed[i] = segments
+	}
+
+	// Paths may be covered by ones inserted after them, so their ends are only known once every path is inserted
+	for i, segments := range parsed {
+		root.paths = append(root.paths, paths[i])
+		root.ends = append(root.ends, root.end(segments))
+	}
+
+	return root, nil
+}
+
+// The attributes populated by the API server, removed when `strip_server_fields` is set
+var serverFields = []string{
+	"status",
+	"metadata.creationTimestamp",
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.managedFields",
+}
+
+// The attributes kept by an allow-list regardless of the paths, so each document can still be identified
+var identifyingAttributes = []string{"apiVersion", "kind", "metadata.name"}
+
+// Compiles the allowed attribute paths into a filter using the same syntax as compileAttributeFilter. The identifying
+// attributes are always allowed. A nil filter is returned when there are no paths.
+func compileAllowedAttributes(paths []string) (*attributeFilter, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	root := &attributeFilter{}
+	for _, path := range paths {
+		segments, err := parseAttributePath(path)
+		if err != nil {
+			return nil, err
+		}
+		root.insert(segments)
+	}
+	for _, path := range identifyingAttributes {
+		segments, _ := parseAttributePath(path)
+		root.insert(segments)
+	}
+
+	return root, nil
+}
+
+// Adds the path to the tree
+func (f *attributeFilter) insert(segments []pathSegment) {
+	node := f
+	for _, segment := range segments {
+		// Anything beneath an entire attribute is already covered
+		if node.whole {
+			return
+		}
+		node = node.child(segment)
+	}
+
+	node.whole, node.children, node.elements, node.every = true, nil, nil, nil
+}
+
+// Retrieves the node the inserted path ends at, which is the node of the attribute covering it, if any
+func (f *attributeFilter) end(segments []pathSegment) *attributeFilter {
+	node := f
+	for _, segment := range segments {
+		if node.whole {
+			break
+		}
+		node = node.child(segment)
+	}
+	return node
+}
+
+// The paths which haven't removed an attribute from any document so far
+func (f *attributeFilter) unmatched() []string {
+	if f == nil {
+		return nil
+	}
+
+	var paths []string
+	for i, end := range f.ends {
+		if !end.matched.Load() {
+			paths = append(paths, f.paths[i])
+		}
+	}
+	return paths
+}
+
+// Splits the attribute path into the keys and list elements it traverses. Keys containing dots or brackets can be
+// escaped with backslashes (`a\.b`), quoted (`"a.b"`), or selected with brackets (`["a.b"]`).
+func parseAttributePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	var key strings.Builder
+
+	// Whether a key is being read, and whether the previous quote or bracket must be followed by a separator
+	pending, closed := false, false
+	flush := func() {
+		if pending {
+			segments = append(segments, pathSegment{key: key.String()})
+			key.Reset()
+		}
+		pending, closed = false, false
+	}
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if closed && c != '.' && c != '[' {
+			return nil, fmt.Errorf("%q is malformed, expected . or [ at position %d", path, i)
+		}
+
+		switch {
+		case c == '\\' && i+1 < len(path):
+			key.WriteByte(path[i+1])
+			pending = true
+			i++
+		case c == '"' && !pending:
+			quoted, next, err := readQuoted(path, i)
+			if err != nil {
+				return nil, err
+			}
+			key.WriteString(quoted)
+			pending, closed = true, true
+			i = next - 1
+		case c == '.':
+			flush()
+		case c == '[':
+			flush()
+			if i+1 < len(path) && path[i+1] == '"' {
+				quoted, next, err := readQuoted(path, i+1)
+				if err != nil {
+					return nil, err
+				}
+				if next >= len(path) || path[next] != ']' {
+					return nil, fmt.Errorf("%q has a malformed list index", path)
+				}
+				segments = append(segments, pathSegment{key: quoted})
+				i, closed = next, true
+				continue
+			}
+
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%q has a malformed list index", path)
+			}
+
+			selector := path[i+1 : i+end]
+			if selector == "*" {
+				segments = append(segments, pathSegment{list: true, every: true})
+			} else if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				segments = append(segments, pathSegment{list: true, index: index})
+			} else {
+				return nil, fmt.Errorf("%q has an invalid list index %q, must be a non-negative number or *", path, selector)
+			}
+			i, closed = i+end, true
+		default:
+			key.WriteByte(c)
+			pending = true
+		}
+	}
+	flush()
+
+	return segments, nil
+}
+
+// Reads the double-quoted string starting at the offset, where backslashes escape the following character. The offset
+// just past the closing quote is returned.
+func readQuoted(path string, offset int) (string, int, error) {
+	var value strings.Builder
+	for i := offset + 1; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 < len(path) {
+				value.WriteByte(path[i+1])
+				i++
+			}
+		case '"':
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(path[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("%q has an unterminated quote", path)
+}
+
+// Retrieves the node for the segment, creating it if it doesn't exist
+func (f *attributeFilter) child(segment pathSegment) *attributeFilter {
+	switch {
+	case segment.every:
+		if f.every == nil {
+			f.every = &attributeFilter{}
+		}
+		return f.every
+	case segment.list:
+		if f.elements == nil {
+			f.elements = map[int]*attributeFilter{}
+		}
+		if _, ok := f.elements[segment.index]; !ok {
+			f.elements[segment.index] = &attributeFilter{}
+		}
+		return f.elements[segment.index]
+	default:
+		if f.children == nil {
+			f.children = map[string]*attributeFilter{}
+		}
+		if _, ok := f.children[segment.key]; !ok {
+			f.children[segment.key] = &attributeFilter{}
+		}
+		return f.children[segment.key]
+	}
+}
+
+// Removes the filtered attributes from the manifest, returning whether any were present
+func (f *attributeFilter) apply(manifest map[any]any) bool {
+	if f == nil {
+		return false
+	}
+	return f.applyMap(manifest, f.prune)
+}
+
+// Removes the filtered attributes from the map, returning whether any were present. When pruning, the attributes left
+// empty once their own attributes are removed are removed as well.
+func (f *attributeFilter) applyMap(manifest map[any]any, prune bool) bool {
+	modified := false
+	for key, child := range f.children {
+		value, ok := manifest[key]
+		if !ok {
+			continue
+		}
+
+		if child.whole {
+			delete(manifest, key)
+			child.matched.Store(true)
+			modified = true
+		} else if value, changed := child.applyValue(value, prune); changed {
+			if prune && isEmpty(value) {
+				delete(manifest, key)
+			} else {
+				manifest[key] = value
+			}
+			modified = true
+		}
+	}
+
+	return modified
+}
+
+// Removes the filtered attributes from a map or list, returning the resulting value and whether any were present
+func (f *attributeFilter) applyValue(value any, prune bool) (any, bool) {
+	switch value := value.(type) {
+	case map[any]any:
+		return value, f.applyMap(value, prune)
+	case []any:
+		return f.applyList(value, prune)
+	default:
+		return value, false
+	}
+}
+
+// Removes the filtered elements of the list and the filtered attributes within its elements, returning the resulting
+// list and whether anything was removed. Emptied elements are kept, as removing them would shift the others.
+func (f *attributeFilter) applyList(list []any, prune bool) ([]any, bool) {
+	if f.every != nil && f.every.whole {
+		if len(list) == 0 {
+			return list, false
+		}
+		f.every.matched.Store(true)
+		return []any{}, true
+	}
+
+	modified := false
+	if f.every != nil {
+		for i, element := range list {
+			var changed bool
+			list[i], changed = f.every.applyValue(element, prune)
+			modified = modified || changed
+		}
+	}
+
+	removed := map[int]bool{}
+	for index, child := range f.elements {
+		if index >= len(list) {
+			continue
+		}
+
+		if child.whole {
+			removed[index] = true
+			child.matched.Store(true)
+			modified = true
+		} else {
+			var changed bool
+			list[index], changed = child.applyValue(list[index], prune)
+			modified = modified || changed
+		}
+	}
+
+	if len(removed) == 0 {
+		return list, modified
+	}
+
+	// Indices refer to the original list, so elements are only dropped once every other filter has been applied
+	kept := make([]any, 0, len(list)-len(removed))
+	for i, element := range list {
+		if !removed[i] {
+			kept = append(kept, element)
+		}
+	}
+	return kept, modified
+}
+
+// Whether the value is an empty map or list
+func isEmpty(value any) bool {
+	switch value := value.(type) {
+	case map[any]any:
+		return len(value) == 0
+	case []any:
+		return len(value) == 0
+	default:
+		return false
+	}
+}
+
+// Drops every attribute of the manifest which isn't allowed, returning whether any were present. Maps and lists left
+// empty once their disallowed attributes are dropped are removed as well.
+func (f *attributeFilter) retain(manifest map[any]any) bool {
+	if f == nil {
+		return false
+	}
+	return retainMap(manifest, []*attributeFilter{f})
+}
+
+// Drops the keys of the map which none of the nodes allow, returning whether any were present
+func retainMap(value map[any]any, nodes []*attributeFilter) bool {
+	modified := false
+	for key, element := range value {
+		name, ok := key.(string)
+		children, whole := allowedBy(nodes, func(node *attributeFilter) *attributeFilter {
+			if !ok {
+				return nil
+			}
+			return node.children[name]
+		})
+		if whole {
+			continue
+		}
+
+		retained, keep, changed := retainValue(element, children)
+		if !keep {
+			delete(value, key)
+			modified = true
+		} else if changed {
+			value[key] = retained
+			modified = true
+		}
+	}
+
+	return modified
+}
+
+// Drops the elements of the list which none of the nodes allow, returning the resulting list and whether any were
+// present
+func retainList(list []any, nodes []*attributeFilter) ([]any, bool) {
+	modified := false
+	kept := make([]any, 0, len(list))
+	for i, element := range list {
+		children, whole := allowedBy(nodes, func(node *attributeFilter) *attributeFilter {
+			if child, ok := node.elements[i]; ok {
+				return child
+			}
+			return nil
+		}, func(node *attributeFilter) *attributeFilter {
+			return node.every
+		})
+		if whole {
+			kept = append(kept, element)
+			continue
+		}
+
+		retained, keep, changed := retainValue(element, children)
+		if keep {
+			kept = append(kept, retained)
+		}
+		modified = modified || changed || !keep
+	}
+
+	return kept, modified
+}
+
+// Drops the disallowed attributes within a map or list, returning the resulting value, whether anything is left to
+// keep, and whether anything was dropped. Scalars can't contain the allowed paths, so they are never kept.
+func retainValue(value any, nodes []*attributeFilter) (any, bool, bool) {
+	if len(nodes) == 0 {
+		return value, false, true
+	}
+
+	switch value := value.(type) {
+	case map[any]any:
+		modified := retainMap(value, nodes)
+		return value, len(value) > 0, modified
+	case []any:
+		kept, modified := retainList(value, nodes)
+		return kept, len(kept) > 0, modified
+	default:
+		return value, false, true
+	}
+}
+
+// Collects the nodes reached from each of the nodes by the steps, and whether any of them allows the attribute
+// entirely. Both an index and a wildcard can reach the same list element, so several nodes may apply at once.
+func allowedBy(nodes []*attributeFilter, steps ...func(*attributeFilter) *attributeFilter) ([]*attributeFilter, bool) {
+	var reached []*attributeFilter
+	for _, node := range nodes {
+		for _, step := range steps {
+			if next := step(node); next != nil {
+				if next.whole {
+					return nil, true
+				}
+				reached = append(reached, next)
+			}
+		}
+	}
+	return reached, false
+}
+
+// Filtered attribute paths which only apply to documents of certain resource types. The paths under the `*` key apply
+// to the documents matching none of the other resource patterns.
+type kindAttributeFilter struct {
+	patterns []string
+	filters  []*attributeFilter
+	fallback *attributeFilter
+}
+
+// Compiles the attribute paths keyed by `{apiVersion}/{kind}` patterns into a filter, returning the key of the entry
+// which is malformed, if any. A nil filter is returned when there are no entries.
+func compileKindAttributeFilter(paths map[string][]string, prune bool) (*kindAttributeFilter, string, error) {
+	if len(paths) == 0 {
+		return nil, "", nil
+	}
+
+	// Documents matched by several patterns have each of their filters applied, so they are applied in a stable order
+	patterns := make([]string, 0, len(paths))
+	for pattern := range paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	filter := &kindAttributeFilter{}
+	for _, pattern := range patterns {
+		compiled, err := compileAttributeFilter(paths[pattern], prune)
+		if err != nil {
+			return nil, pattern, err
+		}
+
+		if pattern == "*" {
+			filter.fallback = compiled
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+			return nil, pattern, fmt.Errorf("%q is not a valid resource pattern, must be in the format {apiVersion}/{kind}", pattern)
+		}
+
+		filter.patterns = append(filter.patterns, pattern)
+		filter.filters = append(filter.filters, compiled)
+	}
+
+	return filter, "", nil
+}
+
+// Removes the attributes filtered for the manifest's resource type, returning whether any were present
+func (f *kindAttributeFilter) apply(manifest map[any]any) bool {
+	if f == nil {
+		return false
+	}
+
+	apiVersion, kind := fmt.Sprint(manifest["apiVersion"]), fmt.Sprint(manifest["kind"])
+	matched, modified := false, false
+	for i, pattern := range f.patterns {
+		if matchResource(pattern, apiVersion, kind) {
+			matched = true
+			modified = f.filters[i].apply(manifest) || modified
+		}
+	}
+
+	if !matched {
+		return f.fallback.apply(manifest)
+	}
+	return modified
+}
+
+// The paths which haven't removed an attribute from any document so far, prefixed by the resource pattern they're
+// filtered for
+func (f *kindAttributeFilter) unmatched() []string {
+	if f == nil {
+		return nil
+	}
+
+	var paths []string
+	for i, pattern := range f.patterns {
+		for _, path := range f.filters[i].unmatched() {
+			paths = append(paths, pattern+": "+path)
+		}
+	}
+	for _, path := range f.fallback.unmatched() {
+		paths = append(paths, "*: "+path)
+	}
+	return paths
+}
+
+// Selects documents by their `{apiVersion}/{kind}`, where either part may contain shell patterns. A lone `*` for the
+// API version matches both core and grouped versions. Patterns prefixed with `!` exclude the documents they match,
+// taking precedence over the others.
+type resourceFilter struct {
+	include []string
+	exclude []string
+}
+
+// Compiles the resource patterns into a filter, failing if any pattern is malformed. A nil filter, which allows every
+// document, is returned when there are no patterns.
+func compileResourceFilter(patterns []string) (*resourceFilter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	filter := &resourceFilter{}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+
+		if negated := strings.TrimPrefix(pattern, "!"); negated != pattern {
+			filter.exclude = append(filter.exclude, negated)
+		} else {
+			filter.include = append(filter.include, pattern)
+		}
+	}
+
+	return filter, nil
+}
+
+// Whether the manifest matches none of the excluded patterns and, unless there are only excluded patterns, any of the
+// included patterns
+func (f *resourceFilter) allows(manifest map[any]any) bool {
+	if f == nil {
+		return true
+	}
+
+	apiVersion, kind := fmt.Sprint(manifest["apiVersion"]), fmt.Sprint(manifest["kind"])
+	for _, pattern := range f.exclude {
+		if matchResource(pattern, apiVersion, kind) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.include {
+		if matchResource(pattern, apiVersion, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchResource(pattern, apiVersion, kind string) bool {
+	separator := strings.LastIndex(pattern, "/")
+	if separator < 0 {
+		return false
+	}
+
+	versionPattern, kindPattern := pattern[:separator], pattern[separator+1:]
+	if matched, _ := path.Match(kindPattern, kind); !matched {
+		return false
+	}
+	if versionPattern == "*" {
+		return true
+	}
+
+	matched, _ := path.Match(versionPattern, apiVersion)
+	return matched
+}
+
+// Selects documents by their `metadata.name`, using exact names, shell patterns, or regular expressions enclosed in
+// slashes
+type nameSelector struct {
+	patterns    []string
+	expressions []*regexp.Regexp
+}
+
+// Compiles the name patterns into a selector, failing if any pattern is malformed. A nil selector, which allows every
+// document, is returned when there are no patterns.
+func compileNameSelector(patterns []string) (*nameSelector, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	selector := &nameSelector{}
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expression, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a valid regular expression: %w", pattern, err)
+			}
+			selector.expressions = append(selector.expressions, expression)
+		} else if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid pattern", pattern)
+		} else {
+			selector.patterns = append(selector.patterns, pattern)
+		}
+	}
+
+	return selector, nil
+}
+
+// Whether the name of the manifest matches any of the patterns or expressions. Manifests without a name never match.
+func (s *nameSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	name, ok := metadata["name"].(string)
+	if !ok {
+		return false
+	}
+
+	for _, pattern := range s.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	for _, expression := range s.expressions {
+		if expression.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selects documents by their `metadata.namespace` using shell patterns, where patterns prefixed with `!` exclude the
+// namespaces they match. Documents without a namespace, such as cluster-scoped resources, are selected by a flag
+// instead.
+type namespaceSelector struct {
+	include       []string
+	exclude       []string
+	clusterScoped bool
+}
+
+// Compiles the namespace patterns into a selector, failing if any pattern is malformed. A nil selector, which allows
+// every document, is returned when there are no patterns.
+func compileNamespaceSelector(patterns []string, clusterScoped bool) (*namespaceSelector, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	selector := &namespaceSelector{clusterScoped: clusterScoped}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+
+		if negated := strings.TrimPrefix(pattern, "!"); negated != pattern {
+			selector.exclude = append(selector.exclude, negated)
+		} else {
+			selector.include = append(selector.include, pattern)
+		}
+	}
+
+	return selector, nil
+}
+
+func (s *namespaceSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	namespace, _ := metadata["namespace"].(string)
+	if namespace == "" {
+		return s.clusterScoped
+	}
+
+	for _, pattern := range s.exclude {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.include {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Selects documents by their `metadata.labels` using the Kubernetes label selector syntax
+type labelSelector struct {
+	selector labels.Selector
+}
+
+// Parses the label selector, failing if it is malformed. A nil selector, which allows every document, is returned when
+// the expression is empty.
+func compileLabelSelector(expression string) (*labelSelector, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &labelSelector{selector: selector}, nil
+}
+
+func (s *labelSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+	return s.selector.Matches(labels.Set(metadataStrings(manifest, "labels")))
+}
+
+// Selects documents by their `metadata.annotations`. Each requirement is either the presence of a key (`key`), its
+// absence (`!key`), or an exact value (`key=value` or `key!=value`), and every requirement must be met. Unlike label
+// selectors, the values may contain any characters.
+type annotationSelector struct {
+	requirements []annotationRequirement
+}
+
+type annotationRequirement struct {
+	key      string
+	value    string
+	hasValue bool
+	negated  bool
+}
+
+// Parses the annotation requirements, failing if any has an empty key. A nil selector, which allows every document, is
+// returned when there are no requirements.
+func compileAnnotationSelector(requirements []string) (*annotationSelector, error) {
+	if len(requirements) == 0 {
+		return nil, nil
+	}
+
+	selector := &annotationSelector{}
+	for _, raw := range requirements {
+		var requirement annotationRequirement
+		if key, value, ok := strings.Cut(raw, "!="); ok {
+			requirement = annotationRequirement{key: key, value: value, hasValue: true, negated: true}
+		} else if key, value, ok := strings.Cut(raw, "="); ok {
+			requirement = annotationRequirement{key: key, value: value, hasValue: true}
+		} else if key := strings.TrimPrefix(raw, "!"); key != raw {
+			requirement = annotationRequirement{key: key, negated: true}
+		} else {
+			requirement = annotationRequirement{key: raw}
+		}
+
+		requirement.key = strings.TrimSpace(requirement.key)
+		if requirement.key == "" {
+			return nil, fmt.Errorf("%q has no annotation key", raw)
+		}
+		selector.requirements = append(selector.requirements, requirement)
+	}
+
+	return selector, nil
+}
+
+func (s *annotationSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+
+	annotations := metadataStrings(manifest, "annotations")
+	for _, requirement := range s.requirements {
+		value, present := annotations[requirement.key]
+		matched := present
+		if requirement.hasValue {
+			matched = present && value == requirement.value
+		}
+
+		if matched == requirement.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// Reads a map of strings, such as the labels, from the metadata of the manifest. Values which are not strings are
+// ignored.
+func metadataStrings(manifest map[any]any, field string) map[string]string {
+	metadata, _ := manifest["metadata"].(map[any]any)
+	raw, _ := metadata[field].(map[any]any)
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if key, ok := key.(string); ok {
+			if value, ok := value.(string); ok {
+				values[key] = value
+			}
+		}
+	}
+	return values
+}
+
+// Converts the decoded YAML into JSON-like values, where every map is keyed by strings, as expected by CEL and JSONPath
+func stringKeyed(value any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for key, element := range value {
+			converted[fmt.Sprint(key)] = stringKeyed(element)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(value))
+		for i, element := range value {
+			converted[i] = stringKeyed(element)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+// Selects the documents allowed by the resource filter, every selector, and the JSONPath and CEL filters
+type documentSelector struct {
+	resources   *resourceFilter
+	crds        *crdSelector
+	names       *nameSelector
+	namespaces  *namespaceSelector
+	labels      *labelSelector
+	annotations *annotationSelector
+	fields      *fieldSelector
+	jsonPath    *jsonPathQuery
+	expression  *celFilter
+}
+
+// Whether the manifest is selected. The JSONPath and CEL filters are only evaluated once every other selector allows the
+// manifest, as they are the most expensive and the only ones that can fail.
+func (s *documentSelector) selects(manifest map[any]any) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+
+	if !s.resources.allows(manifest) || !s.crds.allows(manifest) || !s.names.allows(manifest) || !s.namespaces.allows(manifest) ||
+		!s.labels.allows(manifest) || !s.annotations.allows(manifest) || !s.fields.allows(manifest) {
+		return false, nil
+	}
+	if allowed, err := s.jsonPath.allows(manifest); err != nil || !allowed {
+		return false, err
+	}
+	return s.expression.allows(manifest)
+}
+
+// Transforms the selected documents, in order, by dropping null values, converting deprecated and overridden API
+// versions, keeping only the allowed attributes, removing the filtered attributes, those filtered by kind, finalizers,
+// Helm metadata, and CA bundles, applying JSON patches and overlays, removing containers, applying replacements,
+// setting replicas and service types, injecting environment variables, moving the documents into the namespace,
+// renaming them, injecting image pull secrets, setting the priority class, converting secret data, rewriting images
+// and pinning them to digests, and finally setting attributes. Secrets are redacted afterwards by redactSecrets.
+type documentFilter struct {
+	dropNulls  bool
+	convert    bool
+	versions   *apiVersionOverrides
+	allowed    *attributeFilter
+	attributes *attributeFilter
+	kinds      *kindAttributeFilter
+	finalizers *finalizerStripper
+	stripHelm  bool
+	stripCAs   bool
+	patches    *jsonPatcher
+	overlays   *overlayMerger
+	containers *containerRemover
+	replacer   *replacer
+	replicas   *replicaOverrides
+	services   *serviceTypeOverrides
+	env        *envInjector
+	namespace  *namespaceTransformer
+	names      *nameTransformer
+	secrets    *imagePullSecretInjector
+	priority   *priorityClassSetter
+	secretData *secretDataConverter
+	images     *imageRewriter
+	pins       *imageDigestPinner
+	set        *attributeSetter
+	redact     bool
+	canonical  bool
+	comments   bool
+}
+
+// Transforms the manifest in place, returning whether anything changed
+func (f *documentFilter) apply(manifest map[any]any) (bool, error) {
+	if f == nil {
+		return false, nil
+	}
+
+	modified := f.dropNulls && dropNulls(manifest)
+	modified = f.convert && convertDeprecatedAPIVersion(manifest) || modified
+	modified = f.versions.apply(manifest) || modified
+	modified = f.allowed.retain(manifest) || modified
+	modified = f.attributes.apply(manifest) || modified
+	modified = f.kinds.apply(manifest) || modified
+	modified = f.finalizers.apply(manifest) || modified
+	modified = f.stripHelm && stripHelmMetadata(manifest) || modified
+	modified = f.stripCAs && stripCABundles(manifest) || modified
+	patched, err := f.patches.apply(manifest)
+	if err != nil {
+		return false, err
+	}
+	modified = patched || modified
+	modified = f.overlays.apply(manifest) || modified
+	modified = f.containers.apply(manifest) || modified
+	modified = f.replacer.apply(manifest) || modified
+	modified = f.replicas.apply(manifest) || modified
+	modified = f.services.apply(manifest) || modified
+	modified = f.env.apply(manifest) || modified
+	modified = f.namespace.apply(manifest) || modified
+	modified = f.names.apply(manifest) || modified
+	modified = f.secrets.apply(manifest) || modified
+	modified = f.priority.apply(manifest) || modified
+	modified = f.secretData.apply(manifest) || modified
+	modified = f.images.apply(manifest) || modified
+	modified = f.pins.apply(manifest) || modified
+	return f.set.apply(manifest) || modified, nil
+}
+
+// Redacts the values of the document when it is a secret and redaction is enabled, returning the keys which were
+// redacted. Secrets are redacted once every other transformation is applied, so none can reveal the values.
+func (f *documentFilter) redactSecrets(manifest map[any]any) []string {
+	if f == nil || !f.redact {
+		return nil
+	}
+	return redactSecret(manifest)
+}
+
+// Whether every manifest is re-encoded canonically, rather than only those which were modified
+func (f *documentFilter) canonicalizes() bool {
+	return f != nil && f.canonical
+}
+
+// Whether the comments of modified YAML documents are kept when they are re-encoded
+func (f *documentFilter) preservesComments() bool {
+	return f != nil && f.comments
+}
+
+// Whether any transformation depends on every document, which must then be collected before any is transformed
+func (f *documentFilter) collects() bool {
+	return f != nil && (f.names.renamesReferences() || f.pins != nil || f.replacer != nil)
+}
+
+// Collects what the transformations need to know about the documents within the content. Malformed content is
+// skipped, as it is reported once the content is decoded.
+func (f *documentFilter) collectAll(content []byte, format string, selector *documentSelector) {
+	content, err := normalizeText(content)
+	if err != nil {
+		return
+	}
+
+	_ = unmarshalAllManifests(content, format, selector, func(manifest map[any]any, _ []byte) error {
+		// The API versions, patches, overlays, and container removals are applied first, as they may change what's
+		// collected
+		if f.convert {
+			convertDeprecatedAPIVersion(manifest)
+		}
+		f.versions.apply(manifest)
+		if _, err := f.patches.apply(manifest); err != nil {
+			return nil
+		}
+		f.overlays.apply(manifest)
+		f.containers.apply(manifest)
+
+		if f.names.renamesReferences() {
+			f.names.collect(manifest)
+		}
+		if f.pins != nil {
+			f.pins.collect(manifest)
+		}
+		if f.replacer != nil {
+			f.replacer.collect(manifest)
+		}
+		return nil
+	})
+}
+
+// Removes the keys with null values from the map and every map nested within it, returning whether any were present.
+// Null elements of lists are kept, as removing them would shift the others.
+func dropNulls(value any) bool {
+	modified := false
+	switch value := value.(type) {
+	case map[any]any:
+		for key, element := range value {
+			if element == nil {
+				delete(value, key)
+				modified = true
+			} else {
+				modified = dropNulls(element) || modified
+			}
+		}
+	case []any:
+		for _, element := range value {
+			modified = dropNulls(element) || modified
+		}
+	}
+	return modified
+}
@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var filterBlock = tfsdk.Block{
+	MarkdownDescription: "A structured alternative to the top-level filtering attributes, grouping the selectors, the attribute removals, and the values to set. Each attribute is equivalent to the top-level attribute mentioned in its description, and only one of the two can be set. Unlike filtering errors in general, mistakes within the block are reported while planning, as long as its values are known.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"set": {
+			MarkdownDescription: "Values to set in the returned manifests, keyed by the path of the attribute, equivalent to `set_attributes`.",
+			Type:                types.MapType{ElemType: types.StringType},
+			Optional:            true,
+		},
+	},
+	Blocks: map[string]tfsdk.Block{
+		"select": {
+			MarkdownDescription: "Which manifests are returned. When several selectors are set, every one must match.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			MaxItems:            1,
+			Attributes: map[string]tfsdk.Attribute{
+				"resources": {
+					MarkdownDescription: "The `{apiVersion}/{kind}` patterns of the resources to return, equivalent to `only_resources`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+				"names": {
+					MarkdownDescription: "The names, shell patterns, or regular expressions the `metadata.name` must match, equivalent to `name_selector`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+				"namespaces": {
+					MarkdownDescription: "The namespaces, or shell patterns, the `metadata.namespace` must match, equivalent to `namespaces`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+				"include_cluster_scoped": {
+					MarkdownDescription: "Whether manifests without a namespace are returned when `namespaces` is set, equivalent to `include_cluster_scoped`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+				"labels": {
+					MarkdownDescription: "The Kubernetes label selector the `metadata.labels` must match, equivalent to `label_selector`.",
+					Type:                types.StringType,
+					Optional:            true,
+				},
+				"annotations": {
+					MarkdownDescription: "The requirements the `metadata.annotations` must match, equivalent to `annotation_selector`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+				"jsonpath": {
+					MarkdownDescription: "The JSONPath expression which must match a value, equivalent to `jsonpath_filter`.",
+					Type:                types.StringType,
+					Optional:            true,
+				},
+				"cel": {
+					MarkdownDescription: "The CEL expression which must evaluate to `true`, equivalent to `cel_filter`.",
+					Type:                types.StringType,
+					Optional:            true,
+				},
+			},
+		},
+		"remove": {
+			MarkdownDescription: "Which attributes are removed from the returned manifests.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			MaxItems:            1,
+			Attributes: map[string]tfsdk.Attribute{
+				"attributes": {
+					MarkdownDescription: "The paths of the attributes to remove, equivalent to `filtered_attributes`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+				"by_kind": {
+					MarkdownDescription: "The paths of the attributes to remove, keyed by resource type, equivalent to `filtered_attributes_by_kind`.",
+					Type:                types.MapType{ElemType: types.ListType{ElemType: types.StringType}},
+					Optional:            true,
+				},
+				"server_fields": {
+					MarkdownDescription: "Whether the attributes populated by the API server are removed, equivalent to `strip_server_fields`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+				"nulls": {
+					MarkdownDescription: "Whether attributes with null values are removed, equivalent to `drop_nulls`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+				"empty": {
+					MarkdownDescription: "Whether maps and lists left empty by the removals are removed as well, equivalent to `prune_empty`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+				"strict": {
+					MarkdownDescription: "Whether paths which remove nothing fail rather than warn, equivalent to `strict_filters`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+			},
+		},
+		"keep": {
+			MarkdownDescription: "Which attributes are kept in the returned manifests, removing every other.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			MaxItems:            1,
+			Attributes: map[string]tfsdk.Attribute{
+				"attributes": {
+					MarkdownDescription: "The paths of the only attributes to keep, equivalent to `allowed_attributes`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Optional:            true,
+				},
+			},
+		},
+	},
+}
+
+type filterBlockModel struct {
+	Set    types.Map           `tfsdk:"set"`
+	Select []filterSelectModel `tfsdk:"select"`
+	Remove []filterRemoveModel `tfsdk:"remove"`
+	Keep   []filterKeepModel   `tfsdk:"keep"`
+}
+
+type filterSelectModel struct {
+	Resources            types.List   `tfsdk:"resources"`
+	Names                types.List   `tfsdk:"names"`
+	Namespaces           types.List   `tfsdk:"namespaces"`
+	IncludeClusterScoped types.Bool   `tfsdk:"include_cluster_scoped"`
+	Labels               types.String `tfsdk:"labels"`
+	Annotations          types.List   `tfsdk:"annotations"`
+	JSONPath             types.String `tfsdk:"jsonpath"`
+	CEL                  types.String `tfsdk:"cel"`
+}
+
+type filterRemoveModel struct {
+	Attributes   types.List `tfsdk:"attributes"`
+	ByKind       types.Map  `tfsdk:"by_kind"`
+	ServerFields types.Bool `tfsdk:"server_fields"`
+	Nulls        types.Bool `tfsdk:"nulls"`
+	Empty        types.Bool `tfsdk:"empty"`
+	Strict       types.Bool `tfsdk:"strict"`
+}
+
+type filterKeepModel struct {
+	Attributes types.List `tfsdk:"attributes"`
+}
+
+// Where the value of each top-level filtering attribute was configured, which is the filter block for those merged
+// from it
+type attributePaths map[string]path.Path
+
+// The path of the top-level attribute's value
+func (p attributePaths) of(attribute string) path.Path {
+	if configured, ok := p[attribute]; ok {
+		return configured
+	}
+	return path.Root(attribute)
+}
+
+// Merges the values of the filter block into their top-level equivalents, failing if both are set. The returned paths
+// point errors about the merged values back to the block.
+func mergeFilterBlock(model *modelV0) (attributePaths, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	paths := attributePaths{}
+	if len(model.Filter) == 0 {
+		return paths, diags
+	}
+
+	block := path.Root("filter").AtListIndex(0)
+	mergeFilterValue(&diags, paths, "set_attributes", block.AtName("set"), model.Filter[0].Set, &model.SetAttributes)
+	if filter := model.Filter[0]; len(filter.Select) > 0 {
+		selects, at := filter.Select[0], block.AtName("select").AtListIndex(0)
+		mergeFilterValue(&diags, paths, "only_resources", at.AtName("resources"), selects.Resources, &model.OnlyResources)
+		mergeFilterValue(&diags, paths, "name_selector", at.AtName("names"), selects.Names, &model.NameSelector)
+		mergeFilterValue(&diags, paths, "namespaces", at.AtName("namespaces"), selects.Namespaces, &model.Namespaces)
+		mergeFilterValue(&diags, paths, "include_cluster_scoped", at.AtName("include_cluster_scoped"), selects.IncludeClusterScoped, &model.IncludeClusterScoped)
+		mergeFilterValue(&diags, paths, "label_selector", at.AtName("labels"), selects.Labels, &model.LabelSelector)
+		mergeFilterValue(&diags, paths, "annotation_selector", at.AtName("annotations"), selects.Annotations, &model.AnnotationSelector)
+		mergeFilterValue(&diags, paths, "jsonpath_filter", at.AtName("jsonpath"), selects.JSONPath, &model.JSONPathFilter)
+		mergeFilterValue(&diags, paths, "cel_filter", at.AtName("cel"), selects.CEL, &model.CELFilter)
+	}
+	if filter := model.Filter[0]; len(filter.Remove) > 0 {
+		remove, at := filter.Remove[0], block.AtName("remove").AtListIndex(0)
+		mergeFilterValue(&diags, paths, "filtered_attributes", at.AtName("attributes"), remove.Attributes, &model.FilteredAttributes)
+		mergeFilterValue(&diags, paths, "filtered_attributes_by_kind", at.AtName("by_kind"), remove.ByKind, &model.FilteredByKind)
+		mergeFilterValue(&diags, paths, "strip_server_fields", at.AtName("server_fields"), remove.ServerFields, &model.StripServerFields)
+		mergeFilterValue(&diags, paths, "drop_nulls", at.AtName("nulls"), remove.Nulls, &model.DropNulls)
+		mergeFilterValue(&diags, paths, "prune_empty", at.AtName("empty"), remove.Empty, &model.PruneEmpty)
+		mergeFilterValue(&diags, paths, "strict_filters", at.AtName("strict"), remove.Strict, &model.StrictFilters)
+	}
+	if filter := model.Filter[0]; len(filter.Keep) > 0 {
+		keep, at := filter.Keep[0], block.AtName("keep").AtListIndex(0)
+		mergeFilterValue(&diags, paths, "allowed_attributes", at.AtName("attributes"), keep.Attributes, &model.AllowedAttributes)
+	}
+
+	return paths, diags
+}
+
+// Replaces the top-level attribute with the value from the filter block, if it is set
+func mergeFilterValue[T attr.Value](diags *diag.Diagnostics, paths attributePaths, attribute string, at path.Path, value T, target *T) {
+	if value.IsNull() {
+		return
+	}
+	if !(*target).IsNull() {
+		diags.AddAttributeError(at, "Conflicting filter", fmt.Sprintf("Conflicting filter: %s can't be set in both the filter block and at the top level", attribute))
+		return
+	}
+
+	*target = value
+	paths[attribute] = at
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_FilterBlock(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(filterBlockStatement, server.URL, `
+		select {
+			resources = ["apps/v1/Deployment"]
+			labels    = "component=gateway"
+		}
+		remove {
+			attributes = ["metadata.labels"]
+		}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("gateway"))),
+			},
+			{
+				Config: fmt.Sprintf(filterBlockStatement, server.URL, `
+		keep {
+			attributes = ["metadata.labels.component"]
+		}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", "apiVersion: testing.k8s.io/v1\nkind: Test\n")),
+			},
+		},
+	})
+}
+
+func TestDataSource_FilterBlock_Invalid(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(filterBlockStatement, "https://example.com", `
+		select {
+			cel = "object.kind =="
+		}`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid CEL filter`),
+			},
+			{
+				Config: fmt.Sprintf(filterBlockStatement, "https://example.com", `
+		remove {
+			attributes = ["spec.items[first]"]
+		}`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid attribute path`),
+			},
+			{
+				Config:      filterConflictStatement,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`label_selector can't be set in both`),
+			},
+		},
+	})
+}
+
+func TestMergeFilterBlock(t *testing.T) {
+	nullList, nullString, nullBool := types.List{ElemType: types.StringType, Null: true}, types.String{Null: true}, types.Bool{Null: true}
+	resources := types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "v1/ConfigMap"}}}
+	model := modelV0{
+		OnlyResources: nullList,
+		DropNulls:     types.Bool{Value: true},
+		Filter: []filterBlockModel{{
+			Set: types.Map{ElemType: types.StringType, Null: true},
+			Select: []filterSelectModel{{
+				Resources:            resources,
+				Names:                nullList,
+				Namespaces:           nullList,
+				IncludeClusterScoped: nullBool,
+				Labels:               nullString,
+				Annotations:          nullList,
+				JSONPath:             nullString,
+				CEL:                  nullString,
+			}},
+			Remove: []filterRemoveModel{{
+				Attributes:   nullList,
+				ByKind:       types.Map{ElemType: types.ListType{ElemType: types.StringType}, Null: true},
+				ServerFields: nullBool,
+				Nulls:        nullBool,
+				Empty:        nullBool,
+				Strict:       nullBool,
+			}},
+		}},
+	}
+
+	paths, diags := mergeFilterBlock(&model)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if !model.OnlyResources.Equal(resources) || !model.DropNulls.Value {
+		t.Errorf("expected the block to be merged into the model, got %v", model)
+	}
+
+	expected := path.Root("filter").AtListIndex(0).AtName("select").AtListIndex(0).AtName("resources")
+	if !paths.of("only_resources").Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, paths.of("only_resources"))
+	}
+	if !paths.of("drop_nulls").Equal(path.Root("drop_nulls")) {
+		t.Errorf("expected the top-level path, got %s", paths.of("drop_nulls"))
+	}
+
+	model.Filter[0].Remove[0].Nulls = types.Bool{Value: false}
+	if _, diags := mergeFilterBlock(&model); !diags.HasError() {
+		t.Error("expected setting both drop_nulls and the block's nulls to fail")
+	}
+}
+
+const filterBlockStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/labeled"
+	filter {
+%s
+	}
+}
+`
+
+const filterConflictStatement = `
+data "manifest_fetch" "test" {
+	url            = "https://example.com/labeled"
+	label_selector = "component=pilot"
+	filter {
+		select {
+			labels = "component=gateway"
+		}
+	}
+}
+`
@@ -0,0 +1,529 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAttributeFilter(t *testing.T) {
+	newManifest := func() map[any]any {
+		return map[any]any{
+			"kind": "Test",
+			"metadata": map[any]any{
+				"name":        "test",
+				"uid":         "abc",
+				"annotations": map[any]any{"hello": "world"},
+			},
+			"status": map[any]any{"ready": true},
+		}
+	}
+
+	tests := map[string]struct {
+		paths    []string
+		modified bool
+		expected map[any]any
+	}{
+		"none":    {nil, false, newManifest()},
+		"missing": {[]string{"spec.replicas", "metadata.labels.app", "kind.name"}, false, newManifest()},
+		"nested": {[]string{"metadata.uid", "metadata.annotations.hello"}, true, map[any]any{
+			"kind":     "Test",
+			"metadata": map[any]any{"name": "test", "annotations": map[any]any{}},
+			"status":   map[any]any{"ready": true},
+		}},
+		"covered": {[]string{"metadata.annotations.hello", "metadata", "metadata.uid"}, true, map[any]any{
+			"kind":   "Test",
+			"status": map[any]any{"ready": true},
+		}},
+	}
+
+	for name, test := range tests {
+		manifest := newManifest()
+		filter, err := compileAttributeFilter(test.paths, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		modified := filter.apply(manifest)
+
+		if modified != test.modified {
+			t.Errorf("%s: expected modified to be %t, got %t", name, test.modified, modified)
+		}
+		if !reflect.DeepEqual(manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, manifest)
+		}
+	}
+}
+
+func TestAttributeFilter_Lists(t *testing.T) {
+	newManifest := func() map[any]any {
+		return map[any]any{
+			"spec": map[any]any{
+				"containers": []any{
+					map[any]any{"name": "app", "imagePullPolicy": "Always", "resources": map[any]any{"limits": "1"}},
+					map[any]any{"name": "proxy", "imagePullPolicy": "Always"},
+				},
+				"ports": []any{80, 443, 8080},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		paths    []string
+		modified bool
+		expected map[any]any
+	}{
+		"index": {[]string{"spec.containers[0].resources"}, true, map[any]any{"spec": map[any]any{
+			"containers": []any{
+				map[any]any{"name": "app", "imagePullPolicy": "Always"},
+				map[any]any{"name": "proxy", "imagePullPolicy": "Always"},
+			},
+			"ports": []any{80, 443, 8080},
+		}}},
+		"wildcard": {[]string{"spec.containers[*].imagePullPolicy"}, true, map[any]any{"spec": map[any]any{
+			"containers": []any{
+				map[any]any{"name": "app", "resources": map[any]any{"limits": "1"}},
+				map[any]any{"name": "proxy"},
+			},
+			"ports": []any{80, 443, 8080},
+		}}},
+		"elements": {[]string{"spec.ports[0]", "spec.ports[2]", "spec.containers[*]"}, true, map[any]any{"spec": map[any]any{
+			"containers": []any{},
+			"ports":      []any{443},
+		}}},
+		"out of range": {[]string{"spec.containers[5].name", "spec.ports[3]", "spec.containers[1].resources"}, false, newManifest()},
+		"not a list":   {[]string{"spec[0]", "spec.containers.name"}, false, newManifest()},
+	}
+
+	for name, test := range tests {
+		manifest := newManifest()
+		filter, err := compileAttributeFilter(test.paths, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		modified := filter.apply(manifest)
+
+		if modified != test.modified {
+			t.Errorf("%s: expected modified to be %t, got %t", name, test.modified, modified)
+		}
+		if !reflect.DeepEqual(manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, manifest)
+		}
+	}
+
+	for _, path := range []string{"spec.containers[-1]", "spec.containers[name]", "spec.containers[0]x"} {
+		if _, err := compileAttributeFilter([]string{path}, false); err == nil {
+			t.Errorf("%s: expected a malformed index to fail", path)
+		}
+	}
+}
+
+func TestAttributeFilter_Unmatched(t *testing.T) {
+	filter, _ := compileAttributeFilter([]string{"metadta.uid", "metadata.uid", "spec.ports[*]", "spec.ports[3]", "metadata.labels.app", "metadata.labels"}, false)
+	if unmatched := filter.unmatched(); len(unmatched) != 6 {
+		t.Errorf("expected every path to be unmatched, got %q", unmatched)
+	}
+
+	filter.apply(map[any]any{"metadata": map[any]any{"uid": "abc", "labels": map[any]any{}}, "spec": map[any]any{"ports": []any{80}}})
+	filter.apply(map[any]any{"metadata": map[any]any{}})
+
+	expected := []string{"metadta.uid", "spec.ports[3]"}
+	if unmatched := filter.unmatched(); !reflect.DeepEqual(unmatched, expected) {
+		t.Errorf("expected %q, got %q", expected, unmatched)
+	}
+
+	kinds, _, _ := compileKindAttributeFilter(map[string][]string{"apps/v1/Deployment": {"status"}, "*": {"status"}}, false)
+	kinds.apply(map[any]any{"apiVersion": "v1", "kind": "Pod", "status": map[any]any{}})
+	if unmatched := kinds.unmatched(); !reflect.DeepEqual(unmatched, []string{"apps/v1/Deployment: status"}) {
+		t.Errorf("expected only the deployment path to be unmatched, got %q", unmatched)
+	}
+}
+
+func TestAttributeFilter_Prune(t *testing.T) {
+	manifest := map[any]any{
+		"kind":     "Pod",
+		"metadata": map[any]any{"annotations": map[any]any{"hello": "world"}, "uid": "abc"},
+		"spec": map[any]any{
+			"containers": []any{map[any]any{"name": "app"}, map[any]any{"name": "proxy"}},
+			"volumes":    []any{map[any]any{"name": "cache", "emptyDir": map[any]any{}}},
+			"ports":      []any{80},
+		},
+	}
+
+	filter, err := compileAttributeFilter([]string{"metadata.annotations.hello", "metadata.uid", "spec.containers[0].name", "spec.ports[0]", "spec.volumes[0].name"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !filter.apply(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+
+	expected := map[any]any{
+		"kind": "Pod",
+		"spec": map[any]any{
+			"containers": []any{map[any]any{}, map[any]any{"name": "proxy"}},
+			"volumes":    []any{map[any]any{"emptyDir": map[any]any{}}},
+		},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+}
+
+func TestDropNulls(t *testing.T) {
+	manifest := map[any]any{
+		"kind":     "Pod",
+		"metadata": map[any]any{"name": "app", "creationTimestamp": nil},
+		"spec":     map[any]any{"containers": []any{map[any]any{"name": "app", "resources": nil}, nil}},
+		"status":   nil,
+	}
+
+	if !dropNulls(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+	expected := map[any]any{
+		"kind":     "Pod",
+		"metadata": map[any]any{"name": "app"},
+		"spec":     map[any]any{"containers": []any{map[any]any{"name": "app"}, nil}},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+	if dropNulls(manifest) {
+		t.Error("expected the manifest to be unmodified")
+	}
+}
+
+func TestAllowedAttributes(t *testing.T) {
+	newManifest := func() map[any]any {
+		return map[any]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[any]any{"name": "app", "uid": "abc", "labels": map[any]any{"app": "test"}},
+			"spec": map[any]any{
+				"replicas": 3,
+				"template": map[any]any{"spec": map[any]any{"containers": []any{
+					map[any]any{"name": "app", "image": "example/app:v1"},
+					map[any]any{"name": "proxy", "image": "example/proxy:v2", "ports": []any{8080}},
+				}}},
+			},
+			"status": map[any]any{"ready": true},
+		}
+	}
+	identity := func(extra map[any]any) map[any]any {
+		manifest := map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"name": "app"}}
+		for key, value := range extra {
+			manifest[key] = value
+		}
+		return manifest
+	}
+
+	tests := map[string]struct {
+		paths    []string
+		modified bool
+		expected map[any]any
+	}{
+		"none":    {nil, false, newManifest()},
+		"missing": {[]string{"spec.selector", "status.ready.value"}, true, identity(nil)},
+		"nested": {[]string{"spec.replicas", "metadata.labels"}, true, map[any]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[any]any{"name": "app", "labels": map[any]any{"app": "test"}},
+			"spec":       map[any]any{"replicas": 3},
+		}},
+		"wildcard": {[]string{"spec.template.spec.containers[*].image"}, true, identity(map[any]any{
+			"spec": map[any]any{"template": map[any]any{"spec": map[any]any{"containers": []any{
+				map[any]any{"image": "example/app:v1"},
+				map[any]any{"image": "example/proxy:v2"},
+			}}}},
+		})},
+		"index and wildcard": {[]string{"spec.template.spec.containers[*].name", "spec.template.spec.containers[1].ports"}, true, identity(map[any]any{
+			"spec": map[any]any{"template": map[any]any{"spec": map[any]any{"containers": []any{
+				map[any]any{"name": "app"},
+				map[any]any{"name": "proxy", "ports": []any{8080}},
+			}}}},
+		})},
+		"index": {[]string{"spec.template.spec.containers[1]"}, true, identity(map[any]any{
+			"spec": map[any]any{"template": map[any]any{"spec": map[any]any{"containers": []any{
+				map[any]any{"name": "proxy", "image": "example/proxy:v2", "ports": []any{8080}},
+			}}}},
+		})},
+		"everything": {[]string{"metadata", "spec", "status"}, false, newManifest()},
+	}
+
+	for name, test := range tests {
+		manifest := newManifest()
+		allowed, err := compileAllowedAttributes(test.paths)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		modified := allowed.retain(manifest)
+
+		if modified != test.modified {
+			t.Errorf("%s: expected modified to be %t, got %t", name, test.modified, modified)
+		}
+		if !reflect.DeepEqual(manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, manifest)
+		}
+	}
+
+	if _, err := compileAllowedAttributes([]string{"spec.containers[name]"}); err == nil {
+		t.Error("expected a malformed index to fail")
+	}
+}
+
+func TestKindAttributeFilter(t *testing.T) {
+	filter, _, err := compileKindAttributeFilter(map[string][]string{
+		"admissionregistration.k8s.io/v1/*WebhookConfiguration": {"webhooks[*].clientConfig.caBundle"},
+		"*/Deployment": {"status"},
+		"apps/v1/*":    {"spec.replicas"},
+		"*":            {"metadata.uid"},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]struct {
+		manifest map[any]any
+		modified bool
+		expected map[any]any
+	}{
+		"webhook": {
+			map[any]any{"apiVersion": "admissionregistration.k8s.io/v1", "kind": "MutatingWebhookConfiguration", "webhooks": []any{map[any]any{"clientConfig": map[any]any{"caBundle": "abc", "url": "https://example.com"}}}},
+			true,
+			map[any]any{"apiVersion": "admissionregistration.k8s.io/v1", "kind": "MutatingWebhookConfiguration", "webhooks": []any{map[any]any{"clientConfig": map[any]any{"url": "https://example.com"}}}},
+		},
+		"several patterns": {
+			map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"uid": "abc"}, "spec": map[any]any{"replicas": 3}, "status": map[any]any{}},
+			true,
+			map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"uid": "abc"}, "spec": map[any]any{}},
+		},
+		"fallback": {
+			map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{"uid": "abc"}, "webhooks": []any{}},
+			true,
+			map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{}, "webhooks": []any{}},
+		},
+		"untouched": {
+			map[any]any{"apiVersion": "v1", "kind": "Secret"},
+			false,
+			map[any]any{"apiVersion": "v1", "kind": "Secret"},
+		},
+	}
+
+	for name, test := range tests {
+		modified := filter.apply(test.manifest)
+		if modified != test.modified {
+			t.Errorf("%s: expected modified to be %t, got %t", name, test.modified, modified)
+		}
+		if !reflect.DeepEqual(test.manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, test.manifest)
+		}
+	}
+
+	for pattern, paths := range map[string][]string{"Deployment": {"status"}, "apps/v1/[": {"status"}, "*": {"spec[first]"}} {
+		if _, key, err := compileKindAttributeFilter(map[string][]string{pattern: paths}, false); err == nil || key != pattern {
+			t.Errorf("%s: expected the entry to fail, got %q: %v", pattern, key, err)
+		}
+	}
+}
+
+func TestParseAttributePath(t *testing.T) {
+	key := func(key string) pathSegment { return pathSegment{key: key} }
+	index := func(index int) pathSegment { return pathSegment{list: true, index: index} }
+
+	tests := map[string][]pathSegment{
+		"metadata.uid":                     {key("metadata"), key("uid")},
+		"spec.containers[1].image":         {key("spec"), key("containers"), index(1), key("image")},
+		"spec.containers[*]":               {key("spec"), key("containers"), {list: true, every: true}},
+		`metadata.annotations.a\.b/c`:      {key("metadata"), key("annotations"), key("a.b/c")},
+		`metadata.annotations."a.b/c"`:     {key("metadata"), key("annotations"), key("a.b/c")},
+		`metadata.annotations["a.b/c"].x`:  {key("metadata"), key("annotations"), key("a.b/c"), key("x")},
+		`data."quoted \"key\""[0]`:         {key("data"), key(`quoted "key"`), index(0)},
+		`metadata.labels.app\[0\]`:         {key("metadata"), key("labels"), key("app[0]")},
+		`metadata.annotations."a.b"."c.d"`: {key("metadata"), key("annotations"), key("a.b"), key("c.d")},
+	}
+
+	for path, expected := range tests {
+		segments, err := parseAttributePath(path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", path, err)
+		} else if !reflect.DeepEqual(segments, expected) {
+			t.Errorf("%s: expected %+v, got %+v", path, expected, segments)
+		}
+	}
+
+	for _, path := range []string{`metadata."unterminated`, `metadata."a"b`, `metadata["a"`, `metadata["a"]b`} {
+		if _, err := parseAttributePath(path); err == nil {
+			t.Errorf("%s: expected a malformed path to fail", path)
+		}
+	}
+}
+
+func TestResourceFilter(t *testing.T) {
+	// Patterns are separated by commas
+	tests := map[string]map[string]bool{
+		"apps/v1/Deployment": {"apps/v1/Deployment": true, "apps/v1beta1/Deployment": false, "v1/Deployment": false},
+		"apps/v1/*":          {"apps/v1/Deployment": true, "apps/v1/StatefulSet": true, "batch/v1/Job": false},
+		"*/Deployment":       {"apps/v1/Deployment": true, "v1/Deployment": true, "apps/v1/StatefulSet": false},
+		"*/*/Deployment":     {"apps/v1/Deployment": true, "v1/Deployment": false},
+		"v1/*":               {"v1/Namespace": true, "apps/v1/Deployment": false},
+		"monitoring.coreos.com/*/*": {
+			"monitoring.coreos.com/v1/ServiceMonitor":           true,
+			"monitoring.coreos.com/v1alpha1/AlertmanagerConfig": true,
+			"apps/v1/Deployment":                                false,
+		},
+		"!v1/Namespace":              {"v1/Namespace": false, "v1/ConfigMap": true, "apps/v1/Deployment": true},
+		"apps/v1/*,!apps/v1/Secret*": {"apps/v1/Deployment": true, "apps/v1/SecretStore": false, "v1/Namespace": false},
+		"!*/Secret,*/*,!v1/*":        {"apps/v1/Deployment": true, "apps/v1/Secret": false, "v1/ConfigMap": false},
+	}
+
+	for pattern, resources := range tests {
+		filter, err := compileResourceFilter(strings.Split(pattern, ","))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", pattern, err)
+		}
+
+		for resource, expected := range resources {
+			separator := strings.LastIndex(resource, "/")
+			manifest := map[any]any{"apiVersion": resource[:separator], "kind": resource[separator+1:]}
+			if actual := filter.allows(manifest); actual != expected {
+				t.Errorf("%s: expected %s to be allowed: %t, got %t", pattern, resource, expected, actual)
+			}
+		}
+	}
+
+	if _, err := compileResourceFilter([]string{"apps/v1/[Deployment"}); err == nil {
+		t.Error("expected a malformed pattern to fail")
+	}
+}
+
+func TestNameSelector(t *testing.T) {
+	tests := map[string]map[string]bool{
+		"cert-manager":           {"cert-manager": true, "cert-manager-webhook": false},
+		"cert-manager-*":         {"cert-manager": false, "cert-manager-webhook": true},
+		"/^cert-manager(-.+)?$/": {"cert-manager": true, "cert-manager-webhook": true, "trust-manager": false},
+		"/webhook/":              {"cert-manager-webhook": true, "cert-manager": false},
+	}
+
+	for pattern, names := range tests {
+		selector, err := compileNameSelector([]string{pattern})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", pattern, err)
+		}
+
+		for name, expected := range names {
+			manifest := map[any]any{"metadata": map[any]any{"name": name}}
+			if actual := selector.allows(manifest); actual != expected {
+				t.Errorf("%s: expected %s to be allowed: %t, got %t", pattern, name, expected, actual)
+			}
+		}
+	}
+
+	selector, _ := compileNameSelector([]string{"*"})
+	if selector.allows(map[any]any{"kind": "List"}) {
+		t.Error("expected a manifest without a name not to be allowed")
+	}
+
+	for _, pattern := range []string{"[cert-manager", "/(/"} {
+		if _, err := compileNameSelector([]string{pattern}); err == nil {
+			t.Errorf("%s: expected a malformed pattern to fail", pattern)
+		}
+	}
+}
+
+func TestNamespaceSelector(t *testing.T) {
+	// Patterns are separated by commas
+	tests := map[string]map[string]bool{
+		"team-a":           {"team-a": true, "team-b": false, "": true},
+		"team-*,!team-b":   {"team-a": true, "team-b": false, "default": false},
+		"!kube-*":          {"default": true, "kube-system": false, "kube-public": false},
+		"!team-a,team-a,*": {"team-a": false, "team-b": true},
+	}
+
+	for pattern, namespaces := range tests {
+		selector, err := compileNamespaceSelector(strings.Split(pattern, ","), true)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", pattern, err)
+		}
+
+		for namespace, expected := range namespaces {
+			manifest := map[any]any{"metadata": map[any]any{"namespace": namespace}}
+			if actual := selector.allows(manifest); actual != expected {
+				t.Errorf("%s: expected %q to be allowed: %t, got %t", pattern, namespace, expected, actual)
+			}
+		}
+	}
+
+	selector, _ := compileNamespaceSelector([]string{"*"}, false)
+	if selector.allows(map[any]any{"kind": "ClusterRole"}) {
+		t.Error("expected a cluster-scoped manifest not to be allowed")
+	}
+
+	if _, err := compileNamespaceSelector([]string{"[team"}, true); err == nil {
+		t.Error("expected a malformed pattern to fail")
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	manifest := map[any]any{"metadata": map[any]any{"labels": map[any]any{"app": "istio", "component": "pilot"}}}
+	tests := map[string]bool{
+		"app=istio":                   true,
+		"app=istio,component!=pilot":  false,
+		"component in (pilot,proxy)":  true,
+		"!tier":                       true,
+		"tier":                        false,
+		"app.kubernetes.io/name=test": false,
+	}
+
+	for expression, expected := range tests {
+		selector, err := compileLabelSelector(expression)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", expression, err)
+		}
+		if actual := selector.allows(manifest); actual != expected {
+			t.Errorf("%s: expected %t, got %t", expression, expected, actual)
+		}
+	}
+
+	if selector, _ := compileLabelSelector(""); !selector.allows(manifest) {
+		t.Error("expected an empty selector to allow every manifest")
+	}
+	if _, err := compileLabelSelector("component in (pilot"); err == nil {
+		t.Error("expected a malformed selector to fail")
+	}
+}
+
+func TestAnnotationSelector(t *testing.T) {
+	manifest := map[any]any{"metadata": map[any]any{"annotations": map[any]any{
+		"helm.sh/hook":   "pre-install,pre-upgrade",
+		"example.com/id": "a=b",
+	}}}
+
+	// Requirements are separated by semicolons
+	tests := map[string]bool{
+		"helm.sh/hook":                          true,
+		"!helm.sh/hook":                         false,
+		"helm.sh/hook=pre-install,pre-upgrade":  true,
+		"helm.sh/hook!=pre-install,pre-upgrade": false,
+		"helm.sh/hook!=post-install":            true,
+		"example.com/id=a=b;helm.sh/hook":       true,
+		"example.com/id=a=b;!helm.sh/hook":      false,
+		"example.com/missing!=true;!other":      true,
+		"example.com/missing=":                  false,
+	}
+
+	for requirements, expected := range tests {
+		selector, err := compileAnnotationSelector(strings.Split(requirements, ";"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", requirements, err)
+		}
+		if actual := selector.allows(manifest); actual != expected {
+			t.Errorf("%s: expected %t, got %t", requirements, expected, actual)
+		}
+	}
+
+	for _, requirement := range []string{"", "!", "=value"} {
+		if _, err := compileAnnotationSelector([]string{requirement}); err == nil {
+			t.Errorf("%q: expected a requirement without a key to fail", requirement)
+		}
+	}
+}
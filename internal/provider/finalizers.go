@@ -0,0 +1,35 @@
+package provider
+
+// Removes the `metadata.finalizers` of documents, which otherwise hold up their deletion until a controller, which may
+// be gone by then, removes them
+type finalizerStripper struct {
+	resources *resourceFilter
+}
+
+// Creates a stripper for the documents matching the resource patterns, or every document when there are none. A nil
+// stripper is returned when it isn't enabled.
+func compileFinalizerStripper(enabled bool, resources []string) (*finalizerStripper, error) {
+	if !enabled {
+		return nil, nil
+	}
+
+	filter, err := compileResourceFilter(resources)
+	if err != nil {
+		return nil, err
+	}
+	return &finalizerStripper{resources: filter}, nil
+}
+
+// Removes the finalizers of the document when it is selected, returning whether it had any
+func (s *finalizerStripper) apply(manifest map[any]any) bool {
+	if s == nil || !s.resources.allows(manifest) {
+		return false
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	if _, ok := metadata["finalizers"]; !ok {
+		return false
+	}
+	delete(metadata, "finalizers")
+	return true
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_StripFinalizers(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(stripFinalizersStatement, server.URL, "v1/PersistentVolumeClaim"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: PersistentVolumeClaim\nmetadata:\n  name: data\n")),
+			},
+			{
+				Config: fmt.Sprintf(stripFinalizersStatement, server.URL, "!v1/PersistentVolumeClaim"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", finalizedDocument),
+			},
+			{
+				Config:      fmt.Sprintf(stripFinalizersStatement, server.URL, "["),
+				ExpectError: regexp.MustCompile(`Invalid resource pattern`),
+			},
+		},
+	})
+}
+
+func TestFinalizerStripper(t *testing.T) {
+	if stripper, err := compileFinalizerStripper(false, []string{"v1/*"}); err != nil || stripper != nil {
+		t.Errorf("expected no stripper when disabled, got %v: %v", stripper, err)
+	}
+
+	stripper, err := compileFinalizerStripper(true, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	manifest := map[any]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[any]any{"name": "test", "finalizers": []any{"kubernetes"}}}
+	if !stripper.apply(manifest) {
+		t.Error("expected the finalizers to be removed")
+	}
+	if _, ok := manifest["metadata"].(map[any]any)["finalizers"]; ok {
+		t.Error("expected the finalizers to be gone")
+	}
+	if stripper.apply(manifest) {
+		t.Error("expected a manifest without finalizers to be left untouched")
+	}
+}
+
+const stripFinalizersStatement = `
+data "manifest_fetch" "test" {
+	url                        = "%s/finalized"
+	strip_finalizers           = true
+	strip_finalizers_resources = [%q]
+}
+`
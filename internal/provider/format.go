@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// The formats manifests can be decoded from
+const (
+	formatAuto = "auto"
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
+var formats = []string{formatAuto, formatYAML, formatJSON}
+
+// The encodings the body of a response can be wrapped in
+const bodyEncodingBase64 = "base64"
+
+var bodyEncodings = []string{bodyEncodingBase64}
+
+// Decodes a base64-encoded body, ignoring any line breaks. Bodies in the shape returned by the GitHub contents API,
+// a JSON object with base64 `content`, have their content decoded instead.
+func decodeBase64Body(body []byte) ([]byte, error) {
+	var wrapped struct {
+		Content  *string `json:"content"`
+		Encoding string  `json:"encoding"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Content != nil && wrapped.Encoding == bodyEncodingBase64 {
+		body = []byte(*wrapped.Content)
+	}
+
+	encoded := strings.Join(strings.Fields(string(body)), "")
+	if strings.ContainsAny(encoded, "-_") {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// Normalizes content produced by Windows tooling into UTF-8 with LF line endings. UTF-16 content is transcoded when it
+// starts with a byte-order mark, and UTF-8 byte-order marks are removed from the start of each document.
+func normalizeText(content []byte) ([]byte, error) {
+	if bytes.HasPrefix(content, utf16LEBOM) || bytes.HasPrefix(content, utf16BEBOM) {
+		decoded, err := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode UTF-16 content: %w", err)
+		}
+		content = decoded
+	}
+
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	content = bytes.TrimPrefix(content, utf8BOM)
+
+	// Files joined from multiple sources may each start with their own byte-order mark
+	return bytes.ReplaceAll(content, append([]byte("\n"), utf8BOM...), []byte("\n")), nil
+}
+
+// Selects the format to decode content with, returning an explanation of why it was selected. An explicitly configured
+// format takes precedence, followed by the extension of files extracted from archives or read from directories, then the
+// Content-Type of the response. Otherwise, the format is detected from the content itself.
+func selectFormat(configured, contentType, fileName string) (string, string) {
+	if configured != "" && configured != formatAuto {
+		return configured, "as selected by format"
+	}
+
+	if fileName != "" {
+		switch strings.ToLower(path.Ext(fileName)) {
+		case ".json", ".ndjson":
+			return formatJSON, "as implied by its extension"
+		case ".yaml", ".yml":
+			return formatYAML, "as implied by its extension"
+		}
+		return formatAuto, ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return formatAuto, ""
+	}
+
+	switch {
+	case mediaType == "application/json", mediaType == "application/x-ndjson", mediaType == "application/jsonl", strings.HasSuffix(mediaType, "+json"):
+		return formatJSON, fmt.Sprintf("as declared by its Content-Type %q", mediaType)
+	case mediaType == "application/yaml", mediaType == "application/x-yaml", mediaType == "text/yaml", mediaType == "text/x-yaml", strings.HasSuffix(mediaType, "+yaml"):
+		return formatYAML, fmt.Sprintf("as declared by its Content-Type %q", mediaType)
+	}
+
+	return formatAuto, ""
+}
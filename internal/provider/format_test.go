@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestNormalizeText(t *testing.T) {
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte("kind: Test\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"plain":  []byte("kind: Test\n"),
+		"crlf":   []byte("kind: Test\r\n"),
+		"bom":    []byte("\ufeffkind: Test\n"),
+		"utf-16": utf16,
+	}
+
+	for name, content := range tests {
+		actual, err := normalizeText(content)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		} else if string(actual) != "kind: Test\n" {
+			t.Errorf("%s: expected %q, got %q", name, "kind: Test\n", actual)
+		}
+	}
+
+	joined, _ := normalizeText([]byte("\ufeffkind: A\r\n---\r\n\ufeffkind: B\r\n"))
+	if string(joined) != "kind: A\n---\nkind: B\n" {
+		t.Errorf("joined: expected byte-order marks to be removed from each document, got %q", joined)
+	}
+}
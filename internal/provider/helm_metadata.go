@@ -0,0 +1,58 @@
+package provider
+
+import "strings"
+
+// The labels Helm sets to the name of the tool managing the object, which are only removed when they name Helm
+var helmManagedByLabels = []string{"app.kubernetes.io/managed-by", "heritage"}
+
+// Removes the labels and annotations Helm adds to the documents, and those of the pod templates of workloads, so the
+// documents can be managed by another tool: every key under `helm.sh/` or `meta.helm.sh/`, such as `helm.sh/chart`
+// and the hook annotations, and the labels naming Helm as the manager
+func stripHelmMetadata(manifest map[any]any) bool {
+	modified := stripHelmKeys(manifest)
+
+	if path, ok := podSpecPaths[stringValue(manifest["kind"])]; ok && len(path) > 1 {
+		// The pod template's metadata is next to its spec
+		if template, ok := lookupMap(manifest, path[:len(path)-1]...); ok {
+			modified = stripHelmKeys(template) || modified
+		}
+	}
+	return modified
+}
+
+// Removes the Helm labels and annotations from the `metadata` of the object, returning whether any were present
+func stripHelmKeys(object map[any]any) bool {
+	metadata, ok := object["metadata"].(map[any]any)
+	if !ok {
+		return false
+	}
+
+	modified := false
+	for _, field := range []string{"labels", "annotations"} {
+		values, ok := metadata[field].(map[any]any)
+		if !ok {
+			continue
+		}
+
+		removed := false
+		for key, value := range values {
+			if isHelmKey(stringValue(key), field == "labels", stringValue(value)) {
+				delete(values, key)
+				removed = true
+			}
+		}
+		if removed && len(values) == 0 {
+			delete(metadata, field)
+		}
+		modified = removed || modified
+	}
+	return modified
+}
+
+// Whether the label or annotation was added by Helm
+func isHelmKey(key string, label bool, value string) bool {
+	if strings.HasPrefix(key, "helm.sh/") || strings.HasPrefix(key, "meta.helm.sh/") {
+		return true
+	}
+	return label && contains(helmManagedByLabels, key) && (value == "Helm" || value == "Tiller")
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_StripHelmMetadata(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(stripHelmMetadataStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: job\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", annotatedDocument("deployment", ""))),
+			},
+		},
+	})
+}
+
+func TestStripHelmMetadata(t *testing.T) {
+	manifest := map[any]any{
+		"kind": "Deployment",
+		"metadata": map[any]any{
+			"labels":      map[any]any{"app.kubernetes.io/managed-by": "Helm", "helm.sh/chart": "app-1.0.0", "app": "app"},
+			"annotations": map[any]any{"meta.helm.sh/release-name": "app", "meta.helm.sh/release-namespace": "default"},
+		},
+		"spec": map[any]any{"template": map[any]any{
+			"metadata": map[any]any{"labels": map[any]any{"heritage": "Tiller", "release": "app"}},
+			"spec":     map[any]any{},
+		}},
+	}
+	if !stripHelmMetadata(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+
+	expected := map[any]any{
+		"kind":     "Deployment",
+		"metadata": map[any]any{"labels": map[any]any{"app": "app"}},
+		"spec": map[any]any{"template": map[any]any{
+			"metadata": map[any]any{"labels": map[any]any{"release": "app"}},
+			"spec":     map[any]any{},
+		}},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+
+	managed := map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"labels": map[any]any{"app.kubernetes.io/managed-by": "Terraform"}}}
+	if stripHelmMetadata(managed) {
+		t.Error("expected a manifest managed by another tool to be left untouched")
+	}
+}
+
+const stripHelmMetadataStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/hooks"
+	strip_helm_metadata = true
+}
+`
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pins the images of the containers in pod templates to the digests their tags currently point to, so the returned
+// manifests deploy the same images until they're fetched again
+type imageDigestPinner struct {
+	// The rewrites applied before pinning, so the images are resolved against the registry they're pulled from
+	images *imageRewriter
+
+	// The digest of each image, collected from every document and resolved before any is transformed. Only read once
+	// transforming starts.
+	digests map[string]string
+}
+
+// Creates a pinner when enabled, otherwise returning nil
+func compileImageDigestPinner(enabled bool, images *imageRewriter) *imageDigestPinner {
+	if !enabled {
+		return nil
+	}
+
+	return &imageDigestPinner{images: images, digests: map[string]string{}}
+}
+
+// Records the images of the workload's containers which aren't pinned yet
+func (p *imageDigestPinner) collect(manifest map[any]any) {
+	spec := podSpec(manifest)
+	if spec == nil {
+		return
+	}
+
+	for _, container := range podContainers(spec) {
+		image := stringValue(container["image"])
+		if p.images != nil {
+			image, _ = p.images.rewrite(image)
+		}
+		if image != "" && !strings.Contains(image, "@") {
+			p.digests[image] = ""
+		}
+	}
+}
+
+// Resolves the digest of every collected image from its registry, with at most parallelism requests at once
+func (p *imageDigestPinner) resolve(ctx context.Context, registry *registryClient, parallelism int) error {
+	images := make([]string, 0, len(p.digests))
+	for image := range p.digests {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	digests := make([]string, len(images))
+	errs := make([]error, len(images))
+	forEachConcurrently(len(images), parallelism, func(i int) {
+		ref, err := parseImageReference(images[i])
+		if err == nil {
+			digests[i], err = registry.resolve(ctx, ref)
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to resolve the digest of %s: %w", images[i], err)
+		}
+	})
+
+	for i, image := range images {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		p.digests[image] = digests[i]
+	}
+	return nil
+}
+
+// Pins the images of the workload's containers, returning whether any changed. Runs after the images are rewritten.
+func (p *imageDigestPinner) apply(manifest map[any]any) bool {
+	if p == nil {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil {
+		return false
+	}
+
+	modified := false
+	for _, container := range podContainers(spec) {
+		image := stringValue(container["image"])
+		if digest := p.digests[image]; digest != "" {
+			container["image"] = pinImage(image, digest)
+			modified = true
+		}
+	}
+	return modified
+}
+
+// Replaces the tag of the image with the digest, keeping the image's name as it is written
+func pinImage(image, digest string) string {
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image = image[:index]
+	}
+	return image + "@" + digest
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDataSource_PinImagesToDigest(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+	registry := setupMockImageRegistry()
+	defer registry.Close()
+
+	host := strings.TrimPrefix(registry.URL, "http://")
+	expected := strings.NewReplacer(
+		"quay.io/jetstack/cert-manager-controller:v1.14.0", host+"/jetstack/cert-manager-controller@"+mockImageDigest("jetstack/cert-manager-controller", "v1.14.0"),
+		"image: busybox", "image: "+host+"/library/busybox@"+mockImageDigest("library/busybox", "latest"),
+	).Replace(workloadDocument)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(pinImagesStatement, server.URL, host, host),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", expected)),
+			},
+		},
+	})
+}
+
+func TestImageDigestPinner(t *testing.T) {
+	registry := setupMockImageRegistry()
+	defer registry.Close()
+
+	host := strings.TrimPrefix(registry.URL, "http://")
+	images, _, _ := compileImageRewriter([]imageRewriteModel{{Match: types.String{Value: "quay.io/jetstack"}, Replace: types.String{Value: host + "/jetstack"}}})
+	pinner := compileImageDigestPinner(true, images)
+
+	var manifest map[any]any
+	if err := yaml.Unmarshal([]byte(strings.Replace(workloadDocument, "busybox", host+"/library/busybox@sha256:pinned", 1)), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	pinner.collect(manifest)
+	if len(pinner.digests) != 1 {
+		t.Fatalf("expected only the unpinned image to be collected, got %v", pinner.digests)
+	}
+	if err := pinner.resolve(context.Background(), newRegistryClient(http.DefaultTransport, nil), 2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	images.apply(manifest)
+	if !pinner.apply(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+	containers := podContainers(podSpec(manifest))
+	if expected := host + "/jetstack/cert-manager-controller@" + mockImageDigest("jetstack/cert-manager-controller", "v1.14.0"); containers[1]["image"] != expected {
+		t.Errorf("expected %q, got %q", expected, containers[1]["image"])
+	}
+	if expected := host + "/library/busybox@sha256:pinned"; containers[0]["image"] != expected {
+		t.Errorf("expected %q, got %q", expected, containers[0]["image"])
+	}
+
+	missing := compileImageDigestPinner(true, nil)
+	missing.digests[host+"/example/missing:v1"] = ""
+	if err := missing.resolve(context.Background(), newRegistryClient(http.DefaultTransport, nil), 1); err == nil {
+		t.Error("expected resolving a missing image to fail")
+	}
+}
+
+func TestPinImage(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                        "nginx@sha256:abc",
+		"nginx:1.25":                   "nginx@sha256:abc",
+		"localhost:5000/app":           "localhost:5000/app@sha256:abc",
+		"localhost:5000/app:v1":        "localhost:5000/app@sha256:abc",
+		"quay.io/jetstack/webhook:1.0": "quay.io/jetstack/webhook@sha256:abc",
+	}
+
+	for image, expected := range tests {
+		if pinned := pinImage(image, "sha256:abc"); pinned != expected {
+			t.Errorf("%s: expected %q, got %q", image, expected, pinned)
+		}
+	}
+}
+
+// A registry which serves a digest for every manifest of the jetstack and library repositories
+func setupMockImageRegistry() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repository, reference, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/")
+		if !ok || !(strings.HasPrefix(repository, "jetstack/") || strings.HasPrefix(repository, "library/")) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Docker-Content-Digest", mockImageDigest(repository, reference))
+		w.Header().Set("Content-Type", mediaTypeOCIIndex)
+	}))
+}
+
+func mockImageDigest(repository, reference string) string {
+	return "sha256:" + sha256Hex([]byte(repository+":"+reference))
+}
+
+const pinImagesStatement = `
+data "manifest_fetch" "test" {
+	url                  = "%s/workload"
+	pin_images_to_digest = true
+
+	image_rewrites {
+		match   = "quay.io/jetstack"
+		replace = "%s/jetstack"
+	}
+
+	image_rewrites {
+		match   = "docker.io/"
+		replace = "%s/"
+	}
+}
+`
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Appends image pull secrets to the pod spec of every workload
+type imagePullSecretInjector struct {
+	secrets []string
+}
+
+// Compiles the names of the secrets into an injector, failing with the index of the first invalid name. A nil
+// injector is returned when there are no secrets.
+func compileImagePullSecretInjector(secrets []string) (*imagePullSecretInjector, int, error) {
+	if len(secrets) == 0 {
+		return nil, 0, nil
+	}
+
+	for i, secret := range secrets {
+		if errs := validation.IsDNS1123Subdomain(secret); len(errs) > 0 {
+			return nil, i, fmt.Errorf("%q isn't a valid secret name: %s", secret, strings.Join(errs, ", "))
+		}
+	}
+	return &imagePullSecretInjector{secrets: secrets}, 0, nil
+}
+
+// Appends the secrets the workload doesn't already reference, returning whether any were appended
+func (i *imagePullSecretInjector) apply(manifest map[any]any) bool {
+	if i == nil {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil {
+		return false
+	}
+
+	existing, _ := spec["imagePullSecrets"].([]any)
+	referenced := make(map[string]bool, len(existing))
+	eachMap(existing, func(reference map[any]any) {
+		referenced[stringValue(reference["name"])] = true
+	})
+
+	modified := false
+	for _, secret := range i.secrets {
+		if referenced[secret] {
+			continue
+		}
+
+		existing = append(existing, map[any]any{"name": secret})
+		referenced[secret] = true
+		modified = true
+	}
+
+	if modified {
+		spec["imagePullSecrets"] = existing
+	}
+	return modified
+}
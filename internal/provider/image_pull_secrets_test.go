@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_ImagePullSecrets(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	injected := workloadDocument + "      imagePullSecrets:\n      - name: registry\n"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(imagePullSecretsStatement, server.URL, "registry"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", injected)),
+			},
+			{
+				Config:      fmt.Sprintf(imagePullSecretsStatement, server.URL, "Registry"),
+				ExpectError: regexp.MustCompile(`Invalid image pull secret`),
+			},
+		},
+	})
+}
+
+func TestImagePullSecretInjector(t *testing.T) {
+	injector, _, err := compileImagePullSecretInjector([]string{"mirror", "registry"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	job := map[any]any{
+		"kind": "CronJob",
+		"spec": map[any]any{"jobTemplate": map[any]any{"spec": map[any]any{"template": map[any]any{"spec": map[any]any{
+			"imagePullSecrets": []any{map[any]any{"name": "registry"}},
+		}}}}},
+	}
+	if !injector.apply(job) {
+		t.Error("expected the cron job to be modified")
+	}
+	expected := []any{map[any]any{"name": "registry"}, map[any]any{"name": "mirror"}}
+	if secrets := podSpec(job)["imagePullSecrets"]; !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected %v, got %v", expected, secrets)
+	}
+	if injector.apply(job) {
+		t.Error("expected injecting the same secrets again to change nothing")
+	}
+
+	if injector.apply(map[any]any{"kind": "ConfigMap", "data": map[any]any{}}) {
+		t.Error("expected a manifest which isn't a workload to be left untouched")
+	}
+	if _, index, err := compileImagePullSecretInjector([]string{"registry", "not_valid"}); err == nil || index != 1 {
+		t.Errorf("expected the second name to be invalid, got %d: %v", index, err)
+	}
+}
+
+const imagePullSecretsStatement = `
+data "manifest_fetch" "test" {
+	url                = "%s/workload"
+	image_pull_secrets = [%q]
+}
+`
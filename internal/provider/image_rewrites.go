@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var imageRewritesBlock = tfsdk.Block{
+	MarkdownDescription: "Rewrite the `image` of every container and init container in the pod templates, such as to pull upstream images from a mirror registry. Only the first matching rule is applied to each image.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Attributes: map[string]tfsdk.Attribute{
+		"match": {
+			MarkdownDescription: "The prefix of the images to rewrite, such as `quay.io/jetstack` or `docker.io/`. The prefix must end at a `/`, `:`, or `@`, or at the end of the image, so `quay.io/jetstack` doesn't match `quay.io/jetstack-charts/cert-manager`. Images without a registry are matched as if they were fully qualified, so `docker.io/library/nginx` matches `nginx:1.25`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"replace": {
+			MarkdownDescription: "What the matched prefix is replaced with, such as `registry.example.com/jetstack`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+	},
+}
+
+type imageRewriteModel struct {
+	Match   types.String `tfsdk:"match"`
+	Replace types.String `tfsdk:"replace"`
+}
+
+// Rewrites the images of the containers in pod templates by replacing their prefix
+type imageRewriter struct {
+	rules []imageRewrite
+}
+
+type imageRewrite struct {
+	match   string
+	replace string
+}
+
+// Compiles the rules into a rewriter, failing with the index of the first invalid rule. A nil rewriter is returned
+// when there are no rules.
+func compileImageRewriter(models []imageRewriteModel) (*imageRewriter, int, error) {
+	if len(models) == 0 {
+		return nil, 0, nil
+	}
+
+	rewriter := &imageRewriter{}
+	for i, model := range models {
+		if model.Match.Value == "" {
+			return nil, i, fmt.Errorf("the image prefix to match can't be empty")
+		}
+		rewriter.rules = append(rewriter.rules, imageRewrite{model.Match.Value, model.Replace.Value})
+	}
+	return rewriter, 0, nil
+}
+
+// Rewrites the images of the workload's containers, returning whether any changed
+func (r *imageRewriter) apply(manifest map[any]any) bool {
+	if r == nil {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil {
+		return false
+	}
+
+	modified := false
+	for _, container := range podContainers(spec) {
+		image := stringValue(container["image"])
+		if rewritten, ok := r.rewrite(image); ok && rewritten != image {
+			container["image"] = rewritten
+			modified = true
+		}
+	}
+	return modified
+}
+
+// Rewrites the image with the first matching rule, returning whether any matched
+func (r *imageRewriter) rewrite(image string) (string, bool) {
+	if image == "" {
+		return image, false
+	}
+
+	candidates := []string{image}
+	if qualified := qualifyImage(image); qualified != image {
+		candidates = append(candidates, qualified)
+	}
+
+	for _, rule := range r.rules {
+		for _, candidate := range candidates {
+			if hasImagePrefix(candidate, rule.match) {
+				return rule.replace + candidate[len(rule.match):], true
+			}
+		}
+	}
+	return image, false
+}
+
+// Whether the image starts with the prefix, ending at one of the separators of an image reference
+func hasImagePrefix(image, prefix string) bool {
+	if !strings.HasPrefix(image, prefix) {
+		return false
+	}
+	if len(image) == len(prefix) || strings.ContainsAny(prefix[len(prefix)-1:], "/:@") {
+		return true
+	}
+	return strings.ContainsAny(image[len(prefix):len(prefix)+1], "/:@")
+}
+
+// Qualifies the image with the registry and namespace Docker implies when they're omitted, such as `nginx` being
+// `docker.io/library/nginx`
+func qualifyImage(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if !found {
+		return "docker.io/library/" + image
+	}
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return image
+	}
+	return "docker.io/" + image
+}
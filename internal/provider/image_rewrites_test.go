@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_ImageRewrites(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	expected := strings.NewReplacer("quay.io/jetstack", "registry.example.com/jetstack", "image: busybox", "image: registry.example.com/library/busybox").Replace(workloadDocument)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(imageRewritesStatement, server.URL, "quay.io/jetstack"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", expected)),
+			},
+			{
+				Config:      fmt.Sprintf(imageRewritesStatement, server.URL, ""),
+				ExpectError: regexp.MustCompile(`the image prefix to match can't be empty`),
+			},
+		},
+	})
+}
+
+func TestImageRewriter(t *testing.T) {
+	rewriter, _, err := compileImageRewriter([]imageRewriteModel{
+		{Match: types.String{Value: "quay.io/jetstack"}, Replace: types.String{Value: "mirror.example.com/jetstack"}},
+		{Match: types.String{Value: "docker.io/"}, Replace: types.String{Value: "mirror.example.com/docker/"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]struct {
+		image    string
+		expected string
+		matched  bool
+	}{
+		"prefix":               {"quay.io/jetstack/cert-manager-controller:v1.14.0", "mirror.example.com/jetstack/cert-manager-controller:v1.14.0", true},
+		"partial component":    {"quay.io/jetstack-charts/cert-manager", "quay.io/jetstack-charts/cert-manager", false},
+		"qualified":            {"docker.io/bitnami/redis:7", "mirror.example.com/docker/bitnami/redis:7", true},
+		"implied registry":     {"bitnami/redis:7", "mirror.example.com/docker/bitnami/redis:7", true},
+		"implied namespace":    {"nginx@sha256:abc", "mirror.example.com/docker/library/nginx@sha256:abc", true},
+		"other registry":       {"ghcr.io/fluxcd/source-controller", "ghcr.io/fluxcd/source-controller", false},
+		"registry with a port": {"localhost:5000/app", "localhost:5000/app", false},
+		"localhost registry":   {"localhost/app", "localhost/app", false},
+	}
+
+	for name, test := range tests {
+		rewritten, matched := rewriter.rewrite(test.image)
+		if rewritten != test.expected || matched != test.matched {
+			t.Errorf("%s: expected %q (%t), got %q (%t)", name, test.expected, test.matched, rewritten, matched)
+		}
+	}
+}
+
+const imageRewritesStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/workload"
+
+	image_rewrites {
+		match   = %q
+		replace = "registry.example.com/jetstack"
+	}
+
+	image_rewrites {
+		match   = "docker.io/"
+		replace = "registry.example.com/"
+	}
+}
+`
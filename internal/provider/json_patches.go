@@ -0,0 +1,408 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+// The operations of a JSON patch, as defined by RFC 6902
+const (
+	patchAdd     = "add"
+	patchRemove  = "remove"
+	patchReplace = "replace"
+	patchMove    = "move"
+	patchCopy    = "copy"
+	patchTest    = "test"
+)
+
+var patchOperations = []string{patchAdd, patchRemove, patchReplace, patchMove, patchCopy, patchTest}
+
+var jsonPatchesBlock = tfsdk.Block{
+	MarkdownDescription: "Apply a JSON patch, as defined by RFC 6902, to the matching manifests. Unlike `set_attributes`, patches can insert, remove, move, and test elements of lists. Patches are applied in order, before the manifests are moved into a namespace or renamed, and the fetch fails if a patch can't be applied to a manifest it targets.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Attributes: map[string]tfsdk.Attribute{
+		"resources": {
+			MarkdownDescription: "The `{apiVersion}/{kind}` patterns of the resources to patch, using the same syntax as `only_resources`. Every resource is patched when unset.",
+			Type:                types.ListType{ElemType: types.StringType},
+			Optional:            true,
+		},
+		"names": {
+			MarkdownDescription: "The names, shell patterns, or regular expressions the `metadata.name` of the manifests to patch must match, using the same syntax as `name_selector`. Every name is patched when unset.",
+			Type:                types.ListType{ElemType: types.StringType},
+			Optional:            true,
+		},
+		"operations": {
+			MarkdownDescription: "The operations of the patch as a JSON or YAML list, such as `[{\"op\": \"add\", \"path\": \"/spec/template/spec/containers/0/args/-\", \"value\": \"--v=2\"}]`. The `add`, `remove`, `replace`, `move`, `copy`, and `test` operations are supported.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+	},
+}
+
+type jsonPatchModel struct {
+	Resources  types.List   `tfsdk:"resources"`
+	Names      types.List   `tfsdk:"names"`
+	Operations types.String `tfsdk:"operations"`
+}
+
+// Applies JSON patches to the documents they target, in order
+type jsonPatcher struct {
+	patches []jsonPatch
+}
+
+// Either a JSON patch, which is a list of operations, or a JSON merge patch, as defined by RFC 7386
+type jsonPatch struct {
+	// The attribute the patch was configured at, such as `json_patches[0]`
+	attribute  string
+	resources  *resourceFilter
+	names      *nameSelector
+	target     *patchTarget
+	operations []patchOperation
+	merge      map[any]any
+}
+
+type patchOperation struct {
+	op    string
+	path  []string
+	from  []string
+	value any
+}
+
+// Compiles the patches of the json_patches and patch blocks into a patcher, failing with the path of the first invalid
+// patch. A nil patcher is returned when there are no patches.
+func compileJSONPatcher(ctx context.Context, jsonPatches []jsonPatchModel, patches []patchModel) (*jsonPatcher, path.Path, error) {
+	if len(jsonPatches) == 0 && len(patches) == 0 {
+		return nil, path.Empty(), nil
+	}
+
+	patcher := &jsonPatcher{}
+	for i, model := range jsonPatches {
+		at := path.Root("json_patches").AtListIndex(i)
+		resources, err := compileResourceFilter(parseTfList(ctx, model.Resources, func(resource string) string { return resource }))
+		if err != nil {
+			return nil, at.AtName("resources"), fmt.Errorf("invalid resource pattern: %w", err)
+		}
+		names, err := compileNameSelector(parseTfList(ctx, model.Names, func(name string) string { return name }))
+		if err != nil {
+			return nil, at.AtName("names"), fmt.Errorf("invalid name selector: %w", err)
+		}
+		operations, err := parsePatchOperations(model.Operations.Value)
+		if err != nil {
+			return nil, at.AtName("operations"), err
+		}
+
+		patcher.patches = append(patcher.patches, jsonPatch{attribute: fmt.Sprintf("json_patches[%d]", i), resources: resources, names: names, operations: operations})
+	}
+	for i, model := range patches {
+		patch, at, err := compilePatch(model, path.Root("patch").AtListIndex(i))
+		if err != nil {
+			return nil, at, err
+		}
+
+		patch.attribute = fmt.Sprintf("patch[%d]", i)
+		patcher.patches = append(patcher.patches, patch)
+	}
+	return patcher, path.Empty(), nil
+}
+
+// Parses the operations of a patch, encoded as either JSON or YAML
+func parsePatchOperations(raw string) ([]patchOperation, error) {
+	var decoded []map[string]any
+	if err := yaml.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("the operations must be a list of objects: %w", err)
+	}
+
+	operations := make([]patchOperation, 0, len(decoded))
+	for i, fields := range decoded {
+		operation := patchOperation{op: stringValue(fields["op"]), value: fields["value"]}
+		if !contains(patchOperations, operation.op) {
+			return nil, fmt.Errorf("operation %d: invalid op %q, must be one of: %s", i, operation.op, strings.Join(patchOperations, ", "))
+		}
+
+		var err error
+		if operation.path, err = parseJSONPointer(fields["path"]); err != nil {
+			return nil, fmt.Errorf("operation %d: invalid path: %w", i, err)
+		}
+		if operation.op == patchMove || operation.op == patchCopy {
+			if operation.from, err = parseJSONPointer(fields["from"]); err != nil {
+				return nil, fmt.Errorf("operation %d: invalid from: %w", i, err)
+			}
+		}
+		if _, ok := fields["value"]; !ok && (operation.op == patchAdd || operation.op == patchReplace || operation.op == patchTest) {
+			return nil, fmt.Errorf("operation %d: a value is required for the %s operation", i, operation.op)
+		}
+
+		operations = append(operations, operation)
+	}
+	return operations, nil
+}
+
+// Parses a JSON pointer, as defined by RFC 6901, into its reference tokens
+func parseJSONPointer(value any) ([]string, error) {
+	pointer, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%q must start with a /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// Applies each patch targeting the manifest, returning whether anything changed. A patch is applied entirely or not
+// at all, so a failing patch leaves the manifest untouched.
+func (p *jsonPatcher) apply(manifest map[any]any) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+
+	modified := false
+	for _, patch := range p.patches {
+		if !patch.resources.allows(manifest) || !patch.names.allows(manifest) || !patch.target.allows(manifest) {
+			continue
+		}
+
+		var patched any = copyValue(manifest)
+		if patch.merge != nil {
+			patched = mergePatch(patched, patch.merge)
+		}
+		for j, operation := range patch.operations {
+			var err error
+			if patched, err = operation.apply(patched); err != nil {
+				return false, fmt.Errorf("failed to apply %s to %s: operation %d: %w", patch.attribute, describeManifest(manifest), j, err)
+			}
+		}
+
+		result, ok := patched.(map[any]any)
+		if !ok {
+			return false, fmt.Errorf("failed to apply %s to %s: the manifest must remain an object", patch.attribute, describeManifest(manifest))
+		}
+		if reflect.DeepEqual(result, manifest) {
+			continue
+		}
+
+		for key := range manifest {
+			delete(manifest, key)
+		}
+		for key, value := range result {
+			manifest[key] = value
+		}
+		modified = true
+	}
+	return modified, nil
+}
+
+// Applies the operation to the document, returning the resulting document
+func (o patchOperation) apply(document any) (any, error) {
+	switch o.op {
+	case patchAdd:
+		return addValue(document, o.path, copyValue(o.value))
+	case patchRemove:
+		return removeValue(document, o.path)
+	case patchReplace:
+		if len(o.path) == 0 {
+			return copyValue(o.value), nil
+		}
+		document, err := removeValue(document, o.path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(document, o.path, copyValue(o.value))
+	case patchMove:
+		if len(o.path) > len(o.from) && reflect.DeepEqual(o.path[:len(o.from)], o.from) {
+			return nil, fmt.Errorf("can't move a value into itself")
+		}
+		value, err := getValue(document, o.from)
+		if err != nil {
+			return nil, err
+		}
+		if document, err = removeValue(document, o.from); err != nil {
+			return nil, err
+		}
+		return addValue(document, o.path, value)
+	case patchCopy:
+		value, err := getValue(document, o.from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(document, o.path, copyValue(value))
+	default:
+		value, err := getValue(document, o.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, o.value) {
+			return nil, fmt.Errorf("the value at %s is %v rather than %v", formatJSONPointer(o.path), value, o.value)
+		}
+		return document, nil
+	}
+}
+
+// Retrieves the value at the path, failing if it doesn't exist
+func getValue(document any, path []string) (any, error) {
+	for i, token := range path {
+		switch container := document.(type) {
+		case map[any]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%s doesn't exist", formatJSONPointer(path[:i+1]))
+			}
+			document = value
+		case []any:
+			index, err := listIndex(token, len(container)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatJSONPointer(path[:i+1]), err)
+			}
+			document = container[index]
+		default:
+			return nil, fmt.Errorf("%s isn't an object or a list", formatJSONPointer(path[:i]))
+		}
+	}
+	return document, nil
+}
+
+// Adds the value at the path, inserting it into lists and replacing existing values of maps
+func addValue(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[any]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			index, err := listIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("the parent isn't an object or a list")
+		}
+	})
+}
+
+// Removes the value at the path, failing if it doesn't exist
+func removeValue(document any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the whole manifest can't be removed")
+	}
+
+	return updateParent(document, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[any]any:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("it doesn't exist")
+			}
+			delete(container, token)
+			return container, nil
+		case []any:
+			index, err := listIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("the parent isn't an object or a list")
+		}
+	})
+}
+
+// Replaces the parent of the path with the result of update, which is passed the final token of the path. Lists are
+// replaced rather than modified in place, as inserting and removing elements changes their length.
+func updateParent(document any, path []string, update func(parent any, token string) (any, error)) (any, error) {
+	var walk func(value any, depth int) (any, error)
+	walk = func(value any, depth int) (any, error) {
+		if depth == len(path)-1 {
+			updated, err := update(value, path[depth])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatJSONPointer(path), err)
+			}
+			return updated, nil
+		}
+
+		switch container := value.(type) {
+		case map[any]any:
+			child, ok := container[path[depth]]
+			if !ok {
+				return nil, fmt.Errorf("%s doesn't exist", formatJSONPointer(path[:depth+1]))
+			}
+			updated, err := walk(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			container[path[depth]] = updated
+			return container, nil
+		case []any:
+			index, err := listIndex(path[depth], len(container)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatJSONPointer(path[:depth+1]), err)
+			}
+			updated, err := walk(container[index], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			container[index] = updated
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%s isn't an object or a list", formatJSONPointer(path[:depth]))
+		}
+	}
+
+	return walk(document, 0)
+}
+
+// Parses the token as an index of a list, which must be at most max
+func listIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%q isn't a valid list index", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("index %d is out of range", index)
+	}
+	return index, nil
+}
+
+// Formats the tokens as a JSON pointer
+func formatJSONPointer(path []string) string {
+	if len(path) == 0 {
+		return "the manifest"
+	}
+
+	var pointer strings.Builder
+	for _, token := range path {
+		pointer.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return pointer.String()
+}
+
+// Describes the manifest by its resource type and name for error messages
+func describeManifest(manifest map[any]any) string {
+	metadata, _ := manifest["metadata"].(map[any]any)
+	return strings.TrimSpace(fmt.Sprintf("%s/%s %s", stringValue(manifest["apiVersion"]), stringValue(manifest["kind"]), stringValue(metadata["name"])))
+}
@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_JSONPatches(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	expected := strings.Replace(workloadDocument, "        name: controller\n", "        name: controller\n      - image: busybox\n        name: sidecar\n", 1)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(jsonPatchesStatement, server.URL, `[{"op": "add", "path": "/spec/template/spec/containers/-", "value": {"name": "sidecar", "image": "busybox"}}]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", expected)),
+			},
+			{
+				Config:      fmt.Sprintf(jsonPatchesStatement, server.URL, `[{"op": "remove", "path": "/spec/replicas"}]`),
+				ExpectError: regexp.MustCompile(`/spec/replicas: it doesn't exist`),
+			},
+			{
+				Config:      fmt.Sprintf(jsonPatchesStatement, server.URL, `[{"op": "merge", "path": "/spec"}]`),
+				ExpectError: regexp.MustCompile(`Invalid patch`),
+			},
+		},
+	})
+}
+
+func TestJSONPatcher(t *testing.T) {
+	tests := map[string]struct {
+		operations string
+		expected   map[any]any
+		err        string
+	}{
+		"add to map": {
+			operations: `[{"op": "add", "path": "/spec/replicas", "value": 3}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"replicas": 3, "args": []any{"a", "b"}}},
+		},
+		"insert into list": {
+			operations: `[{"op": "add", "path": "/spec/args/1", "value": "c"}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"args": []any{"a", "c", "b"}}},
+		},
+		"remove from list": {
+			operations: `[{"op": "remove", "path": "/spec/args/0"}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"args": []any{"b"}}},
+		},
+		"replace": {
+			operations: `- {op: replace, path: /metadata/name, value: renamed}`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "renamed"}, "spec": map[any]any{"args": []any{"a", "b"}}},
+		},
+		"move": {
+			operations: `[{"op": "move", "from": "/spec/args", "path": "/spec/command"}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"command": []any{"a", "b"}}},
+		},
+		"copy": {
+			operations: `[{"op": "copy", "from": "/spec/args/1", "path": "/spec/args/-"}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"args": []any{"a", "b", "b"}}},
+		},
+		"escaped path": {
+			operations: `[{"op": "add", "path": "/metadata/annotations", "value": {}}, {"op": "add", "path": "/metadata/annotations/example.com~1owner", "value": "team"}]`,
+			expected:   map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app", "annotations": map[any]any{"example.com/owner": "team"}}, "spec": map[any]any{"args": []any{"a", "b"}}},
+		},
+		"failed test": {
+			operations: `[{"op": "add", "path": "/spec/replicas", "value": 3}, {"op": "test", "path": "/metadata/name", "value": "other"}]`,
+			err:        "failed to apply json_patches[0] to /Deployment app: operation 1: the value at /metadata/name is app rather than other",
+		},
+		"out of range": {
+			operations: `[{"op": "replace", "path": "/spec/args/2", "value": "c"}]`,
+			err:        "operation 0: /spec/args/2: index 2 is out of range",
+		},
+		"missing parent": {
+			operations: `[{"op": "add", "path": "/status/phase", "value": "Running"}]`,
+			err:        "operation 0: /status doesn't exist",
+		},
+	}
+
+	for name, test := range tests {
+		patcher, _, err := compileJSONPatcher(context.Background(), []jsonPatchModel{{Resources: types.List{Null: true, ElemType: types.StringType}, Names: types.List{Null: true, ElemType: types.StringType}, Operations: types.String{Value: test.operations}}}, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+
+		manifest := map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"args": []any{"a", "b"}}}
+		_, err = patcher.apply(manifest)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected error containing %q, got %v", name, test.err, err)
+			}
+			if manifest["spec"].(map[any]any)["replicas"] != nil {
+				t.Errorf("%s: expected a failed patch to leave the manifest untouched, got %v", name, manifest)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		} else if !reflect.DeepEqual(manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, manifest)
+		}
+	}
+}
+
+func TestJSONPatcher_Targets(t *testing.T) {
+	patcher, _, err := compileJSONPatcher(context.Background(), []jsonPatchModel{{
+		Resources:  types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "apps/v1/*"}}},
+		Names:      types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "cert-manager-*"}}},
+		Operations: types.String{Value: `[{"op": "add", "path": "/patched", "value": true}]`},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]struct {
+		manifest map[any]any
+		patched  bool
+	}{
+		"matching":       {map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"name": "cert-manager-webhook"}}, true},
+		"other resource": {map[any]any{"apiVersion": "v1", "kind": "Service", "metadata": map[any]any{"name": "cert-manager-webhook"}}, false},
+		"other name":     {map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"name": "cert-manager"}}, false},
+	}
+
+	for name, test := range tests {
+		if patched, err := patcher.apply(test.manifest); err != nil || patched != test.patched {
+			t.Errorf("%s: expected patched to be %t, got %t (%v)", name, test.patched, patched, err)
+		}
+	}
+}
+
+func TestParsePatchOperations(t *testing.T) {
+	tests := map[string]string{
+		`{"op": "add"}`:                                                  `the operations must be a list of objects`,
+		`[{"op": "merge", "path": "/spec"}]`:                             `operation 0: invalid op "merge"`,
+		`[{"op": "add", "path": "spec", "value": 1}]`:                    `operation 0: invalid path: "spec" must start with a /`,
+		`[{"op": "replace", "path": "/spec"}]`:                           `operation 0: a value is required for the replace operation`,
+		`[{"op": "remove", "path": "/a"}, {"op": "copy", "path": "/b"}]`: `operation 1: invalid from: must be a string`,
+	}
+
+	for operations, expected := range tests {
+		if _, err := parsePatchOperations(operations); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q, got %v", operations, expected, err)
+		}
+	}
+}
+
+const jsonPatchesStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/workload"
+
+	json_patches {
+		resources  = ["apps/v1/Deployment"]
+		operations = %q
+	}
+}
+`
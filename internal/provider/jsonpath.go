@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// A JSONPath expression in the syntax supported by `kubectl`, such as `{.spec.containers[*].image}`. The braces may be
+// omitted. Parsed expressions keep state while being evaluated, so each concurrent evaluation uses its own copy.
+type jsonPathQuery struct {
+	pool sync.Pool
+}
+
+// Parses the expression into a query, failing if it is malformed. A nil query is returned when the expression is
+// empty.
+func compileJSONPath(expression string) (*jsonPathQuery, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	if !strings.Contains(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+
+	if err := jsonpath.New("query").Parse(expression); err != nil {
+		return nil, err
+	}
+
+	// The expression is known to be valid, so each copy parses without error
+	query := &jsonPathQuery{}
+	query.pool.New = func() any {
+		parsed := jsonpath.New("query").AllowMissingKeys(true)
+		_ = parsed.Parse(expression)
+		return parsed
+	}
+	return query, nil
+}
+
+// Evaluates the query against the manifest, returning every value it matches
+func (q *jsonPathQuery) find(manifest map[any]any) ([]any, error) {
+	parsed := q.pool.Get().(*jsonpath.JSONPath)
+	defer q.pool.Put(parsed)
+
+	results, err := parsed.FindResults(stringKeyed(manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	var values []any
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	return values, nil
+}
+
+// Whether the query matches any value in the manifest. A nil query allows every document.
+func (q *jsonPathQuery) allows(manifest map[any]any) (bool, error) {
+	if q == nil {
+		return true, nil
+	}
+
+	values, err := q.find(manifest)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating jsonpath_filter: %w", err)
+	}
+	return len(values) > 0, nil
+}
+
+// Extracts the values the query matches in the manifest. Strings are extracted as-is, while every other value is
+// encoded as JSON.
+func (q *jsonPathQuery) extract(manifest map[any]any) ([]string, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	values, err := q.find(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating jsonpath_extract: %w", err)
+	}
+
+	extracted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value, ok := value.(string); ok {
+			extracted = append(extracted, value)
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding extracted value: %w", err)
+		}
+		extracted = append(extracted, string(encoded))
+	}
+	return extracted, nil
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_JSONPath(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(jsonPathStatement, server.URL, `.metadata.labels`, `{.metadata.labels.component}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "extracted_values.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "extracted_values.0", "pilot"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "extracted_values.1", "gateway")),
+			},
+			{
+				Config: fmt.Sprintf(jsonPathStatement, server.URL, `.spec`, `.spec`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "extracted_values.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "extracted_values.0", `{"some":"key"}`)),
+			},
+			{
+				Config:      fmt.Sprintf(jsonPathStatement, server.URL, `{.metadata.labels[}`, ``),
+				ExpectError: regexp.MustCompile(`Invalid JSONPath filter`),
+			},
+		},
+	})
+}
+
+func TestJSONPathQuery(t *testing.T) {
+	manifest := map[any]any{
+		"kind": "Deployment",
+		"spec": map[any]any{
+			"replicas": 3,
+			"containers": []any{
+				map[any]any{"name": "app", "image": "example/app:v1"},
+				map[any]any{"name": "proxy", "image": "example/proxy:v2", "ports": []any{8080}},
+			},
+		},
+	}
+
+	tests := map[string][]string{
+		"{.spec.containers[*].image}":                 {"example/app:v1", "example/proxy:v2"},
+		".spec.containers[?(@.name==\"proxy\")].name": {"proxy"},
+		".spec.replicas":                              {"3"},
+		".spec.containers[1].ports":                   {"[8080]"},
+		".spec.missing":                               {},
+	}
+
+	for expression, expected := range tests {
+		query, err := compileJSONPath(expression)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", expression, err)
+		}
+
+		extracted, err := query.extract(manifest)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", expression, err)
+		} else if !reflect.DeepEqual(extracted, expected) {
+			t.Errorf("%s: expected %q, got %q", expression, expected, extracted)
+		}
+
+		allowed, _ := query.allows(manifest)
+		if allowed != (len(expected) > 0) {
+			t.Errorf("%s: expected the manifest to be allowed: %t", expression, len(expected) > 0)
+		}
+	}
+
+	if _, err := compileJSONPath("{.spec.containers[}"); err == nil {
+		t.Error("expected a malformed expression to fail")
+	}
+}
+
+const jsonPathStatement = `
+data "manifest_fetch" "test" {
+	url              = "%s/labeled"
+	jsonpath_filter  = %q
+	jsonpath_extract = %q
+}
+`
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The keys which come first in manifests re-encoded without a source, in order, as they do in the output of `kubectl`
+var leadingKeys = yaml.MapSlice{{Key: "apiVersion"}, {Key: "kind"}, {Key: "metadata"}}
+
+// Encodes the manifest as YAML, keeping its keys in the order of the source document it was decoded from. Keys which
+// aren't in the source, such as those set by a filter, follow in lexical order. Without a source, `apiVersion`,
+// `kind`, and `metadata` come first, followed by the remaining keys in lexical order.
+func marshalOrdered(manifest map[any]any, source []byte) ([]byte, error) {
+	var original yaml.MapSlice
+	if source != nil {
+		// The source was already decoded into the manifest, so it can't fail to decode into the same structure
+		_ = yaml.Unmarshal(source, &original)
+	}
+	if original == nil {
+		original = leadingKeys
+	}
+
+	return yaml.Marshal(orderKeys(manifest, original))
+}
+
+// Orders the keys of the mappings within the value by the order of those in the original value. Mappings without an
+// original are left to the encoder, which orders their keys lexically.
+func orderKeys(value any, original any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		originalMapping, _ := original.(yaml.MapSlice)
+		if len(originalMapping) == 0 {
+			ordered := make(map[any]any, len(value))
+			for key, item := range value {
+				ordered[key] = orderKeys(item, nil)
+			}
+			return ordered
+		}
+
+		ordered := make(yaml.MapSlice, 0, len(value))
+		seen := make(map[any]bool, len(value))
+		for _, item := range originalMapping {
+			if current, ok := value[item.Key]; ok && !seen[item.Key] {
+				ordered = append(ordered, yaml.MapItem{Key: item.Key, Value: orderKeys(current, item.Value)})
+				seen[item.Key] = true
+			}
+		}
+
+		var added []any
+		for key := range value {
+			if !seen[key] {
+				added = append(added, key)
+			}
+		}
+		sort.Slice(added, func(i, j int) bool { return fmt.Sprint(added[i]) < fmt.Sprint(added[j]) })
+		for _, key := range added {
+			ordered = append(ordered, yaml.MapItem{Key: key, Value: orderKeys(value[key], nil)})
+		}
+		return ordered
+
+	case []any:
+		originalItems, _ := original.([]any)
+		ordered := make([]any, len(value))
+		for i, item := range value {
+			ordered[i] = orderKeys(item, originalItem(item, originalItems, i))
+		}
+		return ordered
+
+	default:
+		return value
+	}
+}
+
+// Finds the original of an item of a list. Named items, such as containers, are matched by their name, since items
+// may have been removed or inserted before them, while every other item is matched by its position.
+func originalItem(item any, originals []any, index int) any {
+	if mapping, ok := item.(map[any]any); ok {
+		if name, ok := mapping["name"].(string); ok {
+			for _, original := range originals {
+				if originalMapping, ok := original.(yaml.MapSlice); ok && mappingValue(originalMapping, "name") == name {
+					return original
+				}
+			}
+		}
+	}
+
+	if index < len(originals) {
+		return originals[index]
+	}
+	return nil
+}
+
+// The value of the key within the mapping, if it is present
+func mappingValue(mapping yaml.MapSlice, key any) any {
+	for _, item := range mapping {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_PreservesKeyOrder(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(keyOrderStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "kind: Test\napiVersion: testing.k8s.io/v1\nspec:\n  items:\n  - a\n  - b\n  replicas: 2\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", "apiVersion: testing.k8s.io/v1\nkind: Test\nstatus: hello\nspec:\n  replicas: 2\n"),
+				),
+			},
+		},
+	})
+}
+
+func TestMarshalOrdered(t *testing.T) {
+	source := []byte("kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: app\nspec:\n  template:\n    spec:\n      containers:\n      - name: sidecar\n        image: busybox\n      - name: app\n        image: app\n")
+	manifest := map[any]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[any]any{"name": "app", "labels": map[any]any{"tier": "web", "app": "app"}},
+		"spec": map[any]any{"template": map[any]any{"spec": map[any]any{"containers": []any{
+			map[any]any{"name": "app", "image": "app", "env": []any{map[any]any{"name": "A", "value": "a"}}},
+		}}}},
+	}
+
+	encoded, err := marshalOrdered(manifest, source)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: app\n  labels:\n    app: app\n    tier: web\nspec:\n  template:\n    spec:\n      containers:\n      - name: app\n        image: app\n        env:\n        - name: A\n          value: a\n"
+	if string(encoded) != expected {
+		t.Errorf("expected %q, got %q", expected, encoded)
+	}
+
+	encoded, err = marshalOrdered(map[any]any{"data": map[any]any{"b": "2", "a": "1"}, "metadata": map[any]any{"name": "app"}, "kind": "ConfigMap", "apiVersion": "v1"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  a: \"1\"\n  b: \"2\"\n"
+	if string(encoded) != expected {
+		t.Errorf("expected %q, got %q", expected, encoded)
+	}
+}
+
+const keyOrderStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/formatted"
+	set_attributes = {
+		"spec.replicas" = "2"
+	}
+}
+`
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Bounds the number of outbound requests in flight across every data source and resource
+type concurrencyLimiter struct {
+	slots chan struct{}
+}
+
+func newConcurrencyLimiter(limit int) *concurrencyLimiter {
+	return &concurrencyLimiter{slots: make(chan struct{}, limit)}
+}
+
+// Waits for a slot to become available, returning a function to release it. A nil limiter never waits.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-l.slots }) }, nil
+}
+
+// Holds a slot of the limiter from when the request is sent until its response body is closed
+type limitingTransport struct {
+	limiter *concurrencyLimiter
+	next    http.RoundTripper
+}
+
+func (t *limitingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+	return response, nil
+}
+
+// A response body that releases its slot of the limiter when closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestProvider_MaxConcurrency(t *testing.T) {
+	var active, peak int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt64(&active, 1)
+		defer atomic.AddInt64(&active, -1)
+		for {
+			previous := atomic.LoadInt64(&peak)
+			if current <= previous || atomic.CompareAndSwapInt64(&peak, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(maxConcurrencyResourceStatement, 1, server.URL, server.URL, server.URL, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.first", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.second", "manifests.#", "2"),
+					func(*terraform.State) error {
+						if peak := atomic.LoadInt64(&peak); peak != 1 {
+							return fmt.Errorf("expected at most 1 concurrent request, got %d", peak)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestProvider_InvalidMaxConcurrency(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(maxConcurrencyResourceStatement, 0, "https://a", "https://b", "https://c", "https://d"),
+				ExpectError: regexp.MustCompile(`Invalid\s+max\s+concurrency\s+0,\s+must\s+be\s+at\s+least\s+1`),
+			},
+		},
+	})
+}
+
+const maxConcurrencyResourceStatement = `
+provider "manifest" {
+	max_concurrency = %d
+}
+
+data "manifest_fetch" "first" {
+	urls = ["%s/1", "%s/2"]
+}
+
+data "manifest_fetch" "second" {
+	urls = ["%s/3", "%s/4"]
+}
+`
@@ -0,0 +1,36 @@
+package provider
+
+import "strings"
+
+// The items of the manifest when it is a list, such as the `List` returned by `kubectl get -o yaml` or a typed list
+// like `DeploymentList`, and whether it is one. The items of typed lists usually leave out their `apiVersion` and
+// `kind`, which are taken from the list instead.
+func listItems(manifest map[any]any) ([]map[any]any, bool) {
+	kind := stringValue(manifest["kind"])
+	items, ok := manifest["items"].([]any)
+	if !ok || !strings.HasSuffix(kind, "List") {
+		return nil, false
+	}
+
+	itemKind := strings.TrimSuffix(kind, "List")
+	apiVersion := manifest["apiVersion"]
+
+	var manifests []map[any]any
+	for _, item := range items {
+		item, ok := item.(map[any]any)
+		if !ok {
+			continue
+		}
+
+		if itemKind != "" {
+			if _, ok := item["apiVersion"]; !ok && apiVersion != nil {
+				item["apiVersion"] = apiVersion
+			}
+			if _, ok := item["kind"]; !ok {
+				item["kind"] = itemKind
+			}
+		}
+		manifests = append(manifests, item)
+	}
+	return manifests, true
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_ListItems(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, server.URL, "list"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", bundleServiceAccount),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n")),
+			},
+			{
+				Config: fmt.Sprintf(onlyResourcesPatternStatement, server.URL, "list", "v1/ConfigMap"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n")),
+			},
+		},
+	})
+}
+
+func TestListItems(t *testing.T) {
+	list := map[any]any{
+		"apiVersion": "apps/v1",
+		"kind":       "DeploymentList",
+		"items":      []any{map[any]any{"metadata": map[any]any{"name": "app"}}},
+	}
+	items, ok := listItems(list)
+	if !ok {
+		t.Fatal("expected a typed list to be expanded")
+	}
+	expected := []map[any]any{{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[any]any{"name": "app"}}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %v, got %v", expected, items)
+	}
+
+	if _, ok := listItems(map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "items": []any{}}); ok {
+		t.Error("expected a manifest which isn't a list to be left alone")
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// The operators a field can be compared with
+const (
+	operatorEquals    = "equals"
+	operatorNotEquals = "not_equals"
+	operatorExists    = "exists"
+	operatorRegex     = "regex"
+)
+
+var operators = []string{operatorEquals, operatorNotEquals, operatorExists, operatorRegex}
+
+var matchFieldsBlock = tfsdk.Block{
+	MarkdownDescription: "Only return the manifests where the field at the path matches. When several are set, every one must match.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Attributes: map[string]tfsdk.Attribute{
+		"path": {
+			MarkdownDescription: "The dot-separated path of the field, such as `spec.type`. Elements of lists are selected by their index, such as `spec.ports.0.port`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"operator": {
+			MarkdownDescription: "How the field is compared, either `equals`, `not_equals`, `exists`, or `regex`. A missing field is never equal to the value, but is always not equal to it. Defaults to `equals`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"value": {
+			MarkdownDescription: "The value, or regular expression, the field is compared against. Numbers and booleans are compared by their YAML representation, such as `3` or `true`. Required unless the operator is `exists`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+	},
+}
+
+type matchFieldModel struct {
+	Path     types.String `tfsdk:"path"`
+	Operator types.String `tfsdk:"operator"`
+	Value    types.String `tfsdk:"value"`
+}
+
+// Selects documents by comparing the fields at arbitrary paths, all of which must match
+type fieldSelector struct {
+	rules []fieldRule
+}
+
+type fieldRule struct {
+	path       []string
+	operator   string
+	value      string
+	expression *regexp.Regexp
+}
+
+// Compiles the rules into a selector, failing with the index of the first invalid rule. A nil selector, which allows
+// every document, is returned when there are no rules.
+func compileFieldSelector(models []matchFieldModel) (*fieldSelector, int, error) {
+	if len(models) == 0 {
+		return nil, 0, nil
+	}
+
+	selector := &fieldSelector{}
+	for i, model := range models {
+		rule := fieldRule{path: strings.Split(model.Path.Value, "."), operator: operatorEquals, value: model.Value.Value}
+		if isSet(model.Operator) {
+			rule.operator = model.Operator.Value
+		}
+
+		if !contains(operators, rule.operator) {
+			return nil, i, fmt.Errorf("invalid operator %q, must be one of: %s", rule.operator, strings.Join(operators, ", "))
+		}
+		if rule.operator != operatorExists && (model.Value.Null || model.Value.Unknown) {
+			return nil, i, fmt.Errorf("a value is required for the %s operator", rule.operator)
+		}
+
+		if rule.operator == operatorRegex {
+			expression, err := regexp.Compile(rule.value)
+			if err != nil {
+				return nil, i, fmt.Errorf("invalid regex: %w", err)
+			}
+			rule.expression = expression
+		}
+
+		selector.rules = append(selector.rules, rule)
+	}
+
+	return selector, 0, nil
+}
+
+func (s *fieldSelector) allows(manifest map[any]any) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, rule := range s.rules {
+		if !rule.matches(manifest) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fieldRule) matches(manifest map[any]any) bool {
+	field, present := lookupField(manifest, r.path)
+
+	switch r.operator {
+	case operatorExists:
+		return present
+	case operatorNotEquals:
+		return !present || formatField(field) != r.value
+	case operatorRegex:
+		return present && r.expression.MatchString(formatField(field))
+	default:
+		return present && formatField(field) == r.value
+	}
+}
+
+// Retrieves the field at the path, descending into maps by key and lists by index
+func lookupField(value any, path []string) (any, bool) {
+	for _, segment := range path {
+		switch current := value.(type) {
+		case map[any]any:
+			next, ok := current[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, false
+			}
+			value = current[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// Formats scalar fields as they would appear in YAML, so they can be compared with the configured value
+func formatField(field any) string {
+	switch field := field.(type) {
+	case nil:
+		return "null"
+	case string:
+		return field
+	default:
+		return fmt.Sprint(field)
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_MatchFields(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(matchFieldsStatement, server.URL, `
+	match_fields {
+		path  = "metadata.labels.component"
+		value = "gateway"
+	}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", labeledDocument("gateway"))),
+			},
+			{
+				Config: fmt.Sprintf(matchFieldsStatement, server.URL, `
+	match_fields {
+		path     = "metadata.labels"
+		operator = "exists"
+	}
+	match_fields {
+		path     = "metadata.name"
+		operator = "regex"
+		value    = "^p"
+	}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", labeledDocument("pilot"))),
+			},
+		},
+	})
+}
+
+func TestDataSource_MatchFields_Invalid(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(matchFieldsStatement, "https://example.com", `
+	match_fields {
+		path     = "spec.type"
+		operator = "contains"
+		value    = "Load"
+	}`),
+				ExpectError: regexp.MustCompile(`Invalid field match: invalid operator "contains"`),
+			},
+			{
+				Config: fmt.Sprintf(matchFieldsStatement, "https://example.com", `
+	match_fields {
+		path = "spec.type"
+	}`),
+				ExpectError: regexp.MustCompile(`a value is required for the equals operator`),
+			},
+		},
+	})
+}
+
+func TestFieldSelector(t *testing.T) {
+	manifest := map[any]any{
+		"kind": "Service",
+		"spec": map[any]any{
+			"type":      "LoadBalancer",
+			"ports":     []any{map[any]any{"port": 443}},
+			"clusterIP": nil,
+			"internal":  false,
+		},
+	}
+
+	tests := []struct {
+		path, operator, value string
+		expected              bool
+	}{
+		{"spec.type", operatorEquals, "LoadBalancer", true},
+		{"spec.type", operatorEquals, "ClusterIP", false},
+		{"spec.type", operatorNotEquals, "ClusterIP", true},
+		{"spec.missing", operatorNotEquals, "ClusterIP", true},
+		{"spec.missing", operatorEquals, "ClusterIP", false},
+		{"spec.type", operatorExists, "", true},
+		{"spec.type.name", operatorExists, "", false},
+		{"spec.clusterIP", operatorExists, "", true},
+		{"spec.clusterIP", operatorEquals, "null", true},
+		{"spec.internal", operatorEquals, "false", true},
+		{"spec.ports.0.port", operatorEquals, "443", true},
+		{"spec.ports.1.port", operatorExists, "", false},
+		{"spec.type", operatorRegex, "^Load", true},
+		{"kind", operatorRegex, "^Load", false},
+	}
+
+	for _, test := range tests {
+		model := matchFieldModel{
+			Path:     types.String{Value: test.path},
+			Operator: types.String{Value: test.operator},
+			Value:    types.String{Value: test.value, Null: test.operator == operatorExists},
+		}
+		selector, _, err := compileFieldSelector([]matchFieldModel{model})
+		if err != nil {
+			t.Fatalf("%s %s %s: unexpected error: %s", test.path, test.operator, test.value, err)
+		}
+
+		if actual := selector.allows(manifest); actual != test.expected {
+			t.Errorf("%s %s %s: expected %t, got %t", test.path, test.operator, test.value, test.expected, actual)
+		}
+	}
+
+	_, index, err := compileFieldSelector([]matchFieldModel{
+		{Path: types.String{Value: "kind"}, Value: types.String{Value: "Service"}},
+		{Path: types.String{Value: "kind"}, Operator: types.String{Value: operatorRegex}, Value: types.String{Value: "("}},
+	})
+	if err == nil || index != 1 {
+		t.Errorf("expected the second rule to fail, got %d: %v", index, err)
+	}
+}
+
+const matchFieldsStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/labeled"
+%s
+}
+`
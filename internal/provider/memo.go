@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Shares the responses of identical fetches made while the provider is running, which is for the duration of a
+// single plan or apply. Concurrent fetches of the same request wait for the first one to complete rather than
+// issuing their own.
+type fetchMemo struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+}
+
+type memoEntry struct {
+	done     chan struct{}
+	response *fetchResponse
+	err      error
+	// Whether the fetch was abandoned because its context ended, in which case waiters fetch again with their own
+	retry bool
+}
+
+func newFetchMemo() *fetchMemo {
+	return &fetchMemo{entries: make(map[string]*memoEntry)}
+}
+
+// Returns the memoized response for the key, or calls fetch to produce it within the context. Failures are not
+// memoized so that later fetches may try again. When a fetch fails because its context ended, such as when it lost a
+// hedged race, the error is only returned to its own caller, while those waiting on it fetch again.
+func (m *fetchMemo) do(ctx context.Context, key string, fetch func() (*fetchResponse, error)) (*fetchResponse, error) {
+	if m == nil {
+		return fetch()
+	}
+
+	m.mu.Lock()
+	if entry, ok := m.entries[key]; ok {
+		m.mu.Unlock()
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.retry {
+			return m.do(ctx, key, fetch)
+		}
+		return entry.response, entry.err
+	}
+
+	entry := &memoEntry{done: make(chan struct{})}
+	m.entries[key] = entry
+	m.mu.Unlock()
+
+	entry.response, entry.err = fetch()
+	if entry.err != nil {
+		entry.retry = ctx.Err() != nil
+		m.mu.Lock()
+		delete(m.entries, key)
+		m.mu.Unlock()
+	}
+	close(entry.done)
+
+	return entry.response, entry.err
+}
+
+// Fingerprints the request by its URL and the options controlling how the cache is used, as those may cause the same
+// URL to produce different responses
+func memoKey(ctx context.Context, url string) string {
+	options := cacheOptionsFrom(ctx)
+
+	names := make([]string, 0, len(options.triggers))
+	for name := range options.triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	fmt.Fprintf(&key, "%s\npin=%t\n", url, options.pin)
+	for _, name := range names {
+		fmt.Fprintf(&key, "%q=%q\n", name, options.triggers[name])
+	}
+
+	return key.String()
+}
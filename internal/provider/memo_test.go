@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDataSource_MemoizesIdenticalFetches(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(memoizedResourceStatement, server.URL, server.URL, server.URL, server.URL),
+				Check: func(*terraform.State) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					// Each run of the provider fetches the shared URL once, just like the distinct one
+					if requests["/shared"] != requests["/distinct"] {
+						return fmt.Errorf("expected %d requests for the shared URL, got %d", requests["/distinct"], requests["/shared"])
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestFetchMemo(t *testing.T) {
+	memo := newFetchMemo()
+
+	var calls int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = memo.do(context.Background(), "key", func() (*fetchResponse, error) {
+				atomic.AddInt64(&calls, 1)
+				return &fetchResponse{body: []byte("body")}, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected a single fetch, got %d", calls)
+	}
+
+	failures := 0
+	for i := 0; i < 2; i++ {
+		_, _ = memo.do(context.Background(), "failure", func() (*fetchResponse, error) {
+			failures++
+			return nil, errors.New("failed")
+		})
+	}
+	if failures != 2 {
+		t.Errorf("expected failures to be retried, got %d fetches", failures)
+	}
+}
+
+func TestFetchMemo_CancelledFetch(t *testing.T) {
+	memo := newFetchMemo()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := memo.do(ctx, "key", func() (*fetchResponse, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		cancelled <- err
+	}()
+	<-started
+
+	// The second fetch waits on the first, which is cancelled while it is in flight
+	waiting := make(chan struct{})
+	result := make(chan error, 1)
+	var body []byte
+	go func() {
+		close(waiting)
+		response, err := memo.do(context.Background(), "key", func() (*fetchResponse, error) {
+			return &fetchResponse{body: []byte("body")}, nil
+		})
+		if response != nil {
+			body = response.body
+		}
+		result <- err
+	}()
+	<-waiting
+	// Gives the second fetch time to start waiting on the first
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled fetch to fail with %v, got %v", context.Canceled, err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("expected the waiting fetch to retry, got %v", err)
+	}
+	if string(body) != "body" {
+		t.Errorf("expected %q, got %q", "body", body)
+	}
+}
+
+const memoizedResourceStatement = `
+data "manifest_fetch" "first" {
+	url = "%s/shared"
+}
+
+data "manifest_fetch" "second" {
+	url = "%s/shared"
+}
+
+data "manifest_fetch" "third" {
+	url = "%s/shared"
+}
+
+data "manifest_fetch" "distinct" {
+	url = "%s/distinct"
+}
+`
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The kinds whose names can't be changed, as they're derived from what they define
+var fixedNameKinds = map[string]bool{
+	"APIService":               true,
+	"CustomResourceDefinition": true,
+	"Namespace":                true,
+}
+
+// Adds a prefix and a suffix to the names of the documents, so the same bundle can be installed several times side by
+// side. When renaming references, the references between the documents, such as the service account of a workload,
+// follow the documents they refer to.
+type nameTransformer struct {
+	prefix     string
+	suffix     string
+	references bool
+
+	// The renamed documents by `{kind}/{name}`, collected from every document before any is transformed, as a document
+	// may refer to one which comes after it. Only read once transforming starts.
+	renamed map[string]bool
+}
+
+// Compiles the prefix and suffix into a transformer, failing if the resulting names can't be valid. A nil transformer
+// is returned when both are empty.
+func compileNameTransformer(prefix, suffix string, references bool) (*nameTransformer, error) {
+	if prefix == "" && suffix == "" {
+		return nil, nil
+	}
+	if errs := validation.IsDNS1123Subdomain(prefix + "name" + suffix); len(errs) > 0 {
+		return nil, fmt.Errorf("the prefix %q and suffix %q don't produce valid names: %s", prefix, suffix, strings.Join(errs, ", "))
+	}
+
+	return &nameTransformer{prefix: prefix, suffix: suffix, references: references, renamed: map[string]bool{}}, nil
+}
+
+// Whether the references to other documents are renamed, which requires collecting every document first
+func (t *nameTransformer) renamesReferences() bool {
+	return t != nil && t.references
+}
+
+// Records that the document will be renamed, so references to it are renamed as well
+func (t *nameTransformer) collect(manifest map[any]any) {
+	kind := stringValue(manifest["kind"])
+	metadata, _ := manifest["metadata"].(map[any]any)
+	if name := stringValue(metadata["name"]); name != "" && !fixedNameKinds[kind] {
+		t.renamed[kind+"/"+name] = true
+	}
+}
+
+// Renames the document and its references, returning whether anything changed
+func (t *nameTransformer) apply(manifest map[any]any) bool {
+	if t == nil {
+		return false
+	}
+
+	modified := false
+	if t.references {
+		modified = t.renameReferences(manifest)
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	if name := stringValue(metadata["name"]); name != "" && !fixedNameKinds[stringValue(manifest["kind"])] {
+		metadata["name"] = t.prefix + name + t.suffix
+		modified = true
+	}
+	return modified
+}
+
+// Renames the reference in the field when it refers to a renamed document of the kind
+func (t *nameTransformer) rename(reference map[any]any, field, kind string) bool {
+	name := stringValue(reference[field])
+	if name == "" || !t.renamed[kind+"/"+name] {
+		return false
+	}
+
+	reference[field] = t.prefix + name + t.suffix
+	return true
+}
+
+// Renames the common references to other documents: the roles and service accounts of role bindings, the service
+// accounts, config maps, secrets, and claims used by pods, and the services used by stateful sets, ingresses, webhooks,
+// and API services
+func (t *nameTransformer) renameReferences(manifest map[any]any) bool {
+	modified := false
+	renameAt := func(value map[any]any, field, kind string, keys ...string) {
+		if reference, ok := lookupMap(value, keys...); ok && t.rename(reference, field, kind) {
+			modified = true
+		}
+	}
+
+	switch stringValue(manifest["kind"]) {
+	case "RoleBinding", "ClusterRoleBinding":
+		if roleRef, ok := manifest["roleRef"].(map[any]any); ok {
+			renameAt(roleRef, "name", stringValue(roleRef["kind"]))
+		}
+		eachMap(manifest["subjects"], func(subject map[any]any) {
+			if subject["kind"] == "ServiceAccount" {
+				renameAt(subject, "name", "ServiceAccount")
+			}
+		})
+	case "StatefulSet":
+		renameAt(manifest, "serviceName", "Service", "spec")
+	case "Ingress":
+		renameAt(manifest, "name", "Service", "spec", "defaultBackend", "service")
+		spec, _ := manifest["spec"].(map[any]any)
+		eachMap(spec["rules"], func(rule map[any]any) {
+			http, _ := rule["http"].(map[any]any)
+			eachMap(http["paths"], func(path map[any]any) {
+				renameAt(path, "name", "Service", "backend", "service")
+			})
+		})
+	case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+		eachMap(manifest["webhooks"], func(webhook map[any]any) {
+			renameAt(webhook, "name", "Service", "clientConfig", "service")
+		})
+	case "APIService":
+		renameAt(manifest, "name", "Service", "spec", "service")
+	}
+
+	spec := podSpec(manifest)
+	if spec == nil {
+		return modified
+	}
+
+	renameAt(spec, "serviceAccountName", "ServiceAccount")
+	renameAt(spec, "serviceAccount", "ServiceAccount")
+	eachMap(spec["imagePullSecrets"], func(secret map[any]any) {
+		renameAt(secret, "name", "Secret")
+	})
+	eachMap(spec["volumes"], func(volume map[any]any) {
+		renameAt(volume, "name", "ConfigMap", "configMap")
+		renameAt(volume, "secretName", "Secret", "secret")
+		renameAt(volume, "claimName", "PersistentVolumeClaim", "persistentVolumeClaim")
+	})
+	for _, container := range podContainers(spec) {
+		eachMap(container["env"], func(env map[any]any) {
+			renameAt(env, "name", "ConfigMap", "valueFrom", "configMapKeyRef")
+			renameAt(env, "name", "Secret", "valueFrom", "secretKeyRef")
+		})
+		eachMap(container["envFrom"], func(source map[any]any) {
+			renameAt(source, "name", "ConfigMap", "configMapRef")
+			renameAt(source, "name", "Secret", "secretRef")
+		})
+	}
+	return modified
+}
+
+// Visits every map within the list
+func eachMap(list any, visit func(map[any]any)) {
+	elements, _ := list.([]any)
+	for _, element := range elements {
+		if element, ok := element.(map[any]any); ok {
+			visit(element)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_NameAffixes(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(nameAffixesStatement, server.URL, "team-a-", false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", strings.Replace(bundleBinding, "name: app\nroleRef", "name: team-a-app-v2\nroleRef", 1)),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", strings.Replace(bundleServiceAccount, "name: app", "name: team-a-app-v2", 1))),
+			},
+			{
+				Config: fmt.Sprintf(nameAffixesStatement, server.URL, "team-a-", true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", strings.Replace(strings.Replace(bundleBinding, "name: app\nroleRef", "name: team-a-app-v2\nroleRef", 1), "- kind: ServiceAccount\n  name: app", "- kind: ServiceAccount\n  name: team-a-app-v2", 1)),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(nameAffixesStatement, server.URL, "Team_A", false),
+				ExpectError: regexp.MustCompile(`Invalid name prefix or suffix`),
+			},
+		},
+	})
+}
+
+func TestNameTransformer(t *testing.T) {
+	transformer, err := compileNameTransformer("team-a-", "", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	documents := []map[any]any{
+		{"kind": "ServiceAccount", "metadata": map[any]any{"name": "app"}},
+		{"kind": "ConfigMap", "metadata": map[any]any{"name": "config"}},
+		{"kind": "Service", "metadata": map[any]any{"name": "app"}},
+		{"kind": "CustomResourceDefinition", "metadata": map[any]any{"name": "tests.example.com"}},
+	}
+	for _, document := range documents {
+		transformer.collect(document)
+	}
+
+	tests := map[string]struct {
+		manifest map[any]any
+		expected map[any]any
+	}{
+		"fixed name": {
+			map[any]any{"kind": "CustomResourceDefinition", "metadata": map[any]any{"name": "tests.example.com"}},
+			map[any]any{"kind": "CustomResourceDefinition", "metadata": map[any]any{"name": "tests.example.com"}},
+		},
+		"binding": {
+			map[any]any{"kind": "ClusterRoleBinding", "metadata": map[any]any{"name": "app"}, "roleRef": map[any]any{"kind": "ClusterRole", "name": "view"}, "subjects": []any{
+				map[any]any{"kind": "ServiceAccount", "name": "app"},
+				map[any]any{"kind": "ServiceAccount", "name": "external"},
+			}},
+			map[any]any{"kind": "ClusterRoleBinding", "metadata": map[any]any{"name": "team-a-app"}, "roleRef": map[any]any{"kind": "ClusterRole", "name": "view"}, "subjects": []any{
+				map[any]any{"kind": "ServiceAccount", "name": "team-a-app"},
+				map[any]any{"kind": "ServiceAccount", "name": "external"},
+			}},
+		},
+		"workload": {
+			map[any]any{"kind": "StatefulSet", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{
+				"serviceName": "app",
+				"template": map[any]any{"spec": map[any]any{
+					"serviceAccountName": "app",
+					"volumes":            []any{map[any]any{"name": "config", "configMap": map[any]any{"name": "config"}}},
+					"containers": []any{map[any]any{"envFrom": []any{
+						map[any]any{"configMapRef": map[any]any{"name": "config"}},
+						map[any]any{"secretRef": map[any]any{"name": "config"}},
+					}}},
+				}},
+			}},
+			map[any]any{"kind": "StatefulSet", "metadata": map[any]any{"name": "team-a-app"}, "spec": map[any]any{
+				"serviceName": "team-a-app",
+				"template": map[any]any{"spec": map[any]any{
+					"serviceAccountName": "team-a-app",
+					"volumes":            []any{map[any]any{"name": "config", "configMap": map[any]any{"name": "team-a-config"}}},
+					"containers": []any{map[any]any{"envFrom": []any{
+						map[any]any{"configMapRef": map[any]any{"name": "team-a-config"}},
+						map[any]any{"secretRef": map[any]any{"name": "config"}},
+					}}},
+				}},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		transformer.apply(test.manifest)
+		if !reflect.DeepEqual(test.manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, test.manifest)
+		}
+	}
+}
+
+func TestCompileNameTransformer(t *testing.T) {
+	if transformer, err := compileNameTransformer("", "", true); err != nil || transformer != nil {
+		t.Errorf("expected no transformer, got %v (%v)", transformer, err)
+	}
+	if _, err := compileNameTransformer("", "-", false); err == nil {
+		t.Error("expected a suffix producing invalid names to fail")
+	}
+}
+
+const nameAffixesStatement = `
+data "manifest_fetch" "test" {
+	url               = "%s/bundle"
+	name_prefix       = %q
+	name_suffix       = "-v2"
+	rename_references = %t
+}
+`
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The built-in resources which aren't namespaced, keyed by `{group}/{kind}`, where the group of the core resources is
+// empty. Custom resources may reuse the kinds of built-in ones, so the group is needed to tell them apart.
+var clusterScopedKinds = map[string]bool{
+	"/ComponentStatus":  true,
+	"/Namespace":        true,
+	"/Node":             true,
+	"/PersistentVolume": true,
+	"admissionregistration.k8s.io/MutatingWebhookConfiguration":     true,
+	"admissionregistration.k8s.io/ValidatingAdmissionPolicy":        true,
+	"admissionregistration.k8s.io/ValidatingAdmissionPolicyBinding": true,
+	"admissionregistration.k8s.io/ValidatingWebhookConfiguration":   true,
+	"apiextensions.k8s.io/CustomResourceDefinition":                 true,
+	"apiregistration.k8s.io/APIService":                             true,
+	"certificates.k8s.io/CertificateSigningRequest":                 true,
+	"extensions/PodSecurityPolicy":                                  true,
+	"flowcontrol.apiserver.k8s.io/FlowSchema":                       true,
+	"flowcontrol.apiserver.k8s.io/PriorityLevelConfiguration":       true,
+	"networking.k8s.io/IngressClass":                                true,
+	"node.k8s.io/RuntimeClass":                                      true,
+	"policy/PodSecurityPolicy":                                      true,
+	"rbac.authorization.k8s.io/ClusterRole":                         true,
+	"rbac.authorization.k8s.io/ClusterRoleBinding":                  true,
+	"scheduling.k8s.io/PriorityClass":                               true,
+	"storage.k8s.io/CSIDriver":                                      true,
+	"storage.k8s.io/CSINode":                                        true,
+	"storage.k8s.io/StorageClass":                                   true,
+	"storage.k8s.io/VolumeAttachment":                               true,
+}
+
+// Moves the namespaced documents into a namespace, as the namespace transformer of kustomize does. Besides the
+// `metadata.namespace`, the namespace of the service accounts bound by role bindings is moved along with them when it
+// is unset or `default`. When only filling in missing namespaces, documents and service accounts which already have a
+// namespace are left as they are.
+type namespaceTransformer struct {
+	namespace   string
+	onlyMissing bool
+	// The custom resources which aren't namespaced, in addition to the built-in ones
+	clusterScoped *resourceFilter
+}
+
+// Compiles the namespace into a transformer, failing if it isn't a valid namespace. A nil transformer is returned when
+// the namespace is empty.
+func compileNamespaceTransformer(namespace string, onlyMissing bool, clusterScoped *resourceFilter) (*namespaceTransformer, error) {
+	if namespace == "" {
+		return nil, nil
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return nil, fmt.Errorf("%q is not a valid namespace: %s", namespace, strings.Join(errs, ", "))
+	}
+
+	return &namespaceTransformer{namespace: namespace, onlyMissing: onlyMissing, clusterScoped: clusterScoped}, nil
+}
+
+// Whether the manifest is of a resource which isn't namespaced
+func (t *namespaceTransformer) isClusterScoped(manifest map[any]any) bool {
+	group, _, found := strings.Cut(stringValue(manifest["apiVersion"]), "/")
+	if !found {
+		group = ""
+	}
+	if clusterScopedKinds[group+"/"+stringValue(manifest["kind"])] {
+		return true
+	}
+	return t.clusterScoped != nil && t.clusterScoped.allows(manifest)
+}
+
+// Moves the manifest into the namespace, returning whether anything changed
+func (t *namespaceTransformer) apply(manifest map[any]any) bool {
+	if t == nil {
+		return false
+	}
+
+	modified := t.moveSubjects(manifest)
+	if t.isClusterScoped(manifest) {
+		return modified
+	}
+
+	metadata, ok := manifest["metadata"].(map[any]any)
+	if !ok {
+		if manifest["metadata"] != nil {
+			return modified
+		}
+		metadata = map[any]any{}
+		manifest["metadata"] = metadata
+	}
+
+	if current, _ := metadata["namespace"].(string); t.onlyMissing && current != "" {
+		return modified
+	}
+	if metadata["namespace"] != t.namespace {
+		metadata["namespace"] = t.namespace
+		modified = true
+	}
+	return modified
+}
+
+// Moves the service accounts bound by role bindings which are in the default namespace, or have no namespace at all
+func (t *namespaceTransformer) moveSubjects(manifest map[any]any) bool {
+	if kind := manifest["kind"]; kind != "RoleBinding" && kind != "ClusterRoleBinding" {
+		return false
+	}
+	subjects, ok := manifest["subjects"].([]any)
+	if !ok {
+		return false
+	}
+
+	modified := false
+	for _, subject := range subjects {
+		subject, ok := subject.(map[any]any)
+		if !ok || subject["kind"] != "ServiceAccount" {
+			continue
+		}
+
+		namespace, _ := subject["namespace"].(string)
+		if t.onlyMissing && namespace != "" {
+			continue
+		}
+		if (namespace == "" || namespace == "default") && namespace != t.namespace {
+			subject["namespace"] = t.namespace
+			modified = true
+		}
+	}
+	return modified
+}
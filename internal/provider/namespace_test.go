@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Namespace(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(namespaceStatement, server.URL, "platform"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "4"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namespacedDocument("platform")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", namespacedDocument("")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.3", namespacedDocument("platform"))),
+			},
+			{
+				Config: fmt.Sprintf(defaultNamespaceStatement, server.URL, "platform"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cert-manager\n  namespace: platform\n")),
+			},
+			{
+				Config:      fmt.Sprintf(namespaceStatement, server.URL, "Platform_Team"),
+				ExpectError: regexp.MustCompile(`"Platform_Team" is not a valid namespace`),
+			},
+		},
+	})
+}
+
+func TestNamespaceTransformer(t *testing.T) {
+	clusterScoped, _ := compileResourceFilter([]string{"cert-manager.io/*/ClusterIssuer"})
+	transformer, err := compileNamespaceTransformer("platform", false, clusterScoped)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]struct {
+		manifest map[any]any
+		modified bool
+		expected map[any]any
+	}{
+		"namespaced": {
+			map[any]any{"kind": "Service", "metadata": map[any]any{"name": "app", "namespace": "default"}},
+			true,
+			map[any]any{"kind": "Service", "metadata": map[any]any{"name": "app", "namespace": "platform"}},
+		},
+		"missing metadata": {
+			map[any]any{"kind": "ConfigMap"},
+			true,
+			map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"namespace": "platform"}},
+		},
+		"already moved": {
+			map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"namespace": "platform"}},
+			false,
+			map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"namespace": "platform"}},
+		},
+		"built-in cluster-scoped": {
+			map[any]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[any]any{"name": "app"}},
+			false,
+			map[any]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[any]any{"name": "app"}},
+		},
+		"custom namespaced with a built-in kind": {
+			map[any]any{"apiVersion": "example.com/v1", "kind": "Node", "metadata": map[any]any{"name": "worker"}},
+			true,
+			map[any]any{"apiVersion": "example.com/v1", "kind": "Node", "metadata": map[any]any{"name": "worker", "namespace": "platform"}},
+		},
+		"custom cluster-scoped": {
+			map[any]any{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": map[any]any{"name": "ca"}},
+			false,
+			map[any]any{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": map[any]any{"name": "ca"}},
+		},
+		"binding subjects": {
+			map[any]any{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRoleBinding", "subjects": []any{
+				map[any]any{"kind": "ServiceAccount", "name": "app", "namespace": "default"},
+				map[any]any{"kind": "ServiceAccount", "name": "monitoring", "namespace": "monitoring"},
+				map[any]any{"kind": "Group", "name": "admins"},
+			}},
+			true,
+			map[any]any{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRoleBinding", "subjects": []any{
+				map[any]any{"kind": "ServiceAccount", "name": "app", "namespace": "platform"},
+				map[any]any{"kind": "ServiceAccount", "name": "monitoring", "namespace": "monitoring"},
+				map[any]any{"kind": "Group", "name": "admins"},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		modified := transformer.apply(test.manifest)
+		if modified != test.modified {
+			t.Errorf("%s: expected modified to be %t, got %t", name, test.modified, modified)
+		}
+		if !reflect.DeepEqual(test.manifest, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, test.manifest)
+		}
+	}
+}
+
+func TestNamespaceTransformer_OnlyMissing(t *testing.T) {
+	transformer, _ := compileNamespaceTransformer("platform", true, nil)
+
+	manifest := map[any]any{"kind": "RoleBinding", "metadata": map[any]any{"name": "app"}, "subjects": []any{
+		map[any]any{"kind": "ServiceAccount", "name": "app"},
+		map[any]any{"kind": "ServiceAccount", "name": "default", "namespace": "default"},
+	}}
+	if !transformer.apply(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+
+	expected := map[any]any{"kind": "RoleBinding", "metadata": map[any]any{"name": "app", "namespace": "platform"}, "subjects": []any{
+		map[any]any{"kind": "ServiceAccount", "name": "app", "namespace": "platform"},
+		map[any]any{"kind": "ServiceAccount", "name": "default", "namespace": "default"},
+	}}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+
+	declared := map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"namespace": "team-a"}}
+	if transformer.apply(declared) || declared["metadata"].(map[any]any)["namespace"] != "team-a" {
+		t.Errorf("expected the declared namespace to be kept, got %v", declared)
+	}
+}
+
+const namespaceStatement = `
+data "manifest_fetch" "test" {
+	url       = "%s/namespaced"
+	namespace = %q
+}
+`
+
+const defaultNamespaceStatement = `
+data "manifest_fetch" "test" {
+	url               = "%s/named"
+	name_selector     = ["cert-manager"]
+	default_namespace = %q
+}
+`
@@ -0,0 +1,116 @@
+package provider
+
+import "sort"
+
+const (
+	orderDocument   = "document"
+	orderDependency = "dependency"
+)
+
+var orders = []string{orderDocument, orderDependency}
+
+// The fields the manifests can be sorted by
+var sortFields = []string{"kind", "name", "namespace"}
+
+// The kinds in the order they're applied in when ordering by dependency, so each comes after those it depends on.
+// Namespaces and the definitions of custom resources come first, as anything may be created within them, and the
+// webhook configurations come last, as they would otherwise intercept the creation of the services backing them.
+var dependencyOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"PriorityClass",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+// The kinds which come after every other kind, including custom resources, when ordering by dependency
+var dependencyOrderLast = []string{
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// A decoded manifest along with the index of the source it was decoded from and, once read from the spool, its content
+type orderedManifest struct {
+	source  int
+	decoded decodedManifest
+	content string
+}
+
+// Sorts the manifests in place by the order, then by each of the fields in turn, keeping the order of the documents
+// between those which are equal
+func sortManifests(manifests []orderedManifest, order string, fields []string) {
+	if order != orderDependency && len(fields) == 0 {
+		return
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		a, b := manifests[i].decoded, manifests[j].decoded
+		if order == orderDependency {
+			if rankA, rankB := dependencyRank(a.kind), dependencyRank(b.kind); rankA != rankB {
+				return rankA < rankB
+			}
+		}
+
+		for _, field := range fields {
+			if valueA, valueB := a.sortValue(field), b.sortValue(field); valueA != valueB {
+				return valueA < valueB
+			}
+		}
+		return false
+	})
+}
+
+// The value of the field the manifest is sorted by
+func (m decodedManifest) sortValue(field string) string {
+	switch field {
+	case "kind":
+		return m.kind
+	case "name":
+		return m.name
+	case "namespace":
+		return m.namespace
+	}
+	return ""
+}
+
+// The position of the kind when ordering by dependency. Kinds which aren't known, such as custom resources, come after
+// the known kinds, but before the webhook configurations.
+func dependencyRank(kind string) int {
+	for i, known := range dependencyOrder {
+		if kind == known {
+			return i
+		}
+	}
+	for i, last := range dependencyOrderLast {
+		if kind == last {
+			return len(dependencyOrder) + 1 + i
+		}
+	}
+	return len(dependencyOrder)
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Order(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(orderStatement, server.URL, "dependency"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", bundleServiceAccount),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", bundleBinding)),
+			},
+			{
+				Config: fmt.Sprintf(orderStatement, server.URL, "document"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", bundleBinding),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", bundleServiceAccount)),
+			},
+			{
+				Config:      fmt.Sprintf(orderStatement, server.URL, "random"),
+				ExpectError: regexp.MustCompile(`Invalid order "random"`),
+			},
+		},
+	})
+}
+
+func TestDataSource_SortBy(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(sortByStatement, server.URL, "name"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-cainjector")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", namedDocument("cert-manager-webhook"))),
+			},
+			{
+				Config:      fmt.Sprintf(sortByStatement, server.URL, "labels"),
+				ExpectError: regexp.MustCompile(`Invalid sort field "labels"`),
+			},
+		},
+	})
+}
+
+func TestSortManifests(t *testing.T) {
+	var manifests []orderedManifest
+	for i, kind := range []string{"ValidatingWebhookConfiguration", "Certificate", "Deployment", "Issuer", "Namespace", "CustomResourceDefinition", "Service"} {
+		manifests = append(manifests, orderedManifest{source: i, decoded: decodedManifest{kind: kind}})
+	}
+
+	sortManifests(manifests, orderDependency, nil)
+
+	var kinds []string
+	for _, manifest := range manifests {
+		kinds = append(kinds, manifest.decoded.kind)
+	}
+	expected := []string{"Namespace", "CustomResourceDefinition", "Service", "Deployment", "Certificate", "Issuer", "ValidatingWebhookConfiguration"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected %v, got %v", expected, kinds)
+	}
+}
+
+func TestSortManifests_Fields(t *testing.T) {
+	manifests := []orderedManifest{
+		{source: 0, decoded: decodedManifest{kind: "Service", namespace: "b", name: "app"}},
+		{source: 1, decoded: decodedManifest{kind: "Deployment", namespace: "a", name: "app"}},
+		{source: 2, decoded: decodedManifest{kind: "Service", namespace: "a", name: "web"}},
+		{source: 3, decoded: decodedManifest{kind: "Service", namespace: "a", name: "app"}},
+	}
+
+	sortManifests(manifests, orderDependency, []string{"name", "namespace"})
+
+	var sources []int
+	for _, manifest := range manifests {
+		sources = append(sources, manifest.source)
+	}
+	if expected := []int{3, 0, 2, 1}; !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expected %v, got %v", expected, sources)
+	}
+}
+
+const orderStatement = `
+data "manifest_fetch" "test" {
+	url   = "%s/bundle"
+	order = %q
+}
+`
+
+const sortByStatement = `
+data "manifest_fetch" "test" {
+	url     = "%s/named"
+	sort_by = [%q]
+}
+`
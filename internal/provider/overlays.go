@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Deeply merges local manifests on top of the fetched manifests with the same resource type and name
+type overlayMerger struct {
+	overlays []*overlay
+}
+
+type overlay struct {
+	apiVersion string
+	kind       string
+	name       string
+	// Only set when the overlay has a namespace, otherwise it matches manifests in every namespace
+	namespace string
+	patch     map[any]any
+
+	// Whether the overlay was merged into any manifest
+	matched atomic.Bool
+}
+
+// Compiles the overlays, each of which is either YAML or JSON content or the path to a file containing it, failing
+// with the index of the first invalid entry. A nil merger is returned when there are no overlays.
+func compileOverlayMerger(entries []string) (*overlayMerger, int, error) {
+	if len(entries) == 0 {
+		return nil, 0, nil
+	}
+
+	merger := &overlayMerger{}
+	for i, entry := range entries {
+		content := []byte(entry)
+		if isOverlayFile(entry) {
+			var err error
+			if content, err = os.ReadFile(strings.TrimSpace(entry)); err != nil {
+				return nil, i, err
+			}
+		}
+
+		content, err := normalizeText(content)
+		if err != nil {
+			return nil, i, err
+		}
+
+		decoded := 0
+		err = unmarshalAllManifests(content, formatAuto, nil, func(manifest map[any]any, _ []byte) error {
+			overlay, err := newOverlay(manifest)
+			if err != nil {
+				return err
+			}
+
+			merger.overlays = append(merger.overlays, overlay)
+			decoded++
+			return nil
+		})
+		if err != nil {
+			return nil, i, err
+		}
+		if decoded == 0 {
+			return nil, i, fmt.Errorf("no manifests were found")
+		}
+	}
+	return merger, 0, nil
+}
+
+// Whether the entry is the path to a file rather than the content of an overlay
+func isOverlayFile(entry string) bool {
+	if strings.ContainsAny(entry, "\n{") || strings.Contains(entry, ": ") {
+		return false
+	}
+	return hasManifestExtension(strings.TrimSpace(entry))
+}
+
+// Creates an overlay from the manifest, which must identify the manifests it applies to
+func newOverlay(manifest map[any]any) (*overlay, error) {
+	metadata, _ := manifest["metadata"].(map[any]any)
+	overlay := &overlay{
+		apiVersion: stringValue(manifest["apiVersion"]),
+		kind:       stringValue(manifest["kind"]),
+		name:       stringValue(metadata["name"]),
+		namespace:  stringValue(metadata["namespace"]),
+		patch:      manifest,
+	}
+
+	if overlay.apiVersion == "" || overlay.kind == "" || overlay.name == "" {
+		return nil, fmt.Errorf("every overlay must have an apiVersion, kind, and metadata.name")
+	}
+	return overlay, nil
+}
+
+// Whether the overlay applies to the manifest
+func (o *overlay) matches(manifest map[any]any) bool {
+	metadata, _ := manifest["metadata"].(map[any]any)
+	if o.namespace != "" && stringValue(metadata["namespace"]) != o.namespace {
+		return false
+	}
+	return stringValue(manifest["apiVersion"]) == o.apiVersion && stringValue(manifest["kind"]) == o.kind && stringValue(metadata["name"]) == o.name
+}
+
+// Merges the matching overlays into the manifest, returning whether any matched
+func (m *overlayMerger) apply(manifest map[any]any) bool {
+	if m == nil {
+		return false
+	}
+
+	modified := false
+	for _, overlay := range m.overlays {
+		if !overlay.matches(manifest) {
+			continue
+		}
+
+		mergePatch(manifest, overlay.patch)
+		overlay.matched.Store(true)
+		modified = true
+	}
+	return modified
+}
+
+// The overlays which weren't merged into any manifest, described by their resource type and name
+func (m *overlayMerger) unmatched() []string {
+	if m == nil {
+		return nil
+	}
+
+	var unmatched []string
+	for _, overlay := range m.overlays {
+		if !overlay.matched.Load() {
+			unmatched = append(unmatched, fmt.Sprintf("%s/%s %s", overlay.apiVersion, overlay.kind, overlay.name))
+		}
+	}
+	return unmatched
+}
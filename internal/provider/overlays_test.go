@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Overlays(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "webhook.yaml")
+	if err := os.WriteFile(file, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cert-manager-webhook\nspec:\n  replicas: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(overlaysStatement, server.URL, "{apiVersion: apps/v1, kind: Deployment, metadata: {name: cert-manager, labels: {tier: control}}}", file),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cert-manager\n  labels:\n    tier: control\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-webhook")+"spec:\n  replicas: 2\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", namedDocument("cert-manager-cainjector"))),
+			},
+			{
+				Config:      fmt.Sprintf(overlaysStatement, server.URL, "{kind: Deployment}", file),
+				ExpectError: regexp.MustCompile(`every overlay must have an apiVersion, kind, and metadata.name`),
+			},
+		},
+	})
+}
+
+func TestOverlayMerger(t *testing.T) {
+	merger, _, err := compileOverlayMerger([]string{
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  level: debug\n  removed: null\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: token\n  namespace: team-a\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	manifest := map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{"name": "config", "namespace": "default"}, "data": map[any]any{"level": "info", "removed": "yes", "kept": "yes"}}
+	if !merger.apply(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+	expected := map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{"name": "config", "namespace": "default"}, "data": map[any]any{"level": "debug", "kept": "yes"}}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+
+	other := map[any]any{"apiVersion": "v1", "kind": "Secret", "metadata": map[any]any{"name": "token", "namespace": "team-b"}}
+	if merger.apply(other) {
+		t.Error("expected an overlay with a namespace to only match manifests in it")
+	}
+	if unmatched := merger.unmatched(); !reflect.DeepEqual(unmatched, []string{"v1/Secret token"}) {
+		t.Errorf("expected the secret overlay to be unmatched, got %v", unmatched)
+	}
+}
+
+func TestCompileOverlayMerger(t *testing.T) {
+	tests := map[string]struct {
+		entries []string
+		index   int
+		err     string
+	}{
+		"missing file": {[]string{"{apiVersion: v1, kind: ConfigMap, metadata: {name: a}}", "missing.yaml"}, 1, "no such file"},
+		"empty":        {[]string{"# nothing\n"}, 0, "no manifests were found"},
+		"invalid YAML": {[]string{"apiVersion: v1\n  kind: ["}, 0, "yaml"},
+		"missing name": {[]string{"apiVersion: v1\nkind: ConfigMap\n"}, 0, "metadata.name"},
+	}
+
+	for name, test := range tests {
+		_, index, err := compileOverlayMerger(test.entries)
+		if err == nil || !strings.Contains(err.Error(), test.err) || index != test.index {
+			t.Errorf("%s: expected error containing %q at %d, got %v at %d", name, test.err, test.index, err, index)
+		}
+	}
+}
+
+const overlaysStatement = `
+data "manifest_fetch" "test" {
+	url      = "%s/named"
+	overlays = [%q, %q]
+}
+`
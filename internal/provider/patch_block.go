@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+var patchBlock = tfsdk.Block{
+	MarkdownDescription: "Patch the manifests matching the target, as the patches of kustomize do. Each patch is either a JSON patch, using `operations`, or a JSON merge patch, using `merge`. Patches are applied in order, after `json_patches`, and the fetch fails if a patch can't be applied to a manifest it targets.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Attributes: map[string]tfsdk.Attribute{
+		"operations": {
+			MarkdownDescription: "The operations of a JSON patch as a JSON or YAML list, using the same syntax as `json_patches`. Conflicts with `merge`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"merge": {
+			MarkdownDescription: "A JSON merge patch, as defined by RFC 7386, as a JSON or YAML object, such as `{spec: {replicas: 3}}`. Maps are merged recursively, `null` values remove the attribute, and lists are replaced as a whole. Conflicts with `operations`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+	},
+	Blocks: map[string]tfsdk.Block{
+		"target": {
+			MarkdownDescription: "Which manifests are patched. Every field is a regular expression which must match the whole value, such as `cert-manager-.*`, and every field which is set must match. Every manifest is patched when unset.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			MaxItems:            1,
+			Attributes:          withTargetAttributes(map[string]tfsdk.Attribute{}),
+		},
+	},
+}
+
+// Adds the attributes selecting the manifests by their resource type, name, namespace, and labels, as the targets of
+// kustomize do, to the attributes of a block
+func withTargetAttributes(attributes map[string]tfsdk.Attribute) map[string]tfsdk.Attribute {
+	for name, attribute := range map[string]tfsdk.Attribute{
+		"group": {
+			MarkdownDescription: "The API group of the `apiVersion`, such as `apps`. Core resources, such as `ConfigMap`, have an empty group, so `|apps` matches both them and the `apps` group.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"version": {
+			MarkdownDescription: "The version of the `apiVersion`, such as `v1`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"kind": {
+			MarkdownDescription: "The `kind`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"name": {
+			MarkdownDescription: "The `metadata.name`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"namespace": {
+			MarkdownDescription: "The `metadata.namespace`. Manifests without a namespace have an empty one.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"label_selector": {
+			MarkdownDescription: "The Kubernetes label selector the `metadata.labels` must match, such as `app.kubernetes.io/component=webhook`. Unlike the other fields, this isn't a regular expression.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+	} {
+		attributes[name] = attribute
+	}
+	return attributes
+}
+
+type patchModel struct {
+	Target     []patchTargetModel `tfsdk:"target"`
+	Operations types.String       `tfsdk:"operations"`
+	Merge      types.String       `tfsdk:"merge"`
+}
+
+type patchTargetModel struct {
+	Group         types.String `tfsdk:"group"`
+	Version       types.String `tfsdk:"version"`
+	Kind          types.String `tfsdk:"kind"`
+	Name          types.String `tfsdk:"name"`
+	Namespace     types.String `tfsdk:"namespace"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+}
+
+// Selects the documents a patch applies to, as the targets of kustomize's patches do. Each field is an anchored regular
+// expression, or nil when it isn't set.
+type patchTarget struct {
+	group     *regexp.Regexp
+	version   *regexp.Regexp
+	kind      *regexp.Regexp
+	name      *regexp.Regexp
+	namespace *regexp.Regexp
+	labels    *labelSelector
+}
+
+// Compiles the patch block, failing with the path of the invalid attribute
+func compilePatch(model patchModel, at path.Path) (jsonPatch, path.Path, error) {
+	var patch jsonPatch
+
+	if isSet(model.Operations) == isSet(model.Merge) {
+		return patch, at, fmt.Errorf("exactly one of operations or merge must be set")
+	}
+	if isSet(model.Operations) {
+		operations, err := parsePatchOperations(model.Operations.Value)
+		if err != nil {
+			return patch, at.AtName("operations"), err
+		}
+		patch.operations = operations
+	} else {
+		if err := yaml.Unmarshal([]byte(model.Merge.Value), &patch.merge); err != nil || patch.merge == nil {
+			return patch, at.AtName("merge"), fmt.Errorf("the merge patch must be an object")
+		}
+	}
+
+	if len(model.Target) > 0 {
+		target, field, err := compilePatchTarget(model.Target[0])
+		if err != nil {
+			return patch, at.AtName("target").AtListIndex(0).AtName(field), err
+		}
+		patch.target = target
+	}
+	return patch, at, nil
+}
+
+// Compiles the target, failing with the name of the invalid field
+func compilePatchTarget(model patchTargetModel) (*patchTarget, string, error) {
+	target := &patchTarget{}
+	for field, value := range map[string]struct {
+		model  types.String
+		target **regexp.Regexp
+	}{
+		"group":     {model.Group, &target.group},
+		"version":   {model.Version, &target.version},
+		"kind":      {model.Kind, &target.kind},
+		"name":      {model.Name, &target.name},
+		"namespace": {model.Namespace, &target.namespace},
+	} {
+		if !isSet(value.model) {
+			continue
+		}
+
+		expression, err := regexp.Compile("^(?:" + value.model.Value + ")$")
+		if err != nil {
+			return nil, field, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		*value.target = expression
+	}
+
+	labels, err := compileLabelSelector(model.LabelSelector.Value)
+	if err != nil {
+		return nil, "label_selector", fmt.Errorf("invalid label selector: %w", err)
+	}
+	target.labels = labels
+
+	return target, "", nil
+}
+
+// Whether the manifest matches every field of the target
+func (t *patchTarget) allows(manifest map[any]any) bool {
+	if t == nil {
+		return true
+	}
+
+	group, version, found := strings.Cut(stringValue(manifest["apiVersion"]), "/")
+	if !found {
+		group, version = "", group
+	}
+	metadata, _ := manifest["metadata"].(map[any]any)
+
+	for _, field := range []struct {
+		expression *regexp.Regexp
+		value      string
+	}{
+		{t.group, group},
+		{t.version, version},
+		{t.kind, stringValue(manifest["kind"])},
+		{t.name, stringValue(metadata["name"])},
+		{t.namespace, stringValue(metadata["namespace"])},
+	} {
+		if field.expression != nil && !field.expression.MatchString(field.value) {
+			return false
+		}
+	}
+	return t.labels.allows(manifest)
+}
+
+// Applies the JSON merge patch to the value, returning the result. Maps in the value are modified in place.
+func mergePatch(value any, patch map[any]any) any {
+	merged, ok := value.(map[any]any)
+	if !ok {
+		merged = map[any]any{}
+	}
+
+	for key, patched := range patch {
+		switch patched := patched.(type) {
+		case nil:
+			delete(merged, key)
+		case map[any]any:
+			merged[key] = mergePatch(merged[key], patched)
+		default:
+			merged[key] = copyValue(patched)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Patch(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(patchStatement, server.URL, "cert-manager-.*"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-webhook")+"spec:\n  replicas: 3\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", namedDocument("cert-manager-cainjector")+"spec:\n  replicas: 3\n")),
+			},
+			{
+				Config:      fmt.Sprintf(patchStatement, server.URL, "cert-manager-(.*"),
+				ExpectError: regexp.MustCompile(`invalid regular expression`),
+			},
+		},
+	})
+}
+
+func TestPatchTarget(t *testing.T) {
+	target, _, err := compilePatchTarget(patchTargetModel{
+		Group:         types.String{Value: "|rbac.authorization.k8s.io"},
+		Version:       types.String{Null: true},
+		Kind:          types.String{Value: "ConfigMap|Secret"},
+		Name:          types.String{Value: "app-.*"},
+		Namespace:     types.String{Null: true},
+		LabelSelector: types.String{Value: "tier=backend"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	labels := map[any]any{"tier": "backend"}
+	tests := map[string]struct {
+		manifest map[any]any
+		allowed  bool
+	}{
+		"matching":        {map[any]any{"apiVersion": "v1", "kind": "Secret", "metadata": map[any]any{"name": "app-token", "labels": labels}}, true},
+		"other group":     {map[any]any{"apiVersion": "example.com/v1", "kind": "Secret", "metadata": map[any]any{"name": "app-token", "labels": labels}}, false},
+		"partial kind":    {map[any]any{"apiVersion": "v1", "kind": "ConfigMapList", "metadata": map[any]any{"name": "app-config", "labels": labels}}, false},
+		"other name":      {map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{"name": "web-config", "labels": labels}}, false},
+		"missing labels":  {map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[any]any{"name": "app-config"}}, false},
+		"missing version": {map[any]any{"kind": "ConfigMap", "metadata": map[any]any{"name": "app-config", "labels": labels}}, true},
+	}
+
+	for name, test := range tests {
+		if allowed := target.allows(test.manifest); allowed != test.allowed {
+			t.Errorf("%s: expected allowed to be %t, got %t", name, test.allowed, allowed)
+		}
+	}
+}
+
+func TestCompilePatch(t *testing.T) {
+	at := path.Root("patch").AtListIndex(0)
+	tests := map[string]struct {
+		model    patchModel
+		expected path.Path
+		err      string
+	}{
+		"neither": {
+			patchModel{Operations: types.String{Null: true}, Merge: types.String{Null: true}},
+			at,
+			"exactly one of operations or merge must be set",
+		},
+		"both": {
+			patchModel{Operations: types.String{Value: "[]"}, Merge: types.String{Value: "{}"}},
+			at,
+			"exactly one of operations or merge must be set",
+		},
+		"list merge patch": {
+			patchModel{Operations: types.String{Null: true}, Merge: types.String{Value: "[1]"}},
+			at.AtName("merge"),
+			"the merge patch must be an object",
+		},
+		"invalid target": {
+			patchModel{Operations: types.String{Null: true}, Merge: types.String{Value: "{}"}, Target: []patchTargetModel{{LabelSelector: types.String{Value: "a in"}}}},
+			at.AtName("target").AtListIndex(0).AtName("label_selector"),
+			"invalid label selector",
+		},
+	}
+
+	for name, test := range tests {
+		_, errPath, err := compilePatch(test.model, at)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %v", name, test.err, err)
+		}
+		if !errPath.Equal(test.expected) {
+			t.Errorf("%s: expected the error at %s, got %s", name, test.expected, errPath)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	value := map[any]any{"metadata": map[any]any{"name": "app", "labels": map[any]any{"tier": "backend"}}, "spec": map[any]any{"args": []any{"a", "b"}}}
+	patch := map[any]any{"metadata": map[any]any{"labels": map[any]any{"tier": nil, "team": "platform"}}, "spec": map[any]any{"args": []any{"c"}, "template": map[any]any{"spec": map[any]any{}}}}
+
+	expected := map[any]any{"metadata": map[any]any{"name": "app", "labels": map[any]any{"team": "platform"}}, "spec": map[any]any{"args": []any{"c"}, "template": map[any]any{"spec": map[any]any{}}}}
+	if merged := mergePatch(value, patch); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
+
+const patchStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/named"
+
+	patch {
+		merge = "{spec: {replicas: 3}}"
+
+		target {
+			group = "apps"
+			kind  = "Deployment"
+			name  = %q
+		}
+	}
+}
+`
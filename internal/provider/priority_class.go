@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var priorityClassTargetBlock = tfsdk.Block{
+	MarkdownDescription: "Which workloads `priority_class_name` is set on. Every field is a regular expression which must match the whole value, and every field which is set must match. Every workload is selected when unset.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes:          withTargetAttributes(map[string]tfsdk.Attribute{}),
+}
+
+// Sets the priority class of the pod spec of workloads
+type priorityClassSetter struct {
+	name      string
+	workloads *patchTarget
+}
+
+// Compiles the priority class and the workloads it is set on, failing with the name of the invalid target field, or
+// an empty one when the priority class itself is invalid. A nil setter is returned when there is no priority class.
+func compilePriorityClassSetter(name string, targets []patchTargetModel) (*priorityClassSetter, string, error) {
+	if name == "" {
+		if len(targets) > 0 {
+			return nil, "", fmt.Errorf("priority_class_target requires priority_class_name to be set")
+		}
+		return nil, "", nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return nil, "", fmt.Errorf("%q isn't a valid priority class name: %s", name, strings.Join(errs, ", "))
+	}
+
+	setter := &priorityClassSetter{name: name}
+	if len(targets) > 0 {
+		workloads, field, err := compilePatchTarget(targets[0])
+		if err != nil {
+			return nil, field, err
+		}
+		setter.workloads = workloads
+	}
+	return setter, "", nil
+}
+
+// Sets the priority class when the manifest is a selected workload, returning whether it changed
+func (p *priorityClassSetter) apply(manifest map[any]any) bool {
+	if p == nil || !p.workloads.allows(manifest) {
+		return false
+	}
+	spec := podSpec(manifest)
+	if spec == nil || spec["priorityClassName"] == p.name {
+		return false
+	}
+
+	spec["priorityClassName"] = p.name
+	return true
+}
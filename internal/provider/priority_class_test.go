@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_PriorityClassName(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	prioritized := strings.Replace(workloadDocument, "        name: init\n", "        name: init\n      priorityClassName: system-cluster-critical\n", 1)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(priorityClassNameStatement, server.URL, "system-cluster-critical", "Deployment"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", prioritized)),
+			},
+			{
+				Config: fmt.Sprintf(priorityClassNameStatement, server.URL, "system-cluster-critical", "StatefulSet"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", workloadDocument),
+			},
+			{
+				Config:      fmt.Sprintf(priorityClassNameStatement, server.URL, "Critical", "Deployment"),
+				ExpectError: regexp.MustCompile(`Invalid priority class`),
+			},
+		},
+	})
+}
+
+func TestPriorityClassSetter(t *testing.T) {
+	setter, _, err := compilePriorityClassSetter("high", []patchTargetModel{{Name: types.String{Value: "cert-manager-.*"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pod := map[any]any{"kind": "Pod", "metadata": map[any]any{"name": "cert-manager-webhook"}, "spec": map[any]any{"priorityClassName": "low"}}
+	if !setter.apply(pod) {
+		t.Error("expected the pod to be modified")
+	}
+	if priority := podSpec(pod)["priorityClassName"]; priority != "high" {
+		t.Errorf("expected the priority class to be replaced, got %v", priority)
+	}
+	if setter.apply(pod) {
+		t.Error("expected setting the same priority class again to change nothing")
+	}
+	if setter.apply(map[any]any{"kind": "Pod", "metadata": map[any]any{"name": "other"}, "spec": map[any]any{}}) {
+		t.Error("expected a workload which isn't targeted to be left untouched")
+	}
+
+	if _, _, err := compilePriorityClassSetter("", []patchTargetModel{{}}); err == nil {
+		t.Error("expected a target without a priority class to be invalid")
+	}
+	if _, field, err := compilePriorityClassSetter("high", []patchTargetModel{{Kind: types.String{Value: "("}}}); err == nil || field != "kind" {
+		t.Errorf("expected the kind to be invalid, got %q: %v", field, err)
+	}
+}
+
+const priorityClassNameStatement = `
+data "manifest_fetch" "test" {
+	url                 = "%s/workload"
+	priority_class_name = %q
+
+	priority_class_target {
+		kind = %q
+	}
+}
+`
@@ -2,12 +2,17 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ provider.Provider = (*manifestProvider)(nil)
@@ -31,18 +36,334 @@ func (p *manifestProvider) Metadata(_ context.Context, _ provider.MetadataReques
 }
 
 func (p *manifestProvider) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
-	return tfsdk.Schema{}, nil
+	return tfsdk.Schema{
+		Attributes: map[string]tfsdk.Attribute{
+			"offline": {
+				MarkdownDescription: "Forbids all network access, serving every request from the `cache` regardless of its TTL. Requests for content which has not been cached fail. Requires `cache` to be configured.",
+				Type:                types.BoolType,
+				Optional:            true,
+			},
+			"max_concurrency": {
+				Description: "The maximum number of requests to make at once across every data source and resource, including those for fallback URLs, archives, and OCI layers. Defaults to no limit.",
+				Type:        types.Int64Type,
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]tfsdk.Block{
+			"registry_auth": {
+				MarkdownDescription: "Credentials for pulling from an OCI registry. When no credentials are configured for a registry, they are read from the Docker configuration (`~/.docker/config.json` or `$DOCKER_CONFIG`), including any credential helpers.",
+				NestingMode:         tfsdk.BlockNestingModeList,
+				Attributes: map[string]tfsdk.Attribute{
+					"address": {
+						MarkdownDescription: "The host (and optional port) of the registry, such as `ghcr.io`.",
+						Type:                types.StringType,
+						Required:            true,
+					},
+					"username": {
+						Description: "The username to authenticate with.",
+						Type:        types.StringType,
+						Required:    true,
+					},
+					"password": {
+						Description: "The password or access token to authenticate with.",
+						Type:        types.StringType,
+						Required:    true,
+						Sensitive:   true,
+					},
+				},
+			},
+			"sftp_auth": {
+				MarkdownDescription: "Credentials for retrieving `sftp://` URLs. When no credentials are configured for a host, the SSH agent is used and the host key is verified against `~/.ssh/known_hosts`.",
+				NestingMode:         tfsdk.BlockNestingModeList,
+				Attributes: map[string]tfsdk.Attribute{
+					"host": {
+						MarkdownDescription: "The host (and optional port) of the server, such as `files.example.com`.",
+						Type:                types.StringType,
+						Required:            true,
+					},
+					"username": {
+						Description: "The username to authenticate with. Defaults to the username in the URL, or that of the current user.",
+						Type:        types.StringType,
+						Optional:    true,
+					},
+					"password": {
+						Description: "The password to authenticate with.",
+						Type:        types.StringType,
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"private_key": {
+						Description: "The PEM-encoded private key to authenticate with.",
+						Type:        types.StringType,
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"host_key": {
+						MarkdownDescription: "The public key of the server in `authorized_keys` format, such as `ssh-ed25519 AAAA...`. Defaults to verifying the server against `~/.ssh/known_hosts`.",
+						Type:                types.StringType,
+						Optional:            true,
+					},
+				},
+			},
+			"spill": {
+				Description: "Bounds the memory used by decoded manifests, writing them to temporary files once the threshold is exceeded until they are stored in the state. Useful for processing very large bundles on constrained machines.",
+				NestingMode: tfsdk.BlockNestingModeList,
+				MaxItems:    1,
+				Attributes: map[string]tfsdk.Attribute{
+					"threshold": {
+						MarkdownDescription: "The size of the decoded manifests of a single fetch to hold in memory before spilling to disk (e.g. `256MiB`). Supports the `B`, `KB`, `MB`, `GB`, `KiB`, `MiB`, and `GiB` units.",
+						Type:                types.StringType,
+						Required:            true,
+					},
+					"dir": {
+						Description: "The directory to write the temporary files to. Defaults to the system temporary directory.",
+						Type:        types.StringType,
+						Optional:    true,
+					},
+				},
+			},
+			"cache": {
+				Description: "Stores fetched manifests on disk, serving subsequent requests for the same URL from disk until the TTL expires.",
+				NestingMode: tfsdk.BlockNestingModeList,
+				MaxItems:    1,
+				Attributes: map[string]tfsdk.Attribute{
+					"dir": {
+						Description: "The directory to store cached responses in. It is created if it does not exist.",
+						Type:        types.StringType,
+						Required:    true,
+					},
+					"ttl": {
+						MarkdownDescription: "How long cached responses are served for (e.g. `1h`).",
+						Type:                types.StringType,
+						Required:            true,
+					},
+					"honor_cache_control": {
+						MarkdownDescription: "Whether the `Cache-Control` and `Expires` headers of responses determine how long they are cached for, with the `ttl` only used when neither is present. Responses with `no-store` are never cached, and stale responses are revalidated using their `ETag` or `Last-Modified` headers. Defaults to `true`.",
+						Type:                types.BoolType,
+						Optional:            true,
+					},
+				},
+			},
+		},
+	}, nil
 }
 
-func (p *manifestProvider) Configure(context.Context, provider.ConfigureRequest, *provider.ConfigureResponse) {
+func (p *manifestProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := &providerData{
+		registryCredentials: make(map[string]registryCredentials),
+		sftpCredentials:     make(map[string]sftpCredentials),
+	}
+
+	for _, auth := range config.RegistryAuth {
+		data.registryCredentials[auth.Address.Value] = registryCredentials{
+			username: auth.Username.Value,
+			password: auth.Password.Value,
+		}
+	}
+
+	for _, auth := range config.SFTPAuth {
+		data.sftpCredentials[auth.Host.Value] = sftpCredentials{
+			username:   auth.Username.Value,
+			password:   auth.Password.Value,
+			privateKey: auth.PrivateKey.Value,
+			hostKey:    auth.HostKey.Value,
+		}
+	}
+
+	for _, cache := range config.Cache {
+		ttl, err := time.ParseDuration(cache.TTL.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cache").AtListIndex(0).AtName("ttl"), "Invalid cache TTL", fmt.Sprintf("Invalid cache TTL: %s", err))
+			return
+		}
+
+		honorCacheControl := cache.HonorCacheControl.Null || cache.HonorCacheControl.Value
+		data.cache, err = newDiskCache(cache.Dir.Value, ttl, honorCacheControl)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cache").AtListIndex(0).AtName("dir"), "Error creating cache directory", fmt.Sprintf("Error creating cache directory: %s", err))
+			return
+		}
+	}
+
+	for _, spill := range config.Spill {
+		threshold, err := parseByteSize(spill.Threshold.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("spill").AtListIndex(0).AtName("threshold"), "Invalid spill threshold", fmt.Sprintf("Invalid spill threshold: %s", err))
+			return
+		}
+
+		data.spill = &spillOptions{threshold: threshold, dir: spill.Dir.Value}
+	}
+
+	if config.Offline.Value {
+		if data.cache == nil {
+			resp.Diagnostics.AddAttributeError(path.Root("offline"), "Offline mode requires a cache", "Offline mode requires a cache to serve requests from, but no cache is configured.")
+			return
+		}
+		data.offline = true
+	}
+
+	if !config.MaxConcurrency.Null {
+		if config.MaxConcurrency.Value < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_concurrency"), "Invalid max concurrency", fmt.Sprintf("Invalid max concurrency %d, must be at least 1", config.MaxConcurrency.Value))
+			return
+		}
+		data.limiter = newConcurrencyLimiter(int(config.MaxConcurrency.Value))
+	}
+
+	data.memo = newFetchMemo()
+	data.pooledTransport = newPooledTransport()
+	if data.limiter != nil {
+		data.pooledTransport = &limitingTransport{limiter: data.limiter, next: data.pooledTransport}
+	}
+	data.client = &http.Client{Transport: data.transport()}
+
+	resp.DataSourceData = data
+	resp.ResourceData = data
 }
 
 func (p *manifestProvider) DataSources(context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewFetchDataSource,
+		NewJsonnetDataSource,
+		NewYttDataSource,
+		NewHelmTemplateDataSource,
+		NewKustomizeDataSource,
+		NewFluxHelmReleaseDataSource,
+		NewOLMBundleDataSource,
+		NewFileDataSource,
+		NewDecodeDataSource,
+		NewEncodeDataSource,
 	}
 }
 
 func (p *manifestProvider) Resources(context.Context) []func() resource.Resource {
-	return []func() resource.Resource{}
+	return []func() resource.Resource{
+		NewFetchResource,
+	}
+}
+
+// The provider-level configuration shared with data sources and resources
+type providerData struct {
+	cache   *diskCache
+	offline bool
+
+	registryCredentials map[string]registryCredentials
+	sftpCredentials     map[string]sftpCredentials
+
+	// When set, decoded manifests past the threshold are held on disk
+	spill *spillOptions
+
+	// Bounds the outbound requests in flight, or nil when unlimited
+	limiter *concurrencyLimiter
+
+	// Responses of the fetches made so far, shared between data sources and resources referencing the same URL
+	memo *fetchMemo
+
+	// Shared by every data source and resource so connections to the same host are reused
+	pooledTransport http.RoundTripper
+	client          *http.Client
+}
+
+// The options for spilling decoded manifests to disk, or nil to hold them in memory
+func (d *providerData) spillOptions() *spillOptions {
+	if d == nil {
+		return nil
+	}
+
+	return d.spill
+}
+
+// Waits for the limiter to allow another request, for the sources which don't make their requests through the
+// transport
+func (d *providerData) acquireRequestSlot(ctx context.Context) (func(), error) {
+	if d == nil {
+		return func() {}, nil
+	}
+
+	return d.limiter.acquire(ctx)
+}
+
+// The client to make requests with, respecting the provider configuration
+func (d *providerData) httpClient() *http.Client {
+	if d == nil || d.client == nil {
+		return &http.Client{Transport: d.transport()}
+	}
+
+	return d.client
+}
+
+// The transport to make requests with, respecting the provider configuration
+func (d *providerData) transport() http.RoundTripper {
+	if d == nil || d.cache == nil {
+		return d.baseTransport()
+	}
+	if d.offline {
+		return &offlineTransport{cache: d.cache}
+	}
+
+	return &cachingTransport{cache: d.cache, next: d.baseTransport()}
+}
+
+// The transport to make requests with when they must bypass the cache
+func (d *providerData) baseTransport() http.RoundTripper {
+	if d == nil || d.pooledTransport == nil {
+		return http.DefaultTransport
+	}
+
+	return d.pooledTransport
+}
+
+// Creates a transport that keeps enough idle connections to each host for concurrent fetches to reuse them,
+// negotiating HTTP/2 where possible
+func newPooledTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 16
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return transport
+}
+
+type providerModel struct {
+	Offline        types.Bool          `tfsdk:"offline"`
+	MaxConcurrency types.Int64         `tfsdk:"max_concurrency"`
+	Cache          []cacheModel        `tfsdk:"cache"`
+	RegistryAuth   []registryAuthModel `tfsdk:"registry_auth"`
+	SFTPAuth       []sftpAuthModel     `tfsdk:"sftp_auth"`
+	Spill          []spillModel        `tfsdk:"spill"`
+}
+
+type spillModel struct {
+	Threshold types.String `tfsdk:"threshold"`
+	Dir       types.String `tfsdk:"dir"`
+}
+
+type registryAuthModel struct {
+	Address  types.String `tfsdk:"address"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+type sftpAuthModel struct {
+	Host       types.String `tfsdk:"host"`
+	Username   types.String `tfsdk:"username"`
+	Password   types.String `tfsdk:"password"`
+	PrivateKey types.String `tfsdk:"private_key"`
+	HostKey    types.String `tfsdk:"host_key"`
+}
+
+type cacheModel struct {
+	Dir types.String `tfsdk:"dir"`
+	TTL types.String `tfsdk:"ttl"`
+
+	HonorCacheControl types.Bool `tfsdk:"honor_cache_control"`
 }
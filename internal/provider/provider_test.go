@@ -1,8 +1,17 @@
 package provider
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func providerFactories() map[string]func() (tfprotov6.ProviderServer, error) {
@@ -10,3 +19,49 @@ func providerFactories() map[string]func() (tfprotov6.ProviderServer, error) {
 		"manifest": providerserver.NewProtocol6WithError(New("test")()),
 	}
 }
+
+func TestProvider_ReusesConnections(t *testing.T) {
+	var connections, requests int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		_, _ = w.Write([]byte(singleDocument))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(chainedResourceStatement, server.URL, server.URL, server.URL),
+				Check: func(*terraform.State) error {
+					connections, requests := atomic.LoadInt64(&connections), atomic.LoadInt64(&requests)
+					if connections >= requests {
+						return fmt.Errorf("expected connections to be reused, got %d connections for %d requests", connections, requests)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+// Each data source depends on the previous one so their requests are made one after another
+const chainedResourceStatement = `
+data "manifest_fetch" "first" {
+	url = "%s/first"
+}
+
+data "manifest_fetch" "second" {
+	url = "%s/second?after=${data.manifest_fetch.first.content_sha256}"
+}
+
+data "manifest_fetch" "third" {
+	url = "%s/third?after=${data.manifest_fetch.second.content_sha256}"
+}
+`
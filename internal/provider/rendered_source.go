@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Adds the attributes shared by the data sources which render or decode manifests themselves, rather than fetching
+// them, to the attributes of the data source
+func withRenderedAttributes(attributes map[string]tfsdk.Attribute) map[string]tfsdk.Attribute {
+	attributes["id"] = tfsdk.Attribute{
+		Description: "A hash of the resulting manifests.",
+		Type:        types.StringType,
+		Computed:    true,
+	}
+	attributes["filtered_attributes"] = tfsdk.Attribute{
+		MarkdownDescription: "The paths of the attributes to remove from the returned manifests, as with the `manifest_fetch` data source.",
+		Type: types.ListType{
+			ElemType: types.StringType,
+		},
+		Optional: true,
+	}
+	attributes["only_resources"] = tfsdk.Attribute{
+		MarkdownDescription: "The `{apiVersion}/{kind}` patterns of the resources to return, as with the `manifest_fetch` data source. Defaults to every resource.",
+		Type: types.ListType{
+			ElemType: types.StringType,
+		},
+		Optional: true,
+	}
+	attributes["manifests"] = tfsdk.Attribute{
+		Description: "The resulting manifests, in order.",
+		Type: types.ListType{
+			ElemType: types.StringType,
+		},
+		Computed: true,
+	}
+	return attributes
+}
+
+// The blocks shared by the data sources which render or decode manifests themselves
+func withRenderedBlocks(blocks map[string]tfsdk.Block) map[string]tfsdk.Block {
+	blocks["filter"] = filterBlock
+	return blocks
+}
+
+// The filtering configuration shared by the data sources which render or decode manifests themselves
+type renderedFilters struct {
+	FilteredAttributes types.List
+	OnlyResources      types.List
+	Filter             []filterBlockModel
+}
+
+// Converts the filtering configuration into a model of the fetch schema, with every other attribute unset, so the
+// manifests pass through the same pipeline as fetched ones
+func (f renderedFilters) model(ctx context.Context) (modelV0, diag.Diagnostics) {
+	schema := fetchSchema()
+	objectType := schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	var model modelV0
+	state := tfsdk.State{Schema: schema, Raw: tftypes.NewValue(objectType, values)}
+	diags := state.Get(ctx, &model)
+	if diags.HasError() {
+		return model, diags
+	}
+
+	model.FilteredAttributes = f.FilteredAttributes
+	model.OnlyResources = f.OnlyResources
+	model.Filter = f.Filter
+	return model, diags
+}
+
+// Compiles the filtering configuration, so mistakes are reported while planning. Values which aren't known yet are
+// only validated when rendering.
+func (f renderedFilters) validate(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	if !config.Raw.IsFullyKnown() {
+		return nil
+	}
+
+	model, diags := f.model(ctx)
+	if diags.HasError() {
+		return diags
+	}
+	_, filterDiags := compileFilters(ctx, model)
+	diags.Append(filterDiags...)
+	return diags
+}
+
+// Decodes and filters the manifests within the sources, returning them along with an ID derived from them
+func (f renderedFilters) decode(ctx context.Context, provider *providerData, sources []archiveFile) (types.List, types.String, diag.Diagnostics) {
+	model, diags := f.model(ctx)
+	if diags.HasError() {
+		return types.List{}, types.String{}, diags
+	}
+
+	filters, filterDiags := compileFilters(ctx, model)
+	diags.Append(filterDiags...)
+	if diags.HasError() {
+		return types.List{}, types.String{}, diags
+	}
+
+	manifests, decodeDiags := model.decodeSources(ctx, provider, newFetcher(provider), filters, sources, "", defaultParallelism, nil, 0)
+	diags.Append(decodeDiags...)
+	if diags.HasError() {
+		return types.List{}, types.String{}, diags
+	}
+
+	return model.Manifests, types.String{Value: sha256Hex([]byte(strings.Join(manifests, "\n---\n")))}, diags
+}
+
+// Fetches each of the URLs into a file within the directory, returning the paths of the files in order. Files are named
+// after the last segment of their URL, prefixed with their position, and the first of the extensions is appended
+// unless they already have one of them, as tools usually decide how to read a file by its extension. When a URL
+// can't be fetched, it is returned along with the error.
+func fetchToDir(ctx context.Context, fetcher *fetcher, urls []string, dir string, extensions ...string) ([]string, string, error) {
+	files := make([]string, 0, len(urls))
+	for i, rawURL := range urls {
+		response, errs := fetcher.fetchSequential(ctx, []string{rawURL})
+		if response == nil {
+			return nil, rawURL, errs[0]
+		}
+
+		name := path.Base(rawURL)
+		if parsed, err := url.Parse(rawURL); err == nil && parsed.Path != "" {
+			name = path.Base(parsed.Path)
+		}
+		if len(extensions) > 0 && !contains(extensions, strings.ToLower(path.Ext(name))) {
+			name += extensions[0]
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("%d-%s", i, name))
+		if err := os.WriteFile(file, response.body, 0o600); err != nil {
+			return nil, rawURL, err
+		}
+		files = append(files, file)
+	}
+	return files, "", nil
+}
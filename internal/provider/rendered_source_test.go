@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRenderedFilters_Decode(t *testing.T) {
+	ctx := context.Background()
+	filters := renderedFilters{
+		FilteredAttributes: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "metadata.uid"}}},
+		OnlyResources:      types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "v1/ConfigMap"}}},
+	}
+
+	manifests, id, diags := filters.decode(ctx, nil, []archiveFile{{content: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n  uid: \"1234\"\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: test\n")}})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	var decoded []string
+	if diags := manifests.ElementsAs(ctx, &decoded, false); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	expected := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+	if len(decoded) != 1 || decoded[0] != expected {
+		t.Errorf("expected [%q], got %q", expected, decoded)
+	}
+	if id.Value != sha256Hex([]byte(expected)) {
+		t.Errorf("unexpected id %q", id.Value)
+	}
+}
+
+func TestFetchToDir(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	files, failed, err := fetchToDir(context.Background(), newFetcher(nil), []string{server.URL + "/single", server.URL + "/json?version=1"}, dir, ".yml", ".json")
+	if err != nil {
+		t.Fatalf("unexpected error fetching %s: %s", failed, err)
+	}
+
+	expected := []string{filepath.Join(dir, "0-single.yml"), filepath.Join(dir, "1-json.yml")}
+	if len(files) != len(expected) || files[0] != expected[0] || files[1] != expected[1] {
+		t.Fatalf("expected %v, got %v", expected, files)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(content) != singleDocument {
+		t.Errorf("expected %q, got %q", singleDocument, content)
+	}
+
+	if _, failed, err := fetchToDir(context.Background(), newFetcher(nil), []string{server.URL + "/missing"}, dir); err == nil || failed != server.URL+"/missing" {
+		t.Errorf("expected an error for %s, got %v", server.URL+"/missing", err)
+	}
+}
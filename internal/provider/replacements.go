@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var replacementsBlock = tfsdk.Block{
+	MarkdownDescription: "Copy the value of a field from one manifest into fields of other manifests, as the replacements of kustomize do, such as to propagate the name of a `Secret` into the workloads using it. Values are copied after the patches and overlays are applied, but before the manifests are moved into a namespace or renamed.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	Blocks: map[string]tfsdk.Block{
+		"source": {
+			MarkdownDescription: "The manifest the value is copied from, which must be exactly one manifest. Every selecting field is a regular expression which must match the whole value. Required.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			MaxItems:            1,
+			Attributes: withTargetAttributes(map[string]tfsdk.Attribute{
+				"field": {
+					MarkdownDescription: "The path of the field whose value is copied, using the same syntax as `filtered_attributes` without wildcards, such as `metadata.name` or `spec.template.spec.containers[0].image`. Defaults to `metadata.name`.",
+					Type:                types.StringType,
+					Optional:            true,
+				},
+			}),
+		},
+		"target": {
+			MarkdownDescription: "The manifests the value is copied into. Every selecting field is a regular expression which must match the whole value, and every manifest is selected when none are set.",
+			NestingMode:         tfsdk.BlockNestingModeList,
+			Attributes: withTargetAttributes(map[string]tfsdk.Attribute{
+				"fields": {
+					MarkdownDescription: "The paths of the fields the value is copied into, using the same syntax as `filtered_attributes`, such as `spec.template.spec.volumes[*].secret.secretName`.",
+					Type:                types.ListType{ElemType: types.StringType},
+					Required:            true,
+				},
+				"create": {
+					MarkdownDescription: "Whether missing fields are created, along with any maps on the way to them. Missing fields are otherwise skipped. Defaults to `false`.",
+					Type:                types.BoolType,
+					Optional:            true,
+				},
+			}),
+		},
+	},
+}
+
+type replacementModel struct {
+	Source []replacementSourceModel `tfsdk:"source"`
+	Target []replacementTargetModel `tfsdk:"target"`
+}
+
+type replacementSourceModel struct {
+	Group         types.String `tfsdk:"group"`
+	Version       types.String `tfsdk:"version"`
+	Kind          types.String `tfsdk:"kind"`
+	Name          types.String `tfsdk:"name"`
+	Namespace     types.String `tfsdk:"namespace"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+	Field         types.String `tfsdk:"field"`
+}
+
+type replacementTargetModel struct {
+	Group         types.String `tfsdk:"group"`
+	Version       types.String `tfsdk:"version"`
+	Kind          types.String `tfsdk:"kind"`
+	Name          types.String `tfsdk:"name"`
+	Namespace     types.String `tfsdk:"namespace"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+	Fields        types.List   `tfsdk:"fields"`
+	Create        types.Bool   `tfsdk:"create"`
+}
+
+// Copies values between documents. The values are collected from every document before any is transformed, as a
+// document may receive a value from one which comes after it.
+type replacer struct {
+	replacements []*replacement
+}
+
+type replacement struct {
+	source *patchTarget
+	field  []pathSegment
+	// The path of the field as configured, for error messages
+	fieldPath string
+	targets   []replacementTarget
+
+	// The number of documents matching the source, and the value of the field in the last one. Only read once
+	// transforming starts.
+	sources int
+	value   any
+	found   bool
+}
+
+type replacementTarget struct {
+	selector *patchTarget
+	fields   [][]pathSegment
+	create   bool
+}
+
+// Compiles the replacements into a replacer, failing with the path of the first invalid attribute. A nil replacer is
+// returned when there are no replacements.
+func compileReplacer(ctx context.Context, models []replacementModel) (*replacer, path.Path, error) {
+	if len(models) == 0 {
+		return nil, path.Empty(), nil
+	}
+
+	replacer := &replacer{}
+	for i, model := range models {
+		at := path.Root("replacements").AtListIndex(i)
+		if len(model.Source) == 0 {
+			return nil, at, fmt.Errorf("a source is required")
+		}
+
+		source := model.Source[0]
+		selector, field, err := compilePatchTarget(patchTargetModel{source.Group, source.Version, source.Kind, source.Name, source.Namespace, source.LabelSelector})
+		if err != nil {
+			return nil, at.AtName("source").AtListIndex(0).AtName(field), err
+		}
+		replacement := &replacement{source: selector, fieldPath: "metadata.name"}
+		if isSet(source.Field) {
+			replacement.fieldPath = source.Field.Value
+		}
+		if replacement.field, err = parseAttributePath(replacement.fieldPath); err != nil {
+			return nil, at.AtName("source").AtListIndex(0).AtName("field"), err
+		}
+		for _, segment := range replacement.field {
+			if segment.every {
+				return nil, at.AtName("source").AtListIndex(0).AtName("field"), fmt.Errorf("the source field can't contain wildcards")
+			}
+		}
+
+		for j, target := range model.Target {
+			targetAt := at.AtName("target").AtListIndex(j)
+			selector, field, err := compilePatchTarget(patchTargetModel{target.Group, target.Version, target.Kind, target.Name, target.Namespace, target.LabelSelector})
+			if err != nil {
+				return nil, targetAt.AtName(field), err
+			}
+
+			compiled := replacementTarget{selector: selector, create: target.Create.Value}
+			for _, field := range parseTfList(ctx, target.Fields, func(field string) string { return field }) {
+				segments, err := parseAttributePath(field)
+				if err != nil {
+					return nil, targetAt.AtName("fields"), err
+				}
+				compiled.fields = append(compiled.fields, segments)
+			}
+			replacement.targets = append(replacement.targets, compiled)
+		}
+
+		replacer.replacements = append(replacer.replacements, replacement)
+	}
+	return replacer, path.Empty(), nil
+}
+
+// Records the value of the field when the document is a source
+func (r *replacer) collect(manifest map[any]any) {
+	for _, replacement := range r.replacements {
+		if !replacement.source.allows(manifest) {
+			continue
+		}
+
+		replacement.sources++
+		replacement.value, replacement.found = lookupPath(manifest, replacement.field)
+	}
+}
+
+// Ensures every replacement has exactly one source with a value, once every document is collected, failing with the
+// index of the first which doesn't
+func (r *replacer) resolve() (int, error) {
+	for i, replacement := range r.replacements {
+		switch {
+		case replacement.sources == 0:
+			return i, fmt.Errorf("no manifest matches the source")
+		case replacement.sources > 1:
+			return i, fmt.Errorf("%d manifests match the source, rather than exactly one", replacement.sources)
+		case !replacement.found:
+			return i, fmt.Errorf("the source manifest has no value at %s", replacement.fieldPath)
+		}
+	}
+	return 0, nil
+}
+
+// Copies the values into the fields of the document when it is a target, returning whether anything changed
+func (r *replacer) apply(manifest map[any]any) bool {
+	if r == nil {
+		return false
+	}
+
+	modified := false
+	for _, replacement := range r.replacements {
+		for _, target := range replacement.targets {
+			if !target.selector.allows(manifest) {
+				continue
+			}
+
+			for _, field := range target.fields {
+				var changed bool
+				if target.create {
+					_, changed = setValue(manifest, field, replacement.value)
+				} else {
+					changed = replaceValue(manifest, field, replacement.value)
+				}
+				modified = modified || changed
+			}
+		}
+	}
+	return modified
+}
+
+// Retrieves the value at the path, which mustn't contain wildcards, and whether it exists
+func lookupPath(value any, path []pathSegment) (any, bool) {
+	for _, segment := range path {
+		if segment.list {
+			list, ok := value.([]any)
+			if !ok || segment.index >= len(list) {
+				return nil, false
+			}
+			value = list[segment.index]
+			continue
+		}
+
+		attributes, ok := value.(map[any]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = attributes[segment.key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Replaces the values which exist at the path, returning whether any changed
+func replaceValue(current any, path []pathSegment, value any) bool {
+	segment := path[0]
+	if segment.list {
+		list, ok := current.([]any)
+		if !ok {
+			return false
+		}
+
+		modified := false
+		for i := range list {
+			if !segment.every && segment.index != i {
+				continue
+			}
+			if len(path) > 1 {
+				modified = replaceValue(list[i], path[1:], value) || modified
+			} else if !reflect.DeepEqual(list[i], value) {
+				list[i] = copyValue(value)
+				modified = true
+			}
+		}
+		return modified
+	}
+
+	attributes, ok := current.(map[any]any)
+	if !ok {
+		return false
+	}
+	existing, ok := attributes[segment.key]
+	if !ok {
+		return false
+	}
+	if len(path) > 1 {
+		return replaceValue(existing, path[1:], value)
+	}
+	if reflect.DeepEqual(existing, value) {
+		return false
+	}
+
+	attributes[segment.key] = copyValue(value)
+	return true
+}
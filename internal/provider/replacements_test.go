@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Replacements(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(replacementsStatement, server.URL, "cert-manager-webhook"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager")+"spec:\n  serviceName: cert-manager-webhook\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-webhook"))),
+			},
+			{
+				Config:      fmt.Sprintf(replacementsStatement, server.URL, "cert-manager-.*"),
+				ExpectError: regexp.MustCompile(`2 manifests match the source, rather than exactly one`),
+			},
+		},
+	})
+}
+
+func TestReplacer(t *testing.T) {
+	fields := func(paths ...string) types.List {
+		elements := make([]attr.Value, len(paths))
+		for i, path := range paths {
+			elements[i] = types.String{Value: path}
+		}
+		return types.List{ElemType: types.StringType, Elems: elements}
+	}
+	unset := types.String{Null: true}
+
+	replacer, _, err := compileReplacer(context.Background(), []replacementModel{{
+		Source: []replacementSourceModel{{Group: unset, Version: unset, Kind: types.String{Value: "Secret"}, Name: unset, Namespace: unset, LabelSelector: unset, Field: unset}},
+		Target: []replacementTargetModel{
+			{Group: types.String{Value: "apps"}, Version: unset, Kind: unset, Name: unset, Namespace: unset, LabelSelector: unset, Fields: fields("spec.template.spec.volumes[*].secret.secretName", "spec.template.metadata.annotations.secret"), Create: types.Bool{Value: false}},
+			{Group: unset, Version: unset, Kind: types.String{Value: "ConfigMap"}, Name: unset, Namespace: unset, LabelSelector: unset, Fields: fields("data.secret"), Create: types.Bool{Value: true}},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	secret := map[any]any{"apiVersion": "v1", "kind": "Secret", "metadata": map[any]any{"name": "token-5f8d"}}
+	deployment := map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[any]any{"template": map[any]any{"spec": map[any]any{"volumes": []any{
+		map[any]any{"name": "a", "secret": map[any]any{"secretName": "token"}},
+		map[any]any{"name": "b", "emptyDir": map[any]any{}},
+	}}}}}
+	config := map[any]any{"apiVersion": "v1", "kind": "ConfigMap"}
+
+	for _, manifest := range []map[any]any{secret, deployment, config} {
+		replacer.collect(manifest)
+	}
+	if _, err := replacer.resolve(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !replacer.apply(deployment) || !replacer.apply(config) || replacer.apply(secret) {
+		t.Error("expected only the targets to be modified")
+	}
+	expected := map[any]any{"apiVersion": "apps/v1", "kind": "Deployment", "spec": map[any]any{"template": map[any]any{"spec": map[any]any{"volumes": []any{
+		map[any]any{"name": "a", "secret": map[any]any{"secretName": "token-5f8d"}},
+		map[any]any{"name": "b", "emptyDir": map[any]any{}},
+	}}}}}
+	if !reflect.DeepEqual(deployment, expected) {
+		t.Errorf("expected %v, got %v", expected, deployment)
+	}
+	if expected := map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "data": map[any]any{"secret": "token-5f8d"}}; !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected %v, got %v", expected, config)
+	}
+}
+
+func TestReplacer_Resolve(t *testing.T) {
+	tests := map[string]struct {
+		manifests []map[any]any
+		err       string
+	}{
+		"no source":     {nil, "no manifest matches the source"},
+		"several":       {[]map[any]any{{"kind": "Secret", "metadata": map[any]any{"name": "a"}}, {"kind": "Secret", "metadata": map[any]any{"name": "b"}}}, "2 manifests match the source"},
+		"missing value": {[]map[any]any{{"kind": "Secret"}}, "the source manifest has no value at metadata.name"},
+	}
+
+	for name, test := range tests {
+		replacer := &replacer{replacements: []*replacement{{source: &patchTarget{kind: regexp.MustCompile("^(?:Secret)$")}, field: []pathSegment{{key: "metadata"}, {key: "name"}}, fieldPath: "metadata.name"}}}
+		for _, manifest := range test.manifests {
+			replacer.collect(manifest)
+		}
+		if _, err := replacer.resolve(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %v", name, test.err, err)
+		}
+	}
+}
+
+const replacementsStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/named"
+
+	replacements {
+		source {
+			name = %q
+		}
+
+		target {
+			name   = "cert-manager"
+			fields = ["spec.serviceName"]
+			create = true
+		}
+	}
+}
+`
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Overrides the `spec.replicas` of the documents, keyed by `{kind}/{name}`
+type replicaOverrides struct {
+	replicas map[string]int
+	// Whether each key matched any document
+	matched map[string]*atomic.Bool
+}
+
+// Compiles the replica counts into overrides, failing with the first malformed key. Nil overrides are returned when
+// there are no replica counts.
+func compileReplicaOverrides(replicas map[string]int64) (*replicaOverrides, string, error) {
+	if len(replicas) == 0 {
+		return nil, "", nil
+	}
+
+	overrides := &replicaOverrides{replicas: map[string]int{}, matched: map[string]*atomic.Bool{}}
+	for key, count := range replicas {
+		if kind, name, ok := strings.Cut(key, "/"); !ok || kind == "" || name == "" {
+			return nil, key, fmt.Errorf("%q must be in the format {kind}/{name}", key)
+		}
+		if count < 0 {
+			return nil, key, fmt.Errorf("the number of replicas can't be negative, got %d", count)
+		}
+
+		overrides.replicas[key] = int(count)
+		overrides.matched[key] = &atomic.Bool{}
+	}
+	return overrides, "", nil
+}
+
+// Sets the replicas of the document when it is overridden, returning whether they changed
+func (o *replicaOverrides) apply(manifest map[any]any) bool {
+	if o == nil {
+		return false
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	key := stringValue(manifest["kind"]) + "/" + stringValue(metadata["name"])
+	replicas, ok := o.replicas[key]
+	if !ok {
+		return false
+	}
+
+	o.matched[key].Store(true)
+	_, changed := setValue(manifest, []pathSegment{{key: "spec"}, {key: "replicas"}}, replicas)
+	return changed
+}
+
+// The keys which didn't match any document
+func (o *replicaOverrides) unmatched() []string {
+	if o == nil {
+		return nil
+	}
+
+	var unmatched []string
+	for key, matched := range o.matched {
+		if !matched.Load() {
+			unmatched = append(unmatched, key)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Replicas(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(replicasStatement, server.URL, "Deployment/cert-manager-webhook", 3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", namedDocument("cert-manager")),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", namedDocument("cert-manager-webhook")+"spec:\n  replicas: 3\n")),
+			},
+			{
+				Config:      fmt.Sprintf(replicasStatement, server.URL, "cert-manager-webhook", 3),
+				ExpectError: regexp.MustCompile(`must be in the format {kind}/{name}`),
+			},
+			{
+				Config:      fmt.Sprintf(replicasStatement, server.URL, "Deployment/cert-manager-webhook", -1),
+				ExpectError: regexp.MustCompile(`the number of replicas can't be negative`),
+			},
+		},
+	})
+}
+
+func TestReplicaOverrides(t *testing.T) {
+	overrides, _, err := compileReplicaOverrides(map[string]int64{"Deployment/app": 0, "StatefulSet/database": 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	deployment := map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"replicas": 2}}
+	if !overrides.apply(deployment) {
+		t.Error("expected the deployment to be modified")
+	}
+	if expected := map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "app"}, "spec": map[any]any{"replicas": 0}}; !reflect.DeepEqual(deployment, expected) {
+		t.Errorf("expected %v, got %v", expected, deployment)
+	}
+
+	other := map[any]any{"kind": "Deployment", "metadata": map[any]any{"name": "database"}}
+	if overrides.apply(other) {
+		t.Error("expected a workload of another kind to be left untouched")
+	}
+	if unmatched := overrides.unmatched(); !reflect.DeepEqual(unmatched, []string{"StatefulSet/database"}) {
+		t.Errorf("expected the stateful set to be unmatched, got %v", unmatched)
+	}
+}
+
+const replicasStatement = `
+data "manifest_fetch" "test" {
+	url      = "%s/named"
+	replicas = {
+		%q = %d
+	}
+}
+`
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+var _ resource.Resource = (*fetchResource)(nil)
+var _ resource.ResourceWithConfigure = (*fetchResource)(nil)
+var _ resource.ResourceWithValidateConfig = (*fetchResource)(nil)
+
+// The private state key storing the validators of the last response
+const validatorsKey = "validators"
+
+func NewFetchResource() resource.Resource {
+	return &fetchResource{}
+}
+
+type fetchResource struct {
+	provider *providerData
+}
+
+func (r *fetchResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_fetch"
+}
+
+func (r *fetchResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if data, ok := req.ProviderData.(*providerData); ok {
+		r.provider = data
+	}
+}
+
+func (r *fetchResource) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	schema := fetchSchema()
+	schema.MarkdownDescription += "\n\n" +
+		"Unlike the data source, the `ETag` and `Last-Modified` headers of the response are remembered and used to make conditional requests when refreshing. If the server responds with `304 Not Modified`, the previously fetched manifests are kept."
+	return schema, nil
+}
+
+func (r *fetchResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(validateFilters(ctx, req.Config)...)
+}
+
+func (r *fetchResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var model modelV0
+	diags := req.Plan.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	response, diags := model.fetch(ctx, r.provider, nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setValidators(ctx, resp.Private, response)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (r *fetchResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var model modelV0
+	diags := req.State.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Pinned content is only fetched again when the configuration changes
+	if model.Pin.Value {
+		return
+	}
+
+	var previous *cacheValidators
+	raw, diags := req.Private.GetKey(ctx, validatorsKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &previous); err != nil || (previous != nil && previous.URL == "") {
+			previous = nil
+		}
+	}
+
+	response, diags := model.fetch(ctx, r.provider, previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The prior state is still current
+	if response.notModified {
+		return
+	}
+
+	resp.Diagnostics.Append(setValidators(ctx, resp.Private, response)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (r *fetchResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var model modelV0
+	diags := req.Plan.Get(ctx, &model)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The configuration changed, so the content must be re-processed regardless of whether it was modified
+	response, diags := model.fetch(ctx, r.provider, nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(setValidators(ctx, resp.Private, response)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (r *fetchResource) Delete(context.Context, resource.DeleteRequest, *resource.DeleteResponse) {
+}
+
+// Stores the validators of the response in private state, clearing any previous validators if there are none
+func setValidators(ctx context.Context, private privateState, response *fetchResponse) diag.Diagnostics {
+	validators := response.validators()
+	if validators == nil {
+		return private.SetKey(ctx, validatorsKey, []byte("null"))
+	}
+
+	raw, err := json.Marshal(validators)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Error storing validators", err.Error())
+		return diags
+	}
+
+	return private.SetKey(ctx, validatorsKey, raw)
+}
+
+type privateState interface {
+	SetKey(context.Context, string, []byte) diag.Diagnostics
+}
@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResource_ConditionalRequests(t *testing.T) {
+	server := newConditionalServer(singleDocument)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(conditionalResourceStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+			{
+				PreConfig: server.reset,
+				Config:    fmt.Sprintf(conditionalResourceStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.0", singleDocument),
+					server.expectNotModified(t),
+				),
+			},
+			{
+				PreConfig: func() { server.setContent(multipleDocuments) },
+				Config:    fmt.Sprintf(conditionalResourceStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestResource_Pin(t *testing.T) {
+	server := newConditionalServer(singleDocument)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(pinnedResourceStatement, server.URL, "one"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.#", "1"),
+				),
+			},
+			{
+				PreConfig: func() {
+					server.setContent(multipleDocuments)
+					server.reset()
+				},
+				Config: fmt.Sprintf(pinnedResourceStatement, server.URL, "one"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.#", "1"),
+					server.expectRequests(0),
+				),
+			},
+			{
+				Config: fmt.Sprintf(pinnedResourceStatement, server.URL, "two"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+type conditionalServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	content     string
+	version     int
+	full        int
+	notModified int
+}
+
+// A server which serves the content with an ETag, responding with 304 Not Modified to matching conditional requests
+func newConditionalServer(content string) *conditionalServer {
+	server := &conditionalServer{content: content}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		etag := fmt.Sprintf(`"v%d"`, server.version)
+		w.Header().Set("ETag", etag)
+
+		if r.Header.Get("If-None-Match") == etag {
+			server.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		server.full++
+		_, _ = w.Write([]byte(server.content))
+	}))
+	return server
+}
+
+func (s *conditionalServer) setContent(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.content = content
+	s.version++
+}
+
+func (s *conditionalServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.full, s.notModified = 0, 0
+}
+
+func (s *conditionalServer) expectNotModified(t *testing.T) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.full != 0 || s.notModified == 0 {
+			return fmt.Errorf("expected only conditional requests, got %d full and %d not modified responses", s.full, s.notModified)
+		}
+		return nil
+	}
+}
+
+func (s *conditionalServer) expectRequests(expected int) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if actual := s.full + s.notModified; actual != expected {
+			return fmt.Errorf("expected %d requests, got %d", expected, actual)
+		}
+		return nil
+	}
+}
+
+const conditionalResourceStatement = `
+resource "manifest_fetch" "test" {
+	url = "%s/manifest.yaml"
+}
+`
+
+const pinnedResourceStatement = `
+resource "manifest_fetch" "test" {
+	url = "%s/manifest.yaml"
+	pin = true
+
+	triggers = {
+		version = "%s"
+	}
+}
+`
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Moves the values of `Secret` manifests between the base64-encoded `data` and the plaintext `stringData`
+type secretDataConverter struct {
+	// Whether `data` is decoded into `stringData`, rather than `stringData` encoded into `data`
+	decode bool
+}
+
+// Creates a converter in the direction which is enabled, of which there must be at most one. A nil converter is
+// returned when neither is.
+func newSecretDataConverter(decode, encode bool) *secretDataConverter {
+	if !decode && !encode {
+		return nil
+	}
+	return &secretDataConverter{decode: decode}
+}
+
+// Converts the values of the document when it is a secret, returning whether any moved
+func (c *secretDataConverter) apply(manifest map[any]any) bool {
+	if c == nil || manifest["apiVersion"] != "v1" || manifest["kind"] != "Secret" {
+		return false
+	}
+	if c.decode {
+		return decodeSecretData(manifest)
+	}
+	return encodeSecretData(manifest)
+}
+
+// Moves the values of `data` which are valid UTF-8 text into `stringData`. Values already in `stringData` are kept, as
+// they take precedence over those in `data`.
+func decodeSecretData(manifest map[any]any) bool {
+	data, _ := manifest["data"].(map[any]any)
+	stringData, _ := manifest["stringData"].(map[any]any)
+
+	modified := false
+	for key, value := range data {
+		decoded, err := base64.StdEncoding.DecodeString(stringValue(value))
+		if err != nil || !utf8.Valid(decoded) {
+			continue
+		}
+
+		if stringData == nil {
+			stringData = map[any]any{}
+			manifest["stringData"] = stringData
+		}
+		if _, ok := stringData[key]; !ok {
+			stringData[key] = string(decoded)
+		}
+		delete(data, key)
+		modified = true
+	}
+
+	if modified && len(data) == 0 {
+		delete(manifest, "data")
+	}
+	return modified
+}
+
+// Moves the values of `stringData` into `data`, replacing the values they take precedence over
+func encodeSecretData(manifest map[any]any) bool {
+	stringData, ok := manifest["stringData"].(map[any]any)
+	if !ok {
+		return false
+	}
+
+	data, _ := manifest["data"].(map[any]any)
+	if data == nil {
+		data = map[any]any{}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+	}
+
+	manifest["data"] = data
+	delete(manifest, "stringData")
+	return true
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_SecretData(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(secretDataStatement, server.URL, true, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: credentials\nstringData:\n  password: hunter2\n")),
+			},
+			{
+				Config: fmt.Sprintf(secretDataStatement, server.URL, false, true),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", secretDocument),
+			},
+			{
+				Config:      fmt.Sprintf(secretDataStatement, server.URL, true, true),
+				ExpectError: regexp.MustCompile(`Only one of decode_secret_data or encode_secret_data can be set`),
+			},
+		},
+	})
+}
+
+func TestSecretDataConverter(t *testing.T) {
+	if converter := newSecretDataConverter(false, false); converter != nil {
+		t.Errorf("expected no converter, got %v", converter)
+	}
+
+	secret := map[any]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"data":       map[any]any{"token": "c2VjcmV0", "keystore": "/+7d"},
+		"stringData": map[any]any{"token": "override"},
+	}
+	if !newSecretDataConverter(true, false).apply(secret) {
+		t.Error("expected the secret to be decoded")
+	}
+	expected := map[any]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"data":       map[any]any{"keystore": "/+7d"},
+		"stringData": map[any]any{"token": "override"},
+	}
+	if !reflect.DeepEqual(secret, expected) {
+		t.Errorf("expected %v, got %v", expected, secret)
+	}
+
+	if !newSecretDataConverter(false, true).apply(secret) {
+		t.Error("expected the secret to be encoded")
+	}
+	expected = map[any]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"data":       map[any]any{"keystore": "/+7d", "token": "b3ZlcnJpZGU="},
+	}
+	if !reflect.DeepEqual(secret, expected) {
+		t.Errorf("expected %v, got %v", expected, secret)
+	}
+}
+
+const secretDataStatement = `
+data "manifest_fetch" "test" {
+	url                = "%s/secret"
+	decode_secret_data = %t
+	encode_secret_data = %t
+}
+`
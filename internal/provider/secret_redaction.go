@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"encoding/base64"
+	"sort"
+)
+
+// The value every redacted secret value is replaced with, in plaintext in `stringData` and encoded in `data`
+const redactedPlaceholder = "REDACTED"
+
+// Replaces the values of the `data` and `stringData` of a `Secret` manifest with the placeholder, returning the keys
+// which were redacted, in order, as `{namespace}/{name}/{key}`, or `{name}/{key}` when the secret has no namespace
+func redactSecret(manifest map[any]any) []string {
+	if manifest["apiVersion"] != "v1" || manifest["kind"] != "Secret" {
+		return nil
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	prefix := stringValue(metadata["name"]) + "/"
+	if namespace := stringValue(metadata["namespace"]); namespace != "" {
+		prefix = namespace + "/" + prefix
+	}
+
+	seen := map[string]bool{}
+	var keys []string
+	for field, placeholder := range map[string]string{
+		"data":       base64.StdEncoding.EncodeToString([]byte(redactedPlaceholder)),
+		"stringData": redactedPlaceholder,
+	} {
+		values, _ := manifest[field].(map[any]any)
+		for key := range values {
+			values[key] = placeholder
+
+			if name := prefix + stringValue(key); !seen[name] {
+				seen[name] = true
+				keys = append(keys, name)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
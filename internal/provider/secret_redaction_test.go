@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_RedactSecrets(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(redactSecretsStatement, server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\ndata:\n  password: UkVEQUNURUQ=\nkind: Secret\nmetadata:\n  name: credentials\n"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "redacted_secret_keys.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "redacted_secret_keys.0", "credentials/password")),
+			},
+		},
+	})
+}
+
+func TestRedactSecret(t *testing.T) {
+	secret := map[any]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[any]any{"name": "credentials", "namespace": "app"},
+		"data":       map[any]any{"token": "c2VjcmV0", "password": "aHVudGVyMg=="},
+		"stringData": map[any]any{"token": "override"},
+	}
+
+	keys := redactSecret(secret)
+	if expected := []string{"app/credentials/password", "app/credentials/token"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+	if expected := map[any]any{"token": "UkVEQUNURUQ=", "password": "UkVEQUNURUQ="}; !reflect.DeepEqual(secret["data"], expected) {
+		t.Errorf("expected %v, got %v", expected, secret["data"])
+	}
+	if expected := map[any]any{"token": "REDACTED"}; !reflect.DeepEqual(secret["stringData"], expected) {
+		t.Errorf("expected %v, got %v", expected, secret["stringData"])
+	}
+
+	if keys := redactSecret(map[any]any{"apiVersion": "v1", "kind": "ConfigMap", "data": map[any]any{"key": "value"}}); keys != nil {
+		t.Errorf("expected other kinds to be left untouched, got %v", keys)
+	}
+}
+
+const redactSecretsStatement = `
+data "manifest_fetch" "test" {
+	url            = "%s/secret"
+	redact_secrets = true
+}
+`
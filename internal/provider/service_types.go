@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// The fields of a service's spec which are only valid for some types, keyed by the types they're removed from
+var serviceTypeFields = map[string][]string{
+	"ClusterIP":    {"externalName", "externalTrafficPolicy", "healthCheckNodePort", "allocateLoadBalancerNodePorts", "loadBalancerClass", "loadBalancerIP", "loadBalancerSourceRanges"},
+	"NodePort":     {"externalName", "healthCheckNodePort", "allocateLoadBalancerNodePorts", "loadBalancerClass", "loadBalancerIP", "loadBalancerSourceRanges"},
+	"LoadBalancer": {"externalName"},
+}
+
+// Overrides the `spec.type` of services, keyed by their name, or `*` for every other service
+type serviceTypeOverrides struct {
+	types map[string]string
+	// Whether each name matched any service
+	matched map[string]*atomic.Bool
+}
+
+// Compiles the service types into overrides, failing with the key of the first invalid type. Nil overrides are
+// returned when there are no service types.
+func compileServiceTypeOverrides(types map[string]string) (*serviceTypeOverrides, string, error) {
+	if len(types) == 0 {
+		return nil, "", nil
+	}
+
+	overrides := &serviceTypeOverrides{types: types, matched: map[string]*atomic.Bool{}}
+	for name, serviceType := range types {
+		if _, ok := serviceTypeFields[serviceType]; !ok {
+			return nil, name, fmt.Errorf("%q must be one of ClusterIP, NodePort, or LoadBalancer", serviceType)
+		}
+		if name != "*" {
+			overrides.matched[name] = &atomic.Bool{}
+		}
+	}
+	return overrides, "", nil
+}
+
+// Sets the type of the service when it is overridden, removing the fields the type doesn't support, and returning
+// whether anything changed
+func (o *serviceTypeOverrides) apply(manifest map[any]any) bool {
+	if o == nil || manifest["apiVersion"] != "v1" || manifest["kind"] != "Service" {
+		return false
+	}
+
+	metadata, _ := manifest["metadata"].(map[any]any)
+	name := stringValue(metadata["name"])
+	serviceType, ok := o.types[name]
+	if ok {
+		o.matched[name].Store(true)
+	} else if serviceType, ok = o.types["*"]; !ok {
+		return false
+	}
+
+	spec, _ := manifest["spec"].(map[any]any)
+	if spec == nil {
+		spec = map[any]any{}
+		manifest["spec"] = spec
+	}
+
+	modified := false
+	if spec["type"] != serviceType {
+		spec["type"] = serviceType
+		modified = true
+	}
+	for _, field := range serviceTypeFields[serviceType] {
+		if _, ok := spec[field]; ok {
+			delete(spec, field)
+			modified = true
+		}
+	}
+	if serviceType == "ClusterIP" {
+		eachMap(spec["ports"], func(port map[any]any) {
+			if _, ok := port["nodePort"]; ok {
+				delete(port, "nodePort")
+				modified = true
+			}
+		})
+	}
+	return modified
+}
+
+// The names which didn't match any service
+func (o *serviceTypeOverrides) unmatched() []string {
+	if o == nil {
+		return nil
+	}
+
+	var unmatched []string
+	for name, matched := range o.matched {
+		if !matched.Load() {
+			unmatched = append(unmatched, name)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_ServiceTypeOverrides(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(serviceTypeOverridesStatement, server.URL, "*", "ClusterIP"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\nspec:\n  ports:\n  - port: 443\n  type: ClusterIP\n")),
+			},
+			{
+				Config: fmt.Sprintf(serviceTypeOverridesStatement, server.URL, "app", "NodePort"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: Service\nmetadata:\n  name: app\nspec:\n  ports:\n  - nodePort: 30443\n    port: 443\n  type: NodePort\n"),
+			},
+			{
+				Config:      fmt.Sprintf(serviceTypeOverridesStatement, server.URL, "app", "ExternalName"),
+				ExpectError: regexp.MustCompile(`must be one of ClusterIP, NodePort, or LoadBalancer`),
+			},
+		},
+	})
+}
+
+func TestServiceTypeOverrides(t *testing.T) {
+	overrides, _, err := compileServiceTypeOverrides(map[string]string{"*": "ClusterIP", "ingress": "LoadBalancer", "missing": "NodePort"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	service := map[any]any{"apiVersion": "v1", "kind": "Service", "metadata": map[any]any{"name": "webhook"}, "spec": map[any]any{
+		"type":                  "NodePort",
+		"externalTrafficPolicy": "Local",
+		"ports":                 []any{map[any]any{"port": 443, "nodePort": 30443}},
+	}}
+	if !overrides.apply(service) {
+		t.Error("expected the service to be modified")
+	}
+	expected := map[any]any{"type": "ClusterIP", "ports": []any{map[any]any{"port": 443}}}
+	if !reflect.DeepEqual(service["spec"], expected) {
+		t.Errorf("expected %v, got %v", expected, service["spec"])
+	}
+
+	ingress := map[any]any{"apiVersion": "v1", "kind": "Service", "metadata": map[any]any{"name": "ingress"}, "spec": map[any]any{"type": "LoadBalancer"}}
+	if overrides.apply(ingress) {
+		t.Error("expected a service with its own type to take precedence over the wildcard")
+	}
+	if overrides.apply(map[any]any{"apiVersion": "serving.knative.dev/v1", "kind": "Service", "metadata": map[any]any{"name": "webhook"}}) {
+		t.Error("expected a service of another API group to be left untouched")
+	}
+	if unmatched := overrides.unmatched(); !reflect.DeepEqual(unmatched, []string{"missing"}) {
+		t.Errorf("expected the missing service to be unmatched, got %v", unmatched)
+	}
+}
+
+const serviceTypeOverridesStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/service"
+	service_type_overrides = {
+		%q = %q
+	}
+}
+`
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sets the attributes at the paths to fixed values in every document, creating any maps along the way
+type attributeSetter struct {
+	entries []setEntry
+}
+
+type setEntry struct {
+	path  []pathSegment
+	value any
+}
+
+// Compiles the values, keyed by the path of the attribute they're set at, into a setter. Values are decoded as YAML, so
+// numbers, booleans, and nested maps or lists keep their type. The key of the first malformed entry is returned with
+// the error. A nil setter is returned when there are no values.
+func compileAttributeSetter(values map[string]string) (*attributeSetter, string, error) {
+	if len(values) == 0 {
+		return nil, "", nil
+	}
+
+	// Values are set in a stable order, so a path nested within another one consistently overrides it
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	setter := &attributeSetter{}
+	for _, path := range paths {
+		segments, err := parseAttributePath(path)
+		if err != nil {
+			return nil, path, err
+		}
+
+		var value any
+		if err := yaml.Unmarshal([]byte(values[path]), &value); err != nil {
+			return nil, path, err
+		}
+
+		setter.entries = append(setter.entries, setEntry{segments, value})
+	}
+
+	return setter, "", nil
+}
+
+// Sets every value in the manifest, returning whether any attribute changed
+func (s *attributeSetter) apply(manifest map[any]any) bool {
+	if s == nil {
+		return false
+	}
+
+	modified := false
+	for _, entry := range s.entries {
+		_, changed := setValue(manifest, entry.path, entry.value)
+		modified = modified || changed
+	}
+	return modified
+}
+
+// Sets the value at the path within the current value, returning the resulting value and whether it changed. Lists
+// are never created, so paths through missing or out of range elements are skipped.
+func setValue(current any, path []pathSegment, value any) (any, bool) {
+	if len(path) == 0 {
+		if reflect.DeepEqual(current, value) {
+			return current, false
+		}
+		// Each document gets its own copy, as documents are transformed concurrently
+		return copyValue(value), true
+	}
+
+	segment := path[0]
+	if segment.list {
+		list, ok := current.([]any)
+		if !ok {
+			return current, false
+		}
+
+		modified := false
+		for i := range list {
+			if segment.every || segment.index == i {
+				var changed bool
+				list[i], changed = setValue(list[i], path[1:], value)
+				modified = modified || changed
+			}
+		}
+		return list, modified
+	}
+
+	attributes, ok := current.(map[any]any)
+	if !ok {
+		if current != nil {
+			return current, false
+		}
+		attributes = map[any]any{}
+	}
+
+	child, changed := setValue(attributes[segment.key], path[1:], value)
+	if changed {
+		attributes[segment.key] = child
+	}
+	return attributes, changed
+}
+
+// Deeply copies the maps and lists within the value
+func copyValue(value any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		copied := make(map[any]any, len(value))
+		for key, element := range value {
+			copied[key] = copyValue(element)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, element := range value {
+			copied[i] = copyValue(element)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_SetAttributes(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(setAttributesStatement, server.URL, `
+		"spec.replicas"                             = "2"
+		"metadata.annotations.\"example.com/owner\"" = "platform"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cert-manager\n  annotations:\n    example.com/owner: platform\nspec:\n  replicas: 2\n")),
+			},
+			{
+				Config:      fmt.Sprintf(setAttributesStatement, server.URL, `"spec.replicas" = "[1"`),
+				ExpectError: regexp.MustCompile(`Invalid attribute value for "spec.replicas"`),
+			},
+		},
+	})
+}
+
+func TestAttributeSetter(t *testing.T) {
+	setter, _, err := compileAttributeSetter(map[string]string{
+		"spec.replicas":                 "3",
+		"spec.paused":                   "'true'",
+		"spec.template.metadata.labels": "{app: example}",
+		"spec.template.spec.containers[*].imagePullPolicy": "IfNotPresent",
+		"spec.template.spec.volumes[0].name":               "data",
+		"kind.name":                                        "ignored",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	manifest := map[any]any{
+		"kind": "Deployment",
+		"spec": map[any]any{
+			"replicas": 1,
+			"template": map[any]any{"spec": map[any]any{"containers": []any{
+				map[any]any{"name": "app"},
+				map[any]any{"name": "proxy", "imagePullPolicy": "Always"},
+			}}},
+		},
+	}
+	if !setter.apply(manifest) {
+		t.Error("expected the manifest to be modified")
+	}
+
+	expected := map[any]any{
+		"kind": "Deployment",
+		"spec": map[any]any{
+			"replicas": 3,
+			"paused":   "true",
+			"template": map[any]any{
+				"metadata": map[any]any{"labels": map[any]any{"app": "example"}},
+				"spec": map[any]any{"containers": []any{
+					map[any]any{"name": "app", "imagePullPolicy": "IfNotPresent"},
+					map[any]any{"name": "proxy", "imagePullPolicy": "IfNotPresent"},
+				}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected %v, got %v", expected, manifest)
+	}
+	if setter.apply(manifest) {
+		t.Error("expected setting the same values again to leave the manifest unmodified")
+	}
+
+	if _, path, err := compileAttributeSetter(map[string]string{"spec.replicas": "3", "spec[first]": "1"}); err == nil || path != "spec[first]" {
+		t.Errorf("expected the malformed path to fail, got %q: %v", path, err)
+	}
+}
+
+const setAttributesStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/named"
+	set_attributes = {
+%s
+	}
+}
+`
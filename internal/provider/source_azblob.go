@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// The version of the Blob service REST API requests are made against
+const azureStorageVersion = "2021-08-06"
+
+// The scope requested for DefaultAzureCredential
+const azureStorageScope = "https://storage.azure.com/.default"
+
+// Retrieves the blob referenced by an azblob://account/container/blob URL. When the URL contains a SAS token as its
+// query parameters, it is used to authorize the request, otherwise DefaultAzureCredential is used. The endpoint of the
+// account can be set with the `endpoint` query parameter.
+func (f *fetcher) fetchAzureBlob(ctx context.Context, url *neturl.URL) (*fetchResponse, error) {
+	account := url.Host
+	container, blob, _ := strings.Cut(strings.TrimPrefix(url.Path, "/"), "/")
+	if account == "" || container == "" || blob == "" {
+		return nil, newFetchError("Invalid URL", "Azure Blob Storage URLs must be in the format azblob://account/container/blob, got %q", url.String())
+	}
+
+	query := url.Query()
+
+	endpoint := fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	if query.Has("endpoint") {
+		endpoint = strings.TrimSuffix(query.Get("endpoint"), "/")
+		query.Del("endpoint")
+	}
+
+	target := endpoint + "/" + neturl.PathEscape(container) + "/" + escapeBlobName(blob)
+	if query.Has("sig") {
+		target += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, newFetchError("Error creating request", "%s", err)
+	}
+	request.Header.Set("x-ms-version", azureStorageVersion)
+
+	if !query.Has("sig") {
+		credential, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, newFetchError("Error loading Azure credentials", "%s", err)
+		}
+
+		token, err := credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureStorageScope}})
+		if err != nil {
+			return nil, newFetchError("Error loading Azure credentials", "%s", err)
+		}
+		request.Header.Set("Authorization", "Bearer "+token.Token)
+	}
+
+	client := &http.Client{Transport: f.provider.baseTransport()}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, newFetchError("Error retrieving blob", "%s", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, newFetchError("Error reading blob", "%s", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var storageError struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if err := xml.Unmarshal(body, &storageError); err == nil && storageError.Code != "" {
+			return nil, newFetchError("Error retrieving blob", "Received non-success response code %d: %s", response.StatusCode, storageError.Code)
+		}
+		return nil, newFetchError("Error retrieving blob", "Received non-success response code: %d", response.StatusCode)
+	}
+
+	return &fetchResponse{url: url.String(), body: body}, nil
+}
+
+// Escapes each segment of the blob name, preserving the separators between virtual directories
+func escapeBlobName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = neturl.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_AzureBlob_SAS(t *testing.T) {
+	server := setupMockBlobStorage()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, "azblob://account", "manifests/rendered/multiple.yaml?sv=2021-08-06&sr=b&sp=r&sig=signature&endpoint="+server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_AzureBlob_InvalidSAS(t *testing.T) {
+	server := setupMockBlobStorage()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, "azblob://account", "manifests/rendered/multiple.yaml?sv=2021-08-06&sr=b&sp=r&sig=invalid&endpoint="+server.URL),
+				ExpectError: regexp.MustCompile("AuthenticationFailed"),
+			},
+		},
+	})
+}
+
+func TestDataSource_AzureBlob_InvalidURL(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, "azblob://account", "manifests"),
+				ExpectError: regexp.MustCompile("must be in the format"),
+			},
+		},
+	})
+}
+
+// A Blob service endpoint serving a single blob to requests authorized by a SAS token
+func setupMockBlobStorage() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sig") != "signature" || r.Header.Get("x-ms-version") == "" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><Error><Code>AuthenticationFailed</Code><Message>Signature did not match.</Message></Error>`))
+			return
+		}
+
+		if r.URL.Path != "/manifests/rendered/multiple.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(multipleDocuments))
+	}))
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var clusterSourceBlock = tfsdk.Block{
+	MarkdownDescription: "Reads the manifest(s) of existing objects from a Kubernetes cluster, removing the attributes managed by the cluster such as `status` and `metadata.uid`. Conflicts with `url`, `urls`, `path`, `git`, `github_release`, and `crawl`.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"config_path": {
+			MarkdownDescription: "The path to the kubeconfig file. Defaults to the `KUBECONFIG` environment variable or `~/.kube/config`, falling back to the in-cluster configuration.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"config_context": {
+			Description: "The context of the kubeconfig file to use. Defaults to the current context.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"api_version": {
+			MarkdownDescription: "The API version of the objects to read, such as `v1` or `apps/v1`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"kind": {
+			MarkdownDescription: "The kind of the objects to read, such as `ConfigMap`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"namespace": {
+			Description: "The namespace to read the objects from. Defaults to all namespaces. Must not be set for cluster-scoped kinds.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"label_selector": {
+			MarkdownDescription: "Only read the objects matching the label selector, such as `app.kubernetes.io/name=example`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+	},
+}
+
+type clusterSourceModel struct {
+	ConfigPath    types.String `tfsdk:"config_path"`
+	ConfigContext types.String `tfsdk:"config_context"`
+	APIVersion    types.String `tfsdk:"api_version"`
+	Kind          types.String `tfsdk:"kind"`
+	Namespace     types.String `tfsdk:"namespace"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+}
+
+// Attributes of the metadata that are managed by the cluster and prevent the objects from being re-applied
+var clusterManagedMetadata = []string{"creationTimestamp", "deletionGracePeriodSeconds", "deletionTimestamp", "generation", "managedFields", "ownerReferences", "resourceVersion", "selfLink", "uid"}
+
+// Annotations that are managed by the cluster or kubectl
+var clusterManagedAnnotations = []string{"kubectl.kubernetes.io/last-applied-configuration", "deployment.kubernetes.io/revision", "deprecated.daemonset.template.generation"}
+
+// Lists the objects of the kind from the cluster, returning them as a multi-document stream with the attributes
+// managed by the cluster removed
+func (f *fetcher) fetchCluster(ctx context.Context, config clusterSourceModel) (*fetchResponse, error) {
+	if f.provider != nil && f.provider.offline {
+		return nil, newFetchError("Error reading cluster", "cluster sources are unavailable in offline mode")
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if isSet(config.ConfigPath) {
+		rules.ExplicitPath = config.ConfigPath.Value
+	}
+	overrides := &clientcmd.ConfigOverrides{}
+	if isSet(config.ConfigContext) {
+		overrides.CurrentContext = config.ConfigContext.Value
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, newFetchError("Error loading cluster configuration", "%s", err)
+	}
+
+	groupVersion, err := schema.ParseGroupVersion(config.APIVersion.Value)
+	if err != nil {
+		return nil, newFetchError("Invalid API version", "%s", err)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, newFetchError("Error loading cluster configuration", "%s", err)
+	}
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion.String())
+	if err != nil {
+		return nil, newFetchError("Error discovering resources", "%s", err)
+	}
+
+	var resource *metav1.APIResource
+	for i := range resources.APIResources {
+		// Subresources share the kind of their parent
+		if candidate := &resources.APIResources[i]; candidate.Kind == config.Kind.Value && !strings.Contains(candidate.Name, "/") {
+			resource = candidate
+			break
+		}
+	}
+	if resource == nil {
+		return nil, newFetchError("Error discovering resources", "kind %s is not served by %s", config.Kind.Value, groupVersion)
+	}
+	if !resource.Namespaced && isSet(config.Namespace) {
+		return nil, newFetchError("Invalid namespace", "kind %s is not namespaced", config.Kind.Value)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, newFetchError("Error loading cluster configuration", "%s", err)
+	}
+
+	gvr := groupVersion.WithResource(resource.Name)
+	var client dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+	if resource.Namespaced {
+		client = dynamicClient.Resource(gvr).Namespace(config.Namespace.Value)
+	}
+
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: config.LabelSelector.Value})
+	if err != nil {
+		return nil, newFetchError("Error listing objects", "%s", err)
+	}
+
+	var documents [][]byte
+	for _, item := range list.Items {
+		// Lists omit the type of each item
+		item.SetAPIVersion(groupVersion.String())
+		item.SetKind(config.Kind.Value)
+
+		object := item.UnstructuredContent()
+		removeClusterManagedAttributes(object)
+
+		encoded, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, newFetchError("Error encoding object", "%s", err)
+		}
+		documents = append(documents, encoded)
+	}
+
+	return &fetchResponse{url: restConfig.Host, body: joinDocuments(documents)}, nil
+}
+
+// Removes the status and metadata managed by the cluster from the object
+func removeClusterManagedAttributes(object map[string]any) {
+	delete(object, "status")
+
+	metadata, ok := object["metadata"].(map[string]any)
+	if !ok {
+		return
+	}
+	for _, attribute := range clusterManagedMetadata {
+		delete(metadata, attribute)
+	}
+
+	if annotations, ok := metadata["annotations"].(map[string]any); ok {
+		for _, annotation := range clusterManagedAnnotations {
+			delete(annotations, annotation)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Cluster(t *testing.T) {
+	server, kubeconfig := setupMockCluster(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(clusterResourceStatement, kubeconfig, "v1", "ConfigMap", "default", "app=example"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", clusterConfigMap),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Cluster_UnknownKind(t *testing.T) {
+	server, kubeconfig := setupMockCluster(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(clusterResourceStatement, kubeconfig, "v1", "Secret", "default", ""),
+				ExpectError: regexp.MustCompile(`kind\s+Secret\s+is\s+not\s+served\s+by\s+v1`),
+			},
+		},
+	})
+}
+
+func TestDataSource_Cluster_NamespacedClusterScopedKind(t *testing.T) {
+	server, kubeconfig := setupMockCluster(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(clusterResourceStatement, kubeconfig, "v1", "Namespace", "default", ""),
+				ExpectError: regexp.MustCompile(`kind\s+Namespace\s+is\s+not\s+namespaced`),
+			},
+		},
+	})
+}
+
+// A TLS API server serving the configmaps in the default namespace, along with a kubeconfig file pointing to it
+func setupMockCluster(t *testing.T) (*httptest.Server, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cluster-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1":
+			_, _ = w.Write([]byte(`{"kind": "APIResourceList", "groupVersion": "v1", "resources": [
+				{"name": "configmaps", "namespaced": true, "kind": "ConfigMap", "verbs": ["get", "list"]},
+				{"name": "namespaces", "namespaced": false, "kind": "Namespace", "verbs": ["get", "list"]},
+				{"name": "namespaces/status", "namespaced": false, "kind": "Namespace", "verbs": ["get"]}
+			]}`))
+		case "/api/v1/namespaces/default/configmaps":
+			if r.URL.Query().Get("labelSelector") != "app=example" {
+				_, _ = w.Write([]byte(`{"kind": "ConfigMapList", "apiVersion": "v1", "items": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"kind": "ConfigMapList", "apiVersion": "v1", "metadata": {"resourceVersion": "100"}, "items": [{
+				"metadata": {
+					"name": "example",
+					"namespace": "default",
+					"uid": "6d3b1c1e-5a2f-4c1e-9a8e-1f2e3d4c5b6a",
+					"resourceVersion": "42",
+					"creationTimestamp": "2022-12-01T00:00:00Z",
+					"labels": {"app": "example"},
+					"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+					"managedFields": [{"manager": "kubectl", "operation": "Apply"}]
+				},
+				"data": {"key": "value"}
+			}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: test
+  user:
+    token: cluster-token
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+`, server.URL, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	if err := os.WriteFile(kubeconfig, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return server, kubeconfig
+}
+
+const clusterConfigMap = `apiVersion: v1
+data:
+  key: value
+kind: ConfigMap
+metadata:
+  labels:
+    app: example
+  name: example
+  namespace: default
+`
+
+const clusterResourceStatement = `
+data "manifest_fetch" "test" {
+	cluster {
+		config_path    = "%s"
+		api_version    = "%s"
+		kind           = "%s"
+		namespace      = "%s"
+		label_selector = "%s"
+	}
+}
+`
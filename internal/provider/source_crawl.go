@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/net/html"
+)
+
+var crawlSourceBlock = tfsdk.Block{
+	MarkdownDescription: "Retrieves the manifest(s) linked to from an index page, such as a directory listing. Conflicts with `url`, `urls`, `path`, `git`, `github_release`, and `cluster`.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"index_url": {
+			MarkdownDescription: "The URL of the index page. HTML pages are scanned for the `href` of each link, while JSON pages must contain an array of URLs, or of objects with a `url`, `href`, or `name` attribute. Relative links are resolved against the index URL.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"pattern": {
+			MarkdownDescription: "Only retrieve the links whose file name matches the shell pattern, such as `*.yaml`. Defaults to links ending in `.yaml`, `.yml`, or `.json`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"regex": {
+			Description: "Only retrieve the links whose resolved URL matches the regular expression. Conflicts with pattern.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+	},
+}
+
+type crawlSourceModel struct {
+	IndexURL types.String `tfsdk:"index_url"`
+	Pattern  types.String `tfsdk:"pattern"`
+	Regex    types.String `tfsdk:"regex"`
+}
+
+// Attributes of the objects within a JSON index that may contain a link, in order of preference
+var jsonIndexLinkAttributes = []string{"url", "href", "name"}
+
+// Fetches the index page and each matching link in the order they appear, joining them into a single multi-document
+// stream
+func (f *fetcher) fetchCrawl(ctx context.Context, config crawlSourceModel) (*fetchResponse, error) {
+	if isSet(config.Pattern) && isSet(config.Regex) {
+		return nil, newFetchError("Invalid crawl configuration", "only one of pattern or regex may be set")
+	}
+
+	match := func(link *neturl.URL) bool {
+		return contains(manifestExtensions, strings.ToLower(path.Ext(link.Path)))
+	}
+	if isSet(config.Pattern) {
+		if _, err := path.Match(config.Pattern.Value, ""); err != nil {
+			return nil, newFetchError("Invalid pattern", "%s", err)
+		}
+		match = func(link *neturl.URL) bool {
+			matched, _ := path.Match(config.Pattern.Value, path.Base(link.Path))
+			return matched
+		}
+	} else if isSet(config.Regex) {
+		expression, err := regexp.Compile(config.Regex.Value)
+		if err != nil {
+			return nil, newFetchError("Invalid regex", "%s", err)
+		}
+		match = func(link *neturl.URL) bool {
+			return expression.MatchString(link.String())
+		}
+	}
+
+	index, err := f.fetch(ctx, config.IndexURL.Value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := neturl.Parse(index.url)
+	if err != nil {
+		return nil, newFetchError("Invalid URL", "%s", err)
+	}
+
+	links, err := extractLinks(index.body)
+	if err != nil {
+		return nil, newFetchError("Error parsing index", "%s", err)
+	}
+
+	var matched []string
+	seen := map[string]bool{}
+	for _, link := range links {
+		reference, err := neturl.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		resolved := base.ResolveReference(reference)
+		resolved.Fragment = ""
+		if seen[resolved.String()] || !match(resolved) {
+			continue
+		}
+		seen[resolved.String()] = true
+		matched = append(matched, resolved.String())
+	}
+
+	if len(matched) == 0 {
+		return nil, newFetchError("Error crawling index", "no links on %s matched", config.IndexURL.Value)
+	}
+
+	files := make([]archiveFile, len(matched))
+	errs := make([]error, len(matched))
+	forEachConcurrently(len(matched), f.parallelism, func(i int) {
+		var response *fetchResponse
+		if response, errs[i] = f.fetch(ctx, matched[i], nil); response != nil {
+			files[i] = archiveFile{name: matched[i], content: response.body}
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, newFetchError("Error fetching linked manifest", "%s: %s", matched[i], err)
+		}
+	}
+
+	return &fetchResponse{url: index.url, body: joinArchiveFiles(files), files: files}, nil
+}
+
+// Extracts the links from a JSON or HTML index page in the order they appear
+func extractLinks(body []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []any
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, err
+		}
+
+		var links []string
+		for _, entry := range entries {
+			switch entry := entry.(type) {
+			case string:
+				links = append(links, entry)
+			case map[string]any:
+				for _, attribute := range jsonIndexLinkAttributes {
+					if link, ok := entry[attribute].(string); ok {
+						links = append(links, link)
+						break
+					}
+				}
+			default:
+				return nil, fmt.Errorf("unexpected index entry %v", entry)
+			}
+		}
+		return links, nil
+	}
+
+	var links []string
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttributes := tokenizer.TagName()
+			if string(name) != "a" {
+				continue
+			}
+
+			for hasAttributes {
+				var key, value []byte
+				key, value, hasAttributes = tokenizer.TagAttr()
+				if string(key) == "href" {
+					links = append(links, string(value))
+				}
+			}
+		}
+	}
+}
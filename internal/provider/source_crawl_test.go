@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Crawl_HTML(t *testing.T) {
+	server := setupMockIndex()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(crawlResourceStatement, server.URL+"/components/"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "4"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.3", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file."+server.URL+"/components/multiple.yaml.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file."+server.URL+"/components/single.yml.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Crawl_JSON(t *testing.T) {
+	server := setupMockIndex()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(crawlResourceStatement, server.URL+"/components/index.json"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Crawl_Pattern(t *testing.T) {
+	server := setupMockIndex()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(crawlPatternResourceStatement, server.URL+"/components/", "pattern", "single*"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+			{
+				Config: fmt.Sprintf(crawlPatternResourceStatement, server.URL+"/components/", "regex", "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Crawl_NoMatches(t *testing.T) {
+	server := setupMockIndex()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(crawlPatternResourceStatement, server.URL+"/components/", "pattern", "*.json"),
+				ExpectError: regexp.MustCompile(`no\s+links\s+on`),
+			},
+		},
+	})
+}
+
+func TestFetchCrawl_Files(t *testing.T) {
+	server := setupMockIndex()
+	defer server.Close()
+
+	response, err := newFetcher(nil).fetchCrawl(context.Background(), crawlSourceModel{IndexURL: types.String{Value: server.URL + "/components/"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var names []string
+	for _, file := range response.files {
+		names = append(names, file.name)
+	}
+	if expected := []string{server.URL + "/components/multiple.yaml", server.URL + "/components/single.yml"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected files %q, got %q", expected, names)
+	}
+	if expected := multipleDocuments + "---\n" + singleDocument; string(response.body) != expected {
+		t.Errorf("expected %q, got %q", expected, response.body)
+	}
+}
+
+// A directory listing linking to manifests with both relative and absolute links, alongside a JSON index
+func setupMockIndex() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/components/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body>
+				<a href="../">Parent Directory</a>
+				<a href="multiple.yaml">multiple.yaml</a>
+				<a href="/components/single.yml">single.yml</a>
+				<a href="multiple.yaml#duplicate">multiple.yaml</a>
+				<a href="README.txt">README.txt</a>
+			</body></html>`))
+		case "/components/index.json":
+			_, _ = w.Write([]byte(`[{"name": "single.yml"}, "README.txt"]`))
+		case "/components/multiple.yaml":
+			_, _ = w.Write([]byte(multipleDocuments))
+		case "/components/single.yml":
+			_, _ = w.Write([]byte(singleDocument))
+		case "/components/README.txt":
+			_, _ = w.Write([]byte("Not a manifest\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+const crawlResourceStatement = `
+data "manifest_fetch" "test" {
+	crawl {
+		index_url = "%s"
+	}
+}
+`
+
+const crawlPatternResourceStatement = `
+data "manifest_fetch" "test" {
+	crawl {
+		index_url = "%s"
+		%s        = "%s"
+	}
+}
+`
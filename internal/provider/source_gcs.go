@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// The endpoint of the Cloud Storage JSON API used when none is configured by the URL
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// The scope requested for Application Default Credentials
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// Retrieves the object referenced by a gs://bucket/object URL, using Application Default Credentials. The endpoint
+// and generation of the object can be set with the `endpoint` and `generation` query parameters.
+func (f *fetcher) fetchGCS(ctx context.Context, url *neturl.URL) (*fetchResponse, error) {
+	bucket, object := url.Host, strings.TrimPrefix(url.Path, "/")
+	if bucket == "" || object == "" {
+		return nil, newFetchError("Invalid URL", "GCS URLs must be in the format gs://bucket/object, got %q", url.String())
+	}
+
+	credentials, err := google.FindDefaultCredentials(ctx, gcsReadOnlyScope)
+	if err != nil {
+		return nil, newFetchError("Error loading Google Cloud credentials", "%s", err)
+	}
+
+	query := url.Query()
+
+	endpoint := defaultGCSEndpoint
+	if query.Has("endpoint") {
+		endpoint = strings.TrimSuffix(query.Get("endpoint"), "/")
+	}
+
+	parameters := neturl.Values{"alt": {"media"}}
+	if generation := query.Get("generation"); generation != "" {
+		parameters.Set("generation", generation)
+	}
+	target := endpoint + "/storage/v1/b/" + neturl.PathEscape(bucket) + "/o/" + neturl.PathEscape(object) + "?" + parameters.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, newFetchError("Error creating request", "%s", err)
+	}
+
+	client := &http.Client{
+		Transport: &oauth2.Transport{Source: credentials.TokenSource, Base: f.provider.baseTransport()},
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, newFetchError("Error retrieving object", "%s", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, newFetchError("Error reading object", "%s", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var apiError struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error.Message != "" {
+			return nil, newFetchError("Error retrieving object", "Received non-success response code %d: %s", response.StatusCode, apiError.Error.Message)
+		}
+		return nil, newFetchError("Error retrieving object", "Received non-success response code: %d", response.StatusCode)
+	}
+
+	return &fetchResponse{url: url.String(), body: body}, nil
+}
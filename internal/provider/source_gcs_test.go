@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_GCS(t *testing.T) {
+	server := setupMockGCS(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, "gs://manifests", "rendered/multiple.yaml?endpoint="+server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_GCS_Generation(t *testing.T) {
+	server := setupMockGCS(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, "gs://manifests", "rendered/multiple.yaml?generation=1&endpoint="+server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_GCS_NotFound(t *testing.T) {
+	server := setupMockGCS(t)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, "gs://manifests", "missing.yaml?endpoint="+server.URL),
+				ExpectError: regexp.MustCompile(`No\s+such\s+object`),
+			},
+		},
+	})
+}
+
+// A Cloud Storage endpoint serving a single object with two generations, along with a token endpoint for the service
+// account configured as the Application Default Credentials
+func setupMockGCS(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "gcs-token", "token_type": "Bearer", "expires_in": 3600}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer gcs-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.EscapedPath() != "/storage/v1/b/manifests/o/rendered%2Fmultiple.yaml" || r.URL.Query().Get("alt") != "media" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": 404, "message": "No such object: manifests/missing.yaml"}}`))
+			return
+		}
+
+		if r.URL.Query().Get("generation") == "1" {
+			_, _ = w.Write([]byte(singleDocument))
+		} else {
+			_, _ = w.Write([]byte(multipleDocuments))
+		}
+	}))
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "example",
+		"private_key_id": "test",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"client_email":   "manifests@example.iam.gserviceaccount.com",
+		"token_uri":      server.URL + "/token",
+	})
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, credentials, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+
+	return server
+}
@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var gitSourceBlock = tfsdk.Block{
+	MarkdownDescription: "Retrieves the manifest(s) from a Git repository, pinned to a branch, tag, or commit. Requires the `git` executable to be installed. Conflicts with `url`, `urls`, `path`, `github_release`, `cluster`, and `crawl`.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"repository": {
+			MarkdownDescription: "The URL of the repository to clone, such as `https://github.com/example/project.git` or `git@github.com:example/project.git`.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"ref": {
+			MarkdownDescription: "The branch, tag, or commit to retrieve. Defaults to the default branch of the repository.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"path": {
+			MarkdownDescription: "The path within the repository of the manifest file, or of a directory whose `.yaml`, `.yml`, and `.json` files are all retrieved in lexical order. Defaults to the root of the repository.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"username": {
+			Description: "The username to authenticate with over HTTP(S).",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"password": {
+			Description: "The password or access token to authenticate with over HTTP(S).",
+			Type:        types.StringType,
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"ssh_private_key": {
+			Description: "The PEM-encoded private key to authenticate with over SSH.",
+			Type:        types.StringType,
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"accept_new_host_keys": {
+			MarkdownDescription: "Whether the SSH host keys of hosts which aren't known yet are trusted and added to the known hosts, as with `StrictHostKeyChecking=accept-new`. Defaults to `false`, in which case the host key must already be known.",
+			Type:                types.BoolType,
+			Optional:            true,
+		},
+	},
+}
+
+type gitSourceModel struct {
+	Repository        types.String `tfsdk:"repository"`
+	Ref               types.String `tfsdk:"ref"`
+	Path              types.String `tfsdk:"path"`
+	Username          types.String `tfsdk:"username"`
+	Password          types.String `tfsdk:"password"`
+	SSHPrivateKey     types.String `tfsdk:"ssh_private_key"`
+	AcceptNewHostKeys types.Bool   `tfsdk:"accept_new_host_keys"`
+}
+
+// Extensions of the files retrieved from a directory
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// Shallowly fetches the ref from the repository and reads the manifest(s) at the path. The result is cached by the
+// repository, ref, path, and username, as for the other sources.
+func (f *fetcher) fetchGit(ctx context.Context, config gitSourceModel) (*fetchResponse, error) {
+	query := neturl.Values{}
+	if isSet(config.Ref) {
+		query.Set("ref", config.Ref.Value)
+	}
+	if isSet(config.Path) {
+		query.Set("path", config.Path.Value)
+	}
+	if isSet(config.Username) {
+		query.Set("username", config.Username.Value)
+	}
+	key := (&neturl.URL{Scheme: "git", Opaque: config.Repository.Value, RawQuery: query.Encode()}).String()
+
+	return f.fetchCached(ctx, key, func() (*fetchResponse, error) { return f.cloneGit(ctx, config) })
+}
+
+// Shallowly fetches the ref from the repository and reads the manifest(s) at the path, bypassing the cache
+func (f *fetcher) cloneGit(ctx context.Context, config gitSourceModel) (*fetchResponse, error) {
+	dir, err := os.MkdirTemp("", "terraform-provider-manifest-git-")
+	if err != nil {
+		return nil, newFetchError("Error fetching repository", "%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	env, err := gitEnvironment(config, dir)
+	if err != nil {
+		return nil, newFetchError("Error fetching repository", "%s", err)
+	}
+
+	ref := "HEAD"
+	if isSet(config.Ref) {
+		ref = config.Ref.Value
+	}
+
+	worktree := filepath.Join(dir, "repository")
+	commands := [][]string{
+		{"init", "--quiet", worktree},
+		{"-C", worktree, "fetch", "--quiet", "--depth", "1", "--", config.Repository.Value, ref},
+		{"-C", worktree, "checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range commands {
+		if err := runGit(ctx, env, args...); err != nil {
+			return nil, newFetchError("Error fetching repository", "%s", err)
+		}
+	}
+
+	target := worktree
+	if isSet(config.Path) {
+		target = filepath.Join(worktree, filepath.FromSlash(config.Path.Value))
+
+		// Prevent escaping the repository through relative paths
+		if relative, err := filepath.Rel(worktree, target); err != nil || strings.HasPrefix(relative, "..") {
+			return nil, newFetchError("Error reading repository", "path %q is outside of the repository", config.Path.Value)
+		}
+	}
+
+	files, err := readManifestFiles(target, worktree)
+	if err != nil {
+		return nil, newFetchError("Error reading repository", "%s", err)
+	}
+
+	return &fetchResponse{url: config.Repository.Value, body: joinArchiveFiles(files), files: files}, nil
+}
+
+// Builds the environment for git, configuring authentication without exposing credentials in the arguments
+func gitEnvironment(config gitSourceModel, dir string) ([]string, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if isSet(config.Username) || isSet(config.Password) {
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Username.Value + ":" + config.Password.Value))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+
+	var options []string
+	if isSet(config.SSHPrivateKey) {
+		key := filepath.Join(dir, "id")
+		if err := os.WriteFile(key, []byte(strings.TrimSpace(config.SSHPrivateKey.Value)+"\n"), 0o600); err != nil {
+			return nil, err
+		}
+		options = append(options, fmt.Sprintf("-i %q", key), "-o IdentitiesOnly=yes")
+	}
+	if config.AcceptNewHostKeys.Value {
+		options = append(options, "-o StrictHostKeyChecking=accept-new")
+	}
+	if len(options) > 0 {
+		// Unknown host keys fail the fetch rather than prompting for confirmation, unless they're accepted
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes "+strings.Join(options, " "))
+	}
+
+	return env, nil
+}
+
+func runGit(ctx context.Context, env []string, args ...string) error {
+	var stderr bytes.Buffer
+
+	command := exec.CommandContext(ctx, "git", args...)
+	command.Env = env
+	command.Stderr = &stderr
+
+	if err := command.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Reads the file at the path, or every manifest file within the directory in lexical order. Files within a directory
+// are named by their path relative to it. When the root is set, files which resolve outside of it through symlinks are
+// rejected, as a cloned repository may link to any file of the host.
+func readManifestFiles(path, root string) ([]archiveFile, error) {
+	if err := checkWithinRoot(root, path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []archiveFile{{name: filepath.Base(path), content: content}}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() && contains(manifestExtensions, strings.ToLower(filepath.Ext(file))) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var manifests []archiveFile
+	for _, file := range files {
+		if err := checkWithinRoot(root, file); err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		relative, err := filepath.Rel(path, file)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, archiveFile{name: filepath.ToSlash(relative), content: content})
+	}
+
+	return manifests, nil
+}
+
+// Checks the path, once its symlinks are resolved, is within the root, unless the root is empty
+func checkWithinRoot(root, path string) error {
+	if root == "" {
+		return nil
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if relative, err := filepath.Rel(resolvedRoot, resolved); err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		name, _ := filepath.Rel(root, path)
+		return fmt.Errorf("%q links outside of the repository", filepath.ToSlash(name))
+	}
+	return nil
+}
+
+// Joins the contents of multiple files into a single multi-document YAML stream
+func joinDocuments(documents [][]byte) []byte {
+	var joined bytes.Buffer
+
+	for i, document := range documents {
+		if i > 0 {
+			joined.WriteString("---\n")
+		}
+
+		joined.Write(document)
+		if len(document) > 0 && document[len(document)-1] != '\n' {
+			joined.WriteByte('\n')
+		}
+	}
+
+	return joined.Bytes()
+}
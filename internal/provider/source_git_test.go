@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Git_Directory(t *testing.T) {
+	repository := setupGitRepository(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(gitResourceStatement, repository, "v1", "deploy"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "2"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.a.yaml.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.b.yml.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Git_File(t *testing.T) {
+	repository := setupGitRepository(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(gitResourceStatement, repository, "main", "deploy/a.yaml"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument2),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Git_OutsideRepository(t *testing.T) {
+	repository := setupGitRepository(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(gitResourceStatement, repository, "main", "../outside.yaml"),
+				ExpectError: regexp.MustCompile("is outside of the repository"),
+			},
+		},
+	})
+}
+
+func TestReadManifestFiles_Symlinks(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(outside, []byte("secret: value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, "a.yaml"), []byte(singleDocument), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.yaml", filepath.Join(worktree, "b.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := readManifestFiles(worktree, worktree)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 2 || files[1].name != "b.yaml" || string(files[1].content) != singleDocument {
+		t.Errorf("expected the link within the repository to be read, got %v", files)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(worktree, "deploy.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{worktree, filepath.Join(worktree, "deploy.yaml")} {
+		if _, err := readManifestFiles(path, worktree); err == nil || err.Error() != `"deploy.yaml" links outside of the repository` {
+			t.Errorf("expected an error for the link outside of the repository, got %v", err)
+		}
+	}
+	if _, err := readManifestFiles(worktree, ""); err != nil {
+		t.Errorf("expected links to be followed without a root, got %s", err)
+	}
+}
+
+func TestGitEnvironment_SSH(t *testing.T) {
+	dir := t.TempDir()
+	// The last assignment takes precedence over any inherited from the environment
+	sshCommand := func(env []string) (command string) {
+		for _, variable := range env {
+			if value, ok := strings.CutPrefix(variable, "GIT_SSH_COMMAND="); ok {
+				command = value
+			}
+		}
+		return command
+	}
+
+	env, err := gitEnvironment(gitSourceModel{SSHPrivateKey: types.String{Value: "key"}}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if command := sshCommand(env); command != fmt.Sprintf("ssh -o BatchMode=yes -i %q -o IdentitiesOnly=yes", filepath.Join(dir, "id")) {
+		t.Errorf("expected unknown host keys to be rejected, got %q", command)
+	}
+
+	env, err = gitEnvironment(gitSourceModel{AcceptNewHostKeys: types.Bool{Value: true}}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if command := sshCommand(env); command != "ssh -o BatchMode=yes -o StrictHostKeyChecking=accept-new" {
+		t.Errorf("expected unknown host keys to be accepted, got %q", command)
+	}
+}
+
+// Creates a repository with a v1 tag, followed by a commit on main updating one of the manifests
+func setupGitRepository(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		command := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, output)
+		}
+	}
+	write := func(path, content string) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "--quiet", "--initial-branch", "main")
+	write("README.md", "# Manifests\n")
+	write("deploy/a.yaml", multipleDocument1)
+	write("deploy/b.yml", singleDocument)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Initial commit")
+	git("tag", "v1")
+
+	write("deploy/a.yaml", multipleDocument2)
+	git("commit", "--quiet", "-am", "Update manifest")
+
+	return "file://" + filepath.ToSlash(dir)
+}
+
+const gitResourceStatement = `
+data "manifest_fetch" "test" {
+	git {
+		repository = "%s"
+		ref        = "%s"
+		path       = "%s"
+	}
+}
+`
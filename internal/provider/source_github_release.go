@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// The default base URL of the GitHub REST API
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// The tag that resolves to the most recent non-prerelease, non-draft release
+const latestReleaseTag = "latest"
+
+var githubReleaseSourceBlock = tfsdk.Block{
+	MarkdownDescription: "Retrieves the manifest(s) from the assets of a GitHub release. Conflicts with `url`, `urls`, `path`, `git`, `cluster`, and `crawl`.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"owner": {
+			Description: "The user or organization owning the repository.",
+			Type:        types.StringType,
+			Required:    true,
+		},
+		"repository": {
+			Description: "The name of the repository.",
+			Type:        types.StringType,
+			Required:    true,
+		},
+		"tag": {
+			MarkdownDescription: "The tag of the release to retrieve, or `latest` for the most recent release. Defaults to `latest`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"asset": {
+			MarkdownDescription: "The name of the asset to retrieve, which may contain shell patterns such as `*.yaml`. When multiple assets match, they are all retrieved in lexical order.",
+			Type:                types.StringType,
+			Required:            true,
+		},
+		"token": {
+			MarkdownDescription: "The token to authenticate with, required for private repositories. Defaults to the `GITHUB_TOKEN` environment variable.",
+			Type:                types.StringType,
+			Optional:            true,
+			Sensitive:           true,
+		},
+		"api_url": {
+			MarkdownDescription: "The base URL of the GitHub REST API, for GitHub Enterprise Server. Defaults to `https://api.github.com`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"resolved_tag": {
+			MarkdownDescription: "The tag of the release the assets were retrieved from. When `tag` is `latest`, this tracks the most recent release.",
+			Type:                types.StringType,
+			Computed:            true,
+		},
+	},
+}
+
+type githubReleaseSourceModel struct {
+	Owner       types.String `tfsdk:"owner"`
+	Repository  types.String `tfsdk:"repository"`
+	Tag         types.String `tfsdk:"tag"`
+	Asset       types.String `tfsdk:"asset"`
+	Token       types.String `tfsdk:"token"`
+	APIURL      types.String `tfsdk:"api_url"`
+	ResolvedTag types.String `tfsdk:"resolved_tag"`
+}
+
+type githubRelease struct {
+	TagName string               `json:"tag_name"`
+	Assets  []githubReleaseAsset `json:"assets"`
+}
+
+type githubReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Resolves the release and downloads each of its assets matching the pattern, returning the resolved tag alongside
+// the joined contents
+func (f *fetcher) fetchGitHubRelease(ctx context.Context, config githubReleaseSourceModel) (*fetchResponse, string, error) {
+	if _, err := path.Match(config.Asset.Value, ""); err != nil {
+		return nil, "", newFetchError("Invalid asset pattern", "%s", err)
+	}
+
+	apiURL := defaultGitHubAPIURL
+	if isSet(config.APIURL) {
+		apiURL = strings.TrimSuffix(config.APIURL.Value, "/")
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if isSet(config.Token) {
+		token = config.Token.Value
+	}
+
+	releaseURL := fmt.Sprintf("%s/repos/%s/%s/releases/", apiURL, neturl.PathEscape(config.Owner.Value), neturl.PathEscape(config.Repository.Value))
+	if !isSet(config.Tag) || config.Tag.Value == latestReleaseTag {
+		releaseURL += latestReleaseTag
+	} else {
+		releaseURL += "tags/" + neturl.PathEscape(config.Tag.Value)
+	}
+
+	raw, err := f.githubRequest(ctx, releaseURL, "application/vnd.github+json", token)
+	if err != nil {
+		return nil, "", newFetchError("Error resolving release", "%s", err)
+	}
+
+	var release githubRelease
+	if err := json.Unmarshal(raw, &release); err != nil {
+		return nil, "", newFetchError("Error resolving release", "%s", err)
+	}
+
+	var assets []githubReleaseAsset
+	for _, asset := range release.Assets {
+		if matched, _ := path.Match(config.Asset.Value, asset.Name); matched {
+			assets = append(assets, asset)
+		}
+	}
+	if len(assets) == 0 {
+		return nil, "", newFetchError("Error retrieving asset", "no assets of release %s match %q", release.TagName, config.Asset.Value)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Name < assets[j].Name })
+
+	files := make([]archiveFile, len(assets))
+	errs := make([]error, len(assets))
+	forEachConcurrently(len(assets), f.parallelism, func(i int) {
+		files[i].name = assets[i].Name
+		files[i].content, errs[i] = f.githubRequest(ctx, assets[i].URL, "application/octet-stream", token)
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, "", newFetchError("Error retrieving asset", "%s: %s", assets[i].Name, err)
+		}
+	}
+
+	return &fetchResponse{url: releaseURL, body: joinArchiveFiles(files), files: files}, release.TagName, nil
+}
+
+// Makes an optionally authenticated request against the GitHub API, ensuring it responded successfully
+func (f *fetcher) githubRequest(ctx context.Context, url, accept, token string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", accept)
+	request.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &apiError); err == nil && apiError.Message != "" {
+			return nil, fmt.Errorf("received non-success response code %d: %s", response.StatusCode, apiError.Message)
+		}
+		return nil, fmt.Errorf("received non-success response code: %d", response.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_GitHubRelease_Latest(t *testing.T) {
+	server := setupMockGitHub()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(githubReleaseResourceStatement, server.URL, "latest", "*.yaml"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "github_release.0.resolved_tag", "v2.0.0"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "4"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_GitHubRelease_Tag(t *testing.T) {
+	server := setupMockGitHub()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(githubReleaseResourceStatement, server.URL, "v1.0.0", "install.yaml"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "github_release.0.resolved_tag", "v1.0.0"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_GitHubRelease_NoMatchingAsset(t *testing.T) {
+	server := setupMockGitHub()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(githubReleaseResourceStatement, server.URL, "latest", "*.json"),
+				ExpectError: regexp.MustCompile(`no\s+assets\s+of\s+release\s+v2.0.0\s+match`),
+			},
+		},
+	})
+}
+
+// A GitHub API serving two releases of example/manifests, requiring a token to download assets
+func setupMockGitHub() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer github-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/repos/example/manifests/releases/latest":
+			_, _ = fmt.Fprintf(w, `{"tag_name": "v2.0.0", "assets": [
+				{"name": "install.yaml", "url": "%[1]s/assets/1"},
+				{"name": "crds.yaml", "url": "%[1]s/assets/2"},
+				{"name": "checksums.txt", "url": "%[1]s/assets/3"}
+			]}`, server.URL)
+		case "/repos/example/manifests/releases/tags/v1.0.0":
+			_, _ = fmt.Fprintf(w, `{"tag_name": "v1.0.0", "assets": [{"name": "install.yaml", "url": "%s/assets/4"}]}`, server.URL)
+		case "/assets/1":
+			_, _ = w.Write([]byte(multipleDocuments))
+		case "/assets/2", "/assets/4":
+			_, _ = w.Write([]byte(singleDocument))
+		case "/assets/3":
+			_, _ = w.Write([]byte("0000  install.yaml\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	return server
+}
+
+const githubReleaseResourceStatement = `
+data "manifest_fetch" "test" {
+	github_release {
+		owner      = "example"
+		repository = "manifests"
+		api_url    = "%s"
+		tag        = "%s"
+		asset      = "%s"
+		token      = "github-token"
+	}
+}
+`
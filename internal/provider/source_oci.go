@@ -0,0 +1,556 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// The media type of the layer containing the archive of a Helm chart
+	mediaTypeHelmChart = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// The annotation containing the file name of a layer pushed by ORAS
+	annotationTitle = "org.opencontainers.image.title"
+)
+
+var manifestMediaTypes = []string{mediaTypeOCIManifest, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeDockerList}
+
+// A reference to an artifact in a registry, such as ghcr.io/example/manifests:v1.0.0
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// Parses the reference using the same defaults as Docker, where references without a registry are resolved against
+// Docker Hub and references without a tag or digest refer to the latest tag
+func parseImageReference(raw string) (imageReference, error) {
+	var ref imageReference
+
+	remainder := raw
+	if name, digest, ok := strings.Cut(remainder, "@"); ok {
+		remainder, ref.digest = name, digest
+	}
+
+	// The tag is after the last colon, so long as it isn't part of the registry's port
+	if index := strings.LastIndex(remainder, ":"); index > strings.LastIndex(remainder, "/") {
+		remainder, ref.tag = remainder[:index], remainder[index+1:]
+	}
+
+	registry, repository, ok := strings.Cut(remainder, "/")
+	if !ok || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry, repository = "docker.io", remainder
+	}
+	if registry == "docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	if repository == "" {
+		return ref, fmt.Errorf("invalid reference %q", raw)
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+
+	ref.registry, ref.repository = registry, repository
+	return ref, nil
+}
+
+// The tag or digest identifying the manifest, preferring the digest
+func (r imageReference) reference() string {
+	if r.digest != "" {
+		return r.digest
+	}
+	return r.tag
+}
+
+func (r imageReference) String() string {
+	name := r.registry + "/" + r.repository
+	if r.tag != "" {
+		name += ":" + r.tag
+	}
+	if r.digest != "" {
+		name += "@" + r.digest
+	}
+	return name
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// Credentials for authenticating with a registry
+type registryCredentials struct {
+	username string
+	password string
+}
+
+// A minimal client for the OCI distribution API, supporting anonymous, basic, and token authentication
+type registryClient struct {
+	client      *http.Client
+	credentials map[string]registryCredentials
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func newRegistryClient(transport http.RoundTripper, credentials map[string]registryCredentials) *registryClient {
+	return &registryClient{
+		client:      &http.Client{Transport: transport},
+		credentials: credentials,
+		tokens:      make(map[string]string),
+	}
+}
+
+// Retrieves the manifest for the reference, returning its raw content and digest
+func (c *registryClient) manifest(ctx context.Context, ref imageReference) ([]byte, string, error) {
+	response, err := c.do(ctx, ref, http.MethodGet, "manifests/"+ref.reference(), manifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := "sha256:" + sha256Hex(body)
+	if ref.digest != "" && ref.digest != digest {
+		return nil, "", fmt.Errorf("manifest digest %s does not match the expected digest %s", digest, ref.digest)
+	}
+
+	return body, digest, nil
+}
+
+// Resolves the digest of the manifest for the reference without downloading it
+func (c *registryClient) resolve(ctx context.Context, ref imageReference) (string, error) {
+	response, err := c.do(ctx, ref, http.MethodHead, "manifests/"+ref.reference(), manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+
+	if digest := response.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Not all registries return the digest, so fall back to computing it
+	_, digest, err := c.manifest(ctx, ref)
+	return digest, err
+}
+
+// Retrieves the blob from the repository, verifying its digest
+func (c *registryClient) blob(ctx context.Context, ref imageReference, digest string) ([]byte, error) {
+	response, err := c.do(ctx, ref, http.MethodGet, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if actual := "sha256:" + sha256Hex(body); actual != digest {
+		return nil, fmt.Errorf("blob digest %s does not match the expected digest %s", actual, digest)
+	}
+
+	return body, nil
+}
+
+// Lists the tags of the repository
+func (c *registryClient) tags(ctx context.Context, ref imageReference) ([]string, error) {
+	response, err := c.do(ctx, ref, http.MethodGet, "tags/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to parse tags of %s/%s: %w", ref.registry, ref.repository, err)
+	}
+	return list.Tags, nil
+}
+
+// Makes the request against the repository, authenticating if challenged
+func (c *registryClient) do(ctx context.Context, ref imageReference, method, resource string, accept []string) (*http.Response, error) {
+	url := fmt.Sprintf("%s://%s/v2/%s/%s", registryScheme(ref.registry), registryHost(ref.registry), ref.repository, resource)
+
+	send := func(authorization string) (*http.Response, error) {
+		request, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(accept) > 0 {
+			request.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+
+		return c.client.Do(request)
+	}
+
+	scope := "repository:" + ref.repository + ":pull"
+
+	c.mu.Lock()
+	authorization := c.tokens[ref.registry+" "+scope]
+	c.mu.Unlock()
+
+	response, err := send(authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		response.Body.Close()
+
+		authorization, err = c.authorize(ctx, ref.registry, challenge, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.tokens[ref.registry+" "+scope] = authorization
+		c.mu.Unlock()
+
+		response, err = send(authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("received non-success response code %d for %s", response.StatusCode, url)
+	}
+
+	return response, nil
+}
+
+// Responds to the authentication challenge, returning the value of the Authorization header to use
+func (c *registryClient) authorize(ctx context.Context, registry, challenge, scope string) (string, error) {
+	credentials, hasCredentials := c.lookupCredentials(registry)
+
+	scheme, rawParameters, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredentials {
+			return "", fmt.Errorf("registry %s requires authentication, but no credentials were found", registry)
+		}
+		return basicAuthorization(credentials), nil
+
+	case "bearer":
+		parameters := parseChallengeParameters(rawParameters)
+
+		realm, err := neturl.Parse(parameters["realm"])
+		if err != nil || parameters["realm"] == "" {
+			return "", fmt.Errorf("registry %s sent an invalid authentication challenge", registry)
+		}
+
+		query := realm.Query()
+		if service := parameters["service"]; service != "" {
+			query.Set("service", service)
+		}
+		if challengeScope := parameters["scope"]; challengeScope != "" {
+			scope = challengeScope
+		}
+		query.Set("scope", scope)
+		realm.RawQuery = query.Encode()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCredentials {
+			request.Header.Set("Authorization", basicAuthorization(credentials))
+		}
+
+		response, err := c.client.Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("failed to retrieve token for registry %s: received response code %d", registry, response.StatusCode)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to parse token for registry %s: %w", registry, err)
+		}
+
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("registry %s requested unsupported authentication scheme %q", registry, scheme)
+	}
+}
+
+// Finds credentials for the registry, preferring those configured on the provider over the Docker credential store
+func (c *registryClient) lookupCredentials(registry string) (registryCredentials, bool) {
+	if credentials, ok := c.credentials[registry]; ok {
+		return credentials, true
+	}
+
+	return dockerCredentials(registry)
+}
+
+// Reads credentials for the registry from the Docker configuration, including its credential helpers
+func dockerCredentials(registry string) (registryCredentials, bool) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return registryCredentials{}, false
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return registryCredentials{}, false
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+		CredsStore  string            `json:"credsStore"`
+		CredHelpers map[string]string `json:"credHelpers"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return registryCredentials{}, false
+	}
+
+	// Docker Hub credentials are stored under its legacy index address
+	keys := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == "docker.io" {
+		keys = append(keys, "https://index.docker.io/v1/", "index.docker.io")
+	}
+
+	helper := config.CredsStore
+	if specific, ok := config.CredHelpers[registry]; ok {
+		helper = specific
+	}
+	if helper != "" {
+		if credentials, ok := credentialHelper(helper, keys[0]); ok {
+			return credentials, true
+		}
+	}
+
+	for _, key := range keys {
+		if auth, ok := config.Auths[key]; ok && auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				continue
+			}
+
+			username, password, _ := strings.Cut(string(decoded), ":")
+			return registryCredentials{username, password}, true
+		}
+	}
+
+	return registryCredentials{}, false
+}
+
+// Retrieves credentials from a Docker credential helper, such as docker-credential-ecr-login
+func credentialHelper(helper, registry string) (registryCredentials, bool) {
+	command := exec.Command("docker-credential-"+helper, "get")
+	command.Stdin = strings.NewReader(registry)
+
+	output, err := command.Output()
+	if err != nil {
+		return registryCredentials{}, false
+	}
+
+	var credentials struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(output, &credentials); err != nil {
+		return registryCredentials{}, false
+	}
+
+	return registryCredentials{credentials.Username, credentials.Secret}, true
+}
+
+func basicAuthorization(credentials registryCredentials) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials.username+":"+credentials.password))
+}
+
+// Parses the comma-separated key="value" parameters of a WWW-Authenticate challenge
+func parseChallengeParameters(raw string) map[string]string {
+	parameters := make(map[string]string)
+
+	for raw != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(raw, ", "), "=")
+		if !ok {
+			break
+		}
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, raw = rest[1:end+1], rest[end+2:]
+		} else {
+			value, raw, _ = strings.Cut(rest, ",")
+		}
+
+		parameters[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return parameters
+}
+
+// Docker Hub's API is served from a different host than its references use
+func registryHost(registry string) string {
+	if registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return registry
+}
+
+// Registries on the loopback interface are accessed over plain HTTP, matching the behavior of Docker
+func registryScheme(registry string) string {
+	host := registry
+	if hostname, _, err := net.SplitHostPort(registry); err == nil {
+		host = hostname
+	}
+
+	if host == "localhost" {
+		return "http"
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "http"
+	}
+	return "https"
+}
+
+// Pulls the artifact referenced by the oci:// URL and joins the manifests contained in its layers
+func (f *fetcher) fetchOCI(ctx context.Context, url *neturl.URL) (*fetchResponse, error) {
+	ref, err := parseImageReference(url.Host + url.Path)
+	if err != nil {
+		return nil, newFetchError("Invalid URL", "%s", err)
+	}
+
+	files, err := f.pullArtifact(ctx, ref)
+	if err != nil {
+		return nil, newFetchError("Error pulling artifact", "%s", err)
+	}
+
+	return &fetchResponse{url: url.String(), body: joinArchiveFiles(files), files: files}, nil
+}
+
+// Retrieves the manifest files contained in the layers of the artifact. Layers which are tarballs have their
+// manifest files extracted in lexical order, while all other layers are treated as a single file.
+func (f *fetcher) pullArtifact(ctx context.Context, ref imageReference) ([]archiveFile, error) {
+	registry := f.registryClient()
+
+	raw, _, err := registry.manifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest for %s: %w", ref, err)
+	}
+	if len(manifest.Manifests) > 0 {
+		return nil, fmt.Errorf("%s is an index rather than a single artifact", ref)
+	}
+
+	var files []archiveFile
+	for _, layer := range manifest.Layers {
+		content, err := registry.blob(ctx, ref, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(layer.MediaType, "tar") {
+			extracted, err := extractTar(content, strings.Contains(layer.MediaType, "gzip") || isGzip(content), hasManifestExtension)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract layer %s: %w", layer.Digest, err)
+			}
+
+			files = append(files, extracted...)
+		} else {
+			name := layer.Annotations[annotationTitle]
+			if name == "" {
+				name = layer.Digest
+			}
+			files = append(files, archiveFile{name: name, content: content})
+		}
+	}
+
+	return files, nil
+}
+
+// The client for pulling from registries, shared across the fetcher
+func (f *fetcher) registryClient() *registryClient {
+	f.registryOnce.Do(func() {
+		var credentials map[string]registryCredentials
+		if f.provider != nil {
+			credentials = f.provider.registryCredentials
+		}
+
+		f.registry = newRegistryClient(f.provider.baseTransport(), credentials)
+	})
+
+	return f.registry
+}
+
+func sha256Hex(content []byte) string {
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:])
+}
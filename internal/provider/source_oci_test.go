@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_OCI_ProviderCredentials(t *testing.T) {
+	registry := setupMockRegistry(t)
+	defer registry.Close()
+
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(ociResourceStatement, host, host+"/example/manifests:v1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.1", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", multipleDocument3),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.%", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests_by_file.single.yaml.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_OCI_DockerConfig(t *testing.T) {
+	registry := setupMockRegistry(t)
+	defer registry.Close()
+
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	dir := t.TempDir()
+	config := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, base64.StdEncoding.EncodeToString([]byte("user:secret")))
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(unfilteredResourceStatement, "oci://"+host, "example/manifests:v1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_OCI_Unauthorized(t *testing.T) {
+	registry := setupMockRegistry(t)
+	defer registry.Close()
+
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(unfilteredResourceStatement, "oci://"+strings.TrimPrefix(registry.URL, "http://"), "example/manifests:v1"),
+				ExpectError: regexp.MustCompile("failed to retrieve token"),
+			},
+		},
+	})
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := map[string]imageReference{
+		"nginx":                              {registry: "docker.io", repository: "library/nginx", tag: "latest"},
+		"bitnami/redis:7.0":                  {registry: "docker.io", repository: "bitnami/redis", tag: "7.0"},
+		"ghcr.io/example/manifests:v1":       {registry: "ghcr.io", repository: "example/manifests", tag: "v1"},
+		"localhost:5000/manifests":           {registry: "localhost:5000", repository: "manifests", tag: "latest"},
+		"quay.io/example/app@sha256:abc":     {registry: "quay.io", repository: "example/app", digest: "sha256:abc"},
+		"quay.io/example/app:1.0@sha256:abc": {registry: "quay.io", repository: "example/app", tag: "1.0", digest: "sha256:abc"},
+	}
+
+	for raw, expected := range tests {
+		actual, err := parseImageReference(raw)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", raw, err)
+		} else if actual != expected {
+			t.Errorf("%s: expected %+v, got %+v", raw, expected, actual)
+		}
+	}
+}
+
+// A registry serving an artifact with a raw YAML layer and a gzipped tarball layer, requiring token authentication
+func setupMockRegistry(t *testing.T) *httptest.Server {
+	raw := []byte(singleDocument)
+	archive := mustTarGz(t, map[string]string{
+		"b.yaml":    multipleDocument3,
+		"a.yaml":    multipleDocument1,
+		"README.md": "# Not a manifest\n",
+	})
+
+	blobs := map[string][]byte{
+		"sha256:" + sha256Hex(raw):     raw,
+		"sha256:" + sha256Hex(archive): archive,
+	}
+
+	manifest, _ := json.Marshal(ociManifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    ociDescriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", Size: 2},
+		Layers: []ociDescriptor{
+			{MediaType: "application/yaml", Digest: "sha256:" + sha256Hex(raw), Size: int64(len(raw)), Annotations: map[string]string{annotationTitle: "single.yaml"}},
+			{MediaType: "application/vnd.cncf.flux.content.v1.tar+gzip", Digest: "sha256:" + sha256Hex(archive), Size: int64(len(archive))},
+		},
+	})
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token": "registry-token"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:example/manifests:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/example/manifests/manifests/v1":
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			_, _ = w.Write(manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/example/manifests/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/example/manifests/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func mustTarGz(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(gz)
+
+	for name, content := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+const ociResourceStatement = `
+provider "manifest" {
+	registry_auth {
+		address  = "%s"
+		username = "user"
+		password = "secret"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "oci://%s"
+}
+`
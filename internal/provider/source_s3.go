@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"io"
+	neturl "net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// The region used when none is configured by the URL or the environment
+const defaultS3Region = "us-east-1"
+
+// Retrieves the object referenced by an s3://bucket/key URL, using the default AWS credential chain. The region,
+// endpoint, and version of the object can be set with the `region`, `endpoint`, and `version_id` query parameters.
+func (f *fetcher) fetchS3(ctx context.Context, url *neturl.URL) (*fetchResponse, error) {
+	bucket, key := url.Host, strings.TrimPrefix(url.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, newFetchError("Invalid URL", "S3 URLs must be in the format s3://bucket/key, got %q", url.String())
+	}
+
+	query := url.Query()
+
+	// The SDK's own HTTP client is used since it is required to honor AWS_CA_BUNDLE
+	var options []func(*config.LoadOptions) error
+	if region := query.Get("region"); region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, newFetchError("Error loading AWS configuration", "%s", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := query.Get("endpoint"); endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version := query.Get("version_id"); version != "" {
+		input.VersionId = aws.String(version)
+	}
+
+	output, err := client.GetObject(ctx, input)
+	if err != nil {
+		return nil, newFetchError("Error retrieving object", "%s", err)
+	}
+	defer output.Body.Close()
+
+	body, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, newFetchError("Error reading object", "%s", err)
+	}
+
+	return &fetchResponse{url: url.String(), body: body}, nil
+}
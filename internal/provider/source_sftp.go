@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	neturl "net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// How long to wait for the SSH connection to be established
+const sftpDialTimeout = 30 * time.Second
+
+type sftpCredentials struct {
+	username   string
+	password   string
+	privateKey string
+	hostKey    string
+}
+
+// Retrieves the file referenced by an sftp://[user[:password]@]host[:port]/path URL. The credentials and host key are
+// looked up from the provider configuration, falling back to the SSH agent and ~/.ssh/known_hosts respectively.
+func (f *fetcher) fetchSFTP(ctx context.Context, url *neturl.URL) (*fetchResponse, error) {
+	if url.Hostname() == "" || url.Path == "" {
+		return nil, newFetchError("Invalid URL", "SFTP URLs must be in the format sftp://host/path, got %q", url.Redacted())
+	}
+
+	address := url.Host
+	if url.Port() == "" {
+		address = net.JoinHostPort(url.Hostname(), "22")
+	}
+
+	credentials := f.provider.lookupSFTPCredentials(url)
+	config, err := sftpClientConfig(url, credentials)
+	if err != nil {
+		return nil, newFetchError("Error configuring SSH", "%s", err)
+	}
+
+	dialer := net.Dialer{Timeout: sftpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, newFetchError("Error connecting to server", "%s", err)
+	}
+	defer conn.Close()
+
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		return nil, newFetchError("Error connecting to server", "%s", err)
+	}
+	sshClient := ssh.NewClient(sshConn, channels, requests)
+	defer sshClient.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return nil, newFetchError("Error starting SFTP session", "%s", err)
+	}
+	defer client.Close()
+
+	file, err := client.Open(url.Path)
+	if err != nil {
+		return nil, newFetchError("Error retrieving file", "%s", err)
+	}
+	defer file.Close()
+
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return nil, newFetchError("Error reading file", "%s", err)
+	}
+
+	return &fetchResponse{url: url.Redacted(), body: body}, nil
+}
+
+// Builds the SSH configuration, preferring the credentials in the URL over those from the provider configuration
+func sftpClientConfig(url *neturl.URL, credentials sftpCredentials) (*ssh.ClientConfig, error) {
+	username := credentials.username
+	if url.User != nil && url.User.Username() != "" {
+		username = url.User.Username()
+	}
+	if username == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("no username was provided: %w", err)
+		}
+		username = current.Username
+	}
+
+	var methods []ssh.AuthMethod
+	if credentials.privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(credentials.privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	password := credentials.password
+	if url.User != nil {
+		if urlPassword, ok := url.User.Password(); ok {
+			password = urlPassword
+		}
+	}
+	if password != "" {
+		methods = append(methods, ssh.Password(password))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("no password, private key, or SSH agent is available to authenticate with")
+	}
+
+	hostKeyCallback, err := sftpHostKeyCallback(credentials.hostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	}, nil
+}
+
+// Verifies the server against the configured host key, or ~/.ssh/known_hosts when none is configured
+func sftpHostKeyCallback(hostKey string) (ssh.HostKeyCallback, error) {
+	if hostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host key: %w", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("no host key was provided: %w", err)
+	}
+
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("no host key was provided and known hosts could not be read: %w", err)
+	}
+	return callback, nil
+}
+
+// Finds the credentials configured for the host and port of the URL, then for the host alone
+func (d *providerData) lookupSFTPCredentials(url *neturl.URL) sftpCredentials {
+	if d == nil {
+		return sftpCredentials{}
+	}
+
+	if credentials, ok := d.sftpCredentials[url.Host]; ok {
+		return credentials
+	}
+	return d.sftpCredentials[url.Hostname()]
+}
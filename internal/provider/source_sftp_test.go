@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestDataSource_SFTP_Password(t *testing.T) {
+	server := setupMockSFTP(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(sftpPasswordResourceStatement, server.address, server.hostKey, server.address, server.dir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_SFTP_PrivateKey(t *testing.T) {
+	server := setupMockSFTP(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(sftpPrivateKeyResourceStatement, server.address, server.clientKey, server.hostKey, server.address, server.dir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_SFTP_HostKeyMismatch(t *testing.T) {
+	server := setupMockSFTP(t)
+	other := setupMockSFTP(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(sftpPasswordResourceStatement, server.address, other.hostKey, server.address, server.dir),
+				ExpectError: regexp.MustCompile(`host\s+key\s+mismatch`),
+			},
+		},
+	})
+}
+
+type mockSFTPServer struct {
+	address   string
+	dir       string
+	hostKey   string
+	clientKey string
+}
+
+// An SFTP server serving a temporary directory containing multiple.yaml, accepting either the password "secret" or
+// the generated client key for the user "manifests"
+func setupMockSFTP(t *testing.T) mockSFTPServer {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, hostPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientDER, err := x509.MarshalECPrivateKey(clientPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizedKey, _ := ssh.NewPublicKey(&clientPrivate.PublicKey)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "manifests" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid password")
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "manifests" && string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "multiple.yaml"), []byte(multipleDocuments), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	return mockSFTPServer{
+		address:   listener.Addr().String(),
+		dir:       filepath.ToSlash(dir),
+		hostKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))),
+		clientKey: strings.ReplaceAll(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientDER})), "\n", `\n`),
+	}
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for request := range requests {
+				_ = request.Reply(request.Type == "subsystem" && string(request.Payload[4:]) == "sftp", nil)
+			}
+		}()
+
+		server, err := sftp.NewServer(channel, sftp.ReadOnly())
+		if err != nil {
+			return
+		}
+		_ = server.Serve()
+		server.Close()
+	}
+}
+
+const sftpPasswordResourceStatement = `
+provider "manifest" {
+	sftp_auth {
+		host     = "%s"
+		username = "manifests"
+		password = "secret"
+		host_key = "%s"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "sftp://%s%s/multiple.yaml"
+}
+`
+
+const sftpPrivateKeyResourceStatement = `
+provider "manifest" {
+	sftp_auth {
+		host        = "%s"
+		private_key = "%s"
+		host_key    = "%s"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "sftp://manifests@%s%s/multiple.yaml"
+}
+`
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Units accepted by sizes such as the spill threshold. Binary units are checked before decimal ones, and bytes last,
+// so the longest matching suffix is used.
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// Parses a size in bytes with an optional unit suffix, such as `512MiB` or `1GB`
+func parseByteSize(raw string) (int64, error) {
+	number, multiplier := strings.TrimSpace(raw), int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%q is not a size, such as 256MiB", raw)
+	}
+
+	return value * multiplier, nil
+}
+
+// Where and when decoded documents are spilled to disk
+type spillOptions struct {
+	threshold int64
+	dir       string
+}
+
+// Holds the decoded documents of a fetch, writing them to a temporary file once those in memory exceed the threshold.
+// A nil spool keeps every document in memory.
+type documentSpool struct {
+	options spillOptions
+
+	mu       sync.Mutex
+	inMemory int64
+	file     *os.File
+	size     int64
+}
+
+// A reference to a document held by a spool
+type documentRef struct {
+	value string
+
+	spilled        bool
+	offset, length int64
+}
+
+func newDocumentSpool(options *spillOptions) *documentSpool {
+	if options == nil {
+		return nil
+	}
+
+	return &documentSpool{options: *options}
+}
+
+// Stores the document, returning a reference to read it back with
+func (s *documentSpool) add(document string) (documentRef, error) {
+	if s == nil {
+		return documentRef{value: document}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	length := int64(len(document))
+	if s.file == nil && s.inMemory+length <= s.options.threshold {
+		s.inMemory += length
+		return documentRef{value: document}, nil
+	}
+
+	if s.file == nil {
+		file, err := os.CreateTemp(s.options.dir, "manifest-spill-*")
+		if err != nil {
+			return documentRef{}, fmt.Errorf("failed to create spill file: %w", err)
+		}
+		s.file = file
+	}
+
+	if _, err := s.file.WriteAt([]byte(document), s.size); err != nil {
+		return documentRef{}, fmt.Errorf("failed to spill document: %w", err)
+	}
+
+	ref := documentRef{spilled: true, offset: s.size, length: length}
+	s.size += length
+	return ref, nil
+}
+
+// Reads back a document stored by the spool
+func (s *documentSpool) read(ref documentRef) (string, error) {
+	if !ref.spilled {
+		return ref.value, nil
+	}
+
+	document := make([]byte, ref.length)
+	if _, err := s.file.ReadAt(document, ref.offset); err != nil {
+		return "", fmt.Errorf("failed to read spilled document: %w", err)
+	}
+
+	return string(document), nil
+}
+
+// Removes the spill file, if any
+func (s *documentSpool) close() {
+	if s == nil || s.file == nil {
+		return
+	}
+
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
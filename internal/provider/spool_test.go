@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Spill(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(spillResourceStatement, "0B", t.TempDir(), server.URL, "multiple"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "3"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", multipleDocument1),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.2", multipleDocument3),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_Spill_InvalidThreshold(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(spillResourceStatement, "lots", t.TempDir(), "https://example.com", "manifest.yaml"),
+				ExpectError: regexp.MustCompile(`Invalid spill threshold: "lots" is not a size`),
+			},
+		},
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":      0,
+		"512B":   512,
+		"2KB":    2000,
+		"2KiB":   2048,
+		"256MiB": 256 << 20,
+		"1 GB":   1000 * 1000 * 1000,
+	}
+
+	for raw, expected := range tests {
+		actual, err := parseByteSize(raw)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", raw, err)
+		} else if actual != expected {
+			t.Errorf("%s: expected %d, got %d", raw, expected, actual)
+		}
+	}
+
+	for _, raw := range []string{"", "MiB", "-1B", "1.5GiB"} {
+		if _, err := parseByteSize(raw); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+const spillResourceStatement = `
+provider "manifest" {
+	spill {
+		threshold = "%s"
+		dir       = "%s"
+	}
+}
+
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+}
+`
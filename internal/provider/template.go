@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+var templateBlock = tfsdk.Block{
+	MarkdownDescription: "Render the fetched content as a Go [text/template](https://pkg.go.dev/text/template) before it is decoded, so parameterized manifests can be consumed directly. The [Sprig](https://masterminds.github.io/sprig/) functions are available, except `env` and `expandenv`, along with `toYaml` and `fromYaml`, as they are in Helm charts. Each file of an archive, repository, or artifact is rendered separately. Templates are rendered before `variables` are substituted.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"enabled": {
+			MarkdownDescription: "Whether the content is rendered. Defaults to `true`.",
+			Type:                types.BoolType,
+			Optional:            true,
+		},
+		"vars": {
+			MarkdownDescription: "The values available to the template, referenced by their key (e.g. `{{ .namespace }}`). Keys which aren't set are empty, so a fallback can be provided with `default` (e.g. `{{ .namespace | default \"default\" }}`).",
+			Type: types.MapType{
+				ElemType: types.StringType,
+			},
+			Optional: true,
+		},
+	},
+}
+
+type templateModel struct {
+	Enabled types.Bool `tfsdk:"enabled"`
+	Vars    types.Map  `tfsdk:"vars"`
+}
+
+// The functions available to templates. Like Helm, the Sprig functions reading the environment are removed, so
+// rendering doesn't depend on where Terraform runs.
+var templateFuncs = func() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+
+	funcs["toYaml"] = func(value any) string {
+		encoded, err := yaml.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(string(encoded), "\n")
+	}
+	funcs["fromYaml"] = func(value string) map[string]any {
+		decoded := map[string]any{}
+		if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded["Error"] = err.Error()
+		}
+		return decoded
+	}
+	return funcs
+}()
+
+// Renders the fetched content as a template with the values
+type templateRenderer struct {
+	vars map[string]string
+}
+
+// Compiles the template block into a renderer. A nil renderer is returned when the block is unset or disabled.
+func compileTemplateRenderer(ctx context.Context, models []templateModel) (*templateRenderer, diag.Diagnostics) {
+	if len(models) == 0 || (!models[0].Enabled.Null && !models[0].Enabled.Value) {
+		return nil, nil
+	}
+
+	vars := map[string]string{}
+	diags := models[0].Vars.ElementsAs(ctx, &vars, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+	return &templateRenderer{vars: vars}, diags
+}
+
+// Renders the content, named by the file it was read from, if any
+func (r *templateRenderer) render(name string, content []byte) ([]byte, error) {
+	if r == nil {
+		return content, nil
+	}
+	if name == "" {
+		name = "manifest"
+	}
+
+	parsed, err := template.New(name).Option("missingkey=zero").Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := parsed.Execute(&rendered, r.vars); err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Template(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(templateStatement, server.URL, true, "name"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"app\"\n")),
+			},
+			{
+				Config: fmt.Sprintf(templateStatement, server.URL, false, "name"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"{{ .name }}\"\n"),
+			},
+			{
+				Config: fmt.Sprintf(templateStatement, server.URL, true, "namespace"),
+				Check:  resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: \"\"\n"),
+			},
+		},
+	})
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	renderer := &templateRenderer{vars: map[string]string{"image": "nginx"}}
+	rendered, err := renderer.render("", []byte("image: {{ .image }}{{ if .image }}:latest{{ end }}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(rendered) != "image: nginx:latest" {
+		t.Errorf("expected the template to be rendered, got %q", rendered)
+	}
+
+	if rendered, _ := renderer.render("deploy.yaml", []byte("image: {{ .tag }}")); string(rendered) != "image: " {
+		t.Errorf("expected a missing value to be empty, got %q", rendered)
+	}
+	if _, err := renderer.render("deploy.yaml", []byte("image: {{ .image")); err == nil {
+		t.Error("expected an error for a malformed template")
+	}
+
+	rendered, err = renderer.render("", []byte(`{{ .missing | default "latest" }} {{ .image | b64enc }} {{ dict "a" 1 | toYaml }} {{ (fromYaml "b: 2").b }}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(rendered) != "latest bmdpbng= a: 1 2" {
+		t.Errorf("expected the functions to be available, got %q", rendered)
+	}
+	if _, err := renderer.render("", []byte(`{{ env "HOME" }}`)); err == nil {
+		t.Error("expected env to be unavailable")
+	}
+
+	var disabled *templateRenderer
+	if rendered, _ := disabled.render("", []byte("{{ .image }}")); string(rendered) != "{{ .image }}" {
+		t.Errorf("expected the content to be untouched, got %q", rendered)
+	}
+}
+
+const templateStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/template"
+
+	template {
+		enabled = %t
+		vars = {
+			%s = "app"
+		}
+	}
+}
+`
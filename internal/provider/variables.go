@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// A placeholder in the style of `envsubst`, such as `${NAME}`, optionally with a default used when the variable
+	// isn't set, such as `${NAME:-default}` or `${NAME:=default}`
+	variablePlaceholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::[-=]([^}]*))?\}`)
+	variableNamePattern        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Substitutes the values of variables for their placeholders within the fetched content
+type variableSubstituter struct {
+	values map[string]string
+}
+
+// Compiles the variables into a substituter, failing with the first malformed name. A nil substituter is returned when
+// there are no variables.
+func compileVariableSubstituter(values map[string]string) (*variableSubstituter, string, error) {
+	if len(values) == 0 {
+		return nil, "", nil
+	}
+
+	for name := range values {
+		if !variableNamePattern.MatchString(name) {
+			return nil, name, fmt.Errorf("%q must only contain letters, digits, and underscores, and can't start with a digit", name)
+		}
+	}
+	return &variableSubstituter{values: values}, "", nil
+}
+
+// Replaces the placeholders within the content with the values of their variables, or their defaults when the
+// variables aren't set. Placeholders of variables which aren't set and have no default are left untouched, so
+// unrelated placeholders, such as those of embedded shell scripts, are preserved.
+func (s *variableSubstituter) substitute(content []byte) []byte {
+	if s == nil {
+		return content
+	}
+
+	return variablePlaceholderPattern.ReplaceAllFunc(content, func(placeholder []byte) []byte {
+		match := variablePlaceholderPattern.FindSubmatchIndex(placeholder)
+		if value, ok := s.values[string(placeholder[match[2]:match[3]])]; ok {
+			return []byte(value)
+		}
+		if match[4] != -1 {
+			return placeholder[match[4]:match[5]]
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_Variables(t *testing.T) {
+	server := setupMockServer()
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(variablesStatement, server.URL, "NAME", "app"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: default\ndata:\n  script: echo $HOME ${HOME}\n")),
+			},
+			{
+				Config:      fmt.Sprintf(variablesStatement, server.URL, "1NAME", "app"),
+				ExpectError: regexp.MustCompile(`Invalid variable`),
+			},
+		},
+	})
+}
+
+func TestVariableSubstituter(t *testing.T) {
+	substituter, _, err := compileVariableSubstituter(map[string]string{"IMAGE": "nginx", "TAG": "1.25", "EMPTY": ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := map[string]string{
+		"image: ${IMAGE}:${TAG}":           "image: nginx:1.25",
+		"replicas: ${REPLICAS:-2}":         "replicas: 2",
+		"replicas: ${REPLICAS:=3}":         "replicas: 3",
+		"image: ${IMAGE:-busybox}":         "image: nginx",
+		"value: '${EMPTY:-unset}'":         "value: ''",
+		"home: ${HOME} $IMAGE ${1INVALID}": "home: ${HOME} $IMAGE ${1INVALID}",
+	}
+	for content, expected := range tests {
+		if substituted := string(substituter.substitute([]byte(content))); substituted != expected {
+			t.Errorf("expected %q to become %q, got %q", content, expected, substituted)
+		}
+	}
+
+	if _, name, err := compileVariableSubstituter(map[string]string{"NAME-WITH-DASHES": ""}); err == nil || name != "NAME-WITH-DASHES" {
+		t.Errorf("expected the malformed name to be rejected, got %q: %v", name, err)
+	}
+}
+
+const variablesStatement = `
+data "manifest_fetch" "test" {
+	url       = "%s/templated"
+	variables = {
+		%q = %q
+	}
+}
+`
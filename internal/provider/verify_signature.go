@@ -0,0 +1,409 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Fulcio certificate extensions containing the OIDC issuer
+var (
+	oidcIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidcIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+var verifySignatureBlock = tfsdk.Block{
+	MarkdownDescription: "Verifies a [cosign](https://docs.sigstore.dev/cosign/overview/) signature of the fetched content before it is parsed. Either `public_key` must be set to verify a key-based signature, or `certificate_identity`, `certificate_oidc_issuer`, `certificate_chain`, and `rekor_public_key` must be set to verify a keyless signature.",
+	NestingMode:         tfsdk.BlockNestingModeList,
+	MaxItems:            1,
+	Attributes: map[string]tfsdk.Attribute{
+		"signature_url": {
+			MarkdownDescription: "The URL of the base64-encoded signature, as produced by `cosign sign-blob --output-signature`. Conflicts with `bundle_url`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"bundle_url": {
+			MarkdownDescription: "The URL of the signature bundle, as produced by `cosign sign-blob --bundle`. Required for keyless verification. Conflicts with `signature_url`.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+		"public_key": {
+			Description: "The PEM-encoded public key the content was signed with.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"certificate_identity": {
+			Description: "The identity (email or URI subject alternative name) that must be present in the signing certificate.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"certificate_oidc_issuer": {
+			Description: "The OIDC issuer that must be present in the signing certificate.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"certificate_chain": {
+			Description: "The PEM-encoded certificate authorities trusted to issue signing certificates, such as the Fulcio root and intermediate certificates.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"rekor_public_key": {
+			MarkdownDescription: "The PEM-encoded public key of the transparency log. When set, the signed entry timestamp in the bundle is verified and the time of inclusion is used to validate the signing certificate.",
+			Type:                types.StringType,
+			Optional:            true,
+		},
+	},
+}
+
+type verifySignatureModel struct {
+	SignatureURL          types.String `tfsdk:"signature_url"`
+	BundleURL             types.String `tfsdk:"bundle_url"`
+	PublicKey             types.String `tfsdk:"public_key"`
+	CertificateIdentity   types.String `tfsdk:"certificate_identity"`
+	CertificateOIDCIssuer types.String `tfsdk:"certificate_oidc_issuer"`
+	CertificateChain      types.String `tfsdk:"certificate_chain"`
+	RekorPublicKey        types.String `tfsdk:"rekor_public_key"`
+}
+
+// The bundle produced by `cosign sign-blob --bundle`
+type cosignBundle struct {
+	Base64Signature string       `json:"base64Signature"`
+	Cert            string       `json:"cert"`
+	RekorBundle     *rekorBundle `json:"rekorBundle"`
+}
+
+type rekorBundle struct {
+	SignedEntryTimestamp string       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// The fields are ordered such that they are marshaled as canonical JSON
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   string `json:"content"`
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// Verifies the content against the configured signature, returning an error describing why verification failed
+func verifySignature(ctx context.Context, fetcher *fetcher, config verifySignatureModel, content []byte) error {
+	hasSignature, hasBundle := isSet(config.SignatureURL), isSet(config.BundleURL)
+	if hasSignature == hasBundle {
+		return errors.New("exactly one of signature_url or bundle_url must be set")
+	}
+
+	keyless := isSet(config.CertificateIdentity) || isSet(config.CertificateOIDCIssuer) || isSet(config.CertificateChain)
+	if keyless == isSet(config.PublicKey) {
+		return errors.New("exactly one of public_key or the certificate_* attributes must be set")
+	}
+	if keyless && !(isSet(config.CertificateIdentity) && isSet(config.CertificateOIDCIssuer) && isSet(config.CertificateChain) && isSet(config.RekorPublicKey)) {
+		return errors.New("certificate_identity, certificate_oidc_issuer, certificate_chain, and rekor_public_key are all required for keyless verification")
+	}
+	if keyless && !hasBundle {
+		return errors.New("bundle_url is required for keyless verification")
+	}
+
+	var signature []byte
+	var bundle cosignBundle
+	if hasSignature {
+		raw, err := fetcher.fetchURL(ctx, config.SignatureURL.Value)
+		if err != nil {
+			return fmt.Errorf("failed to fetch signature: %w", err)
+		}
+
+		signature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return fmt.Errorf("failed to decode signature: %w", err)
+		}
+	} else {
+		raw, err := fetcher.fetchURL(ctx, config.BundleURL.Value)
+		if err != nil {
+			return fmt.Errorf("failed to fetch bundle: %w", err)
+		}
+
+		if err := json.Unmarshal(raw, &bundle); err != nil {
+			return fmt.Errorf("failed to parse bundle: %w", err)
+		}
+
+		signature, err = base64.StdEncoding.DecodeString(bundle.Base64Signature)
+		if err != nil {
+			return fmt.Errorf("failed to decode signature: %w", err)
+		}
+	}
+
+	// The time at which the signing certificate must have been valid, and the key the transparency log entry was
+	// made for
+	signedAt := time.Now()
+	var entryKey crypto.PublicKey
+	if isSet(config.RekorPublicKey) {
+		if !hasBundle || bundle.RekorBundle == nil {
+			return errors.New("a bundle containing a transparency log entry is required when rekor_public_key is set")
+		}
+
+		integratedAt, key, err := verifyRekorBundle(config.RekorPublicKey.Value, bundle.RekorBundle, content, signature)
+		if err != nil {
+			return err
+		}
+		signedAt, entryKey = integratedAt, key
+	}
+
+	var publicKey crypto.PublicKey
+	if keyless {
+		certificate, err := verifyCertificate(config, bundle.Cert, signedAt)
+		if err != nil {
+			return err
+		}
+		publicKey = certificate.PublicKey
+	} else {
+		var err error
+		publicKey, err = parsePublicKey(config.PublicKey.Value)
+		if err != nil {
+			return fmt.Errorf("invalid public key: %w", err)
+		}
+	}
+
+	// Otherwise, an entry logged for any other key would vouch for the signature
+	if entryKey != nil && !equalPublicKeys(entryKey, publicKey) {
+		return errors.New("transparency log entry does not match the signing key")
+	}
+
+	return verifyBlob(publicKey, content, signature)
+}
+
+// Ensures the signed entry timestamp was issued by the transparency log and that the entry matches the content,
+// returning the time the entry was integrated and the public key it was made for
+func verifyRekorBundle(rawPublicKey string, bundle *rekorBundle, content, signature []byte) (time.Time, crypto.PublicKey, error) {
+	publicKey, err := parsePublicKey(rawPublicKey)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid rekor public key: %w", err)
+	}
+
+	timestamp, err := base64.StdEncoding.DecodeString(bundle.SignedEntryTimestamp)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to decode signed entry timestamp: %w", err)
+	}
+
+	payload, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if err := verifyBlob(publicKey, payload, timestamp); err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid signed entry timestamp: %w", err)
+	}
+
+	rawBody, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to decode transparency log entry: %w", err)
+	}
+
+	var entry hashedRekord
+	if err := json.Unmarshal(rawBody, &entry); err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to parse transparency log entry: %w", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return time.Time{}, nil, fmt.Errorf("unsupported transparency log entry kind %q", entry.Kind)
+	}
+
+	digest := sha256.Sum256(content)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(digest[:]) {
+		return time.Time{}, nil, errors.New("transparency log entry does not match the content")
+	}
+	if entry.Spec.Signature.Content != base64.StdEncoding.EncodeToString(signature) {
+		return time.Time{}, nil, errors.New("transparency log entry does not match the signature")
+	}
+
+	entryKey, err := parseEntryPublicKey(entry.Spec.Signature.PublicKey.Content)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid transparency log entry public key: %w", err)
+	}
+
+	return time.Unix(bundle.Payload.IntegratedTime, 0), entryKey, nil
+}
+
+// Extracts the public key from the base64-encoded PEM of a transparency log entry, which is either the signing
+// certificate or the public key itself
+func parseEntryPublicKey(content string) (crypto.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type == "CERTIFICATE" {
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Whether the public keys are the same key
+func equalPublicKeys(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// Ensures the signing certificate chains to a trusted authority and was issued to the expected identity
+func verifyCertificate(config verifySignatureModel, rawCertificate string, at time.Time) (*x509.Certificate, error) {
+	if !strings.HasPrefix(strings.TrimSpace(rawCertificate), "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(rawCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode certificate: %w", err)
+		}
+		rawCertificate = string(decoded)
+	}
+
+	block, _ := pem.Decode([]byte(rawCertificate))
+	if block == nil {
+		return nil, errors.New("bundle does not contain a signing certificate")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	rest := []byte(config.CertificateChain.Value)
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		authority, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate chain: %w", err)
+		}
+
+		if bytes.Equal(authority.RawIssuer, authority.RawSubject) {
+			roots.AddCert(authority)
+		} else {
+			intermediates.AddCert(authority)
+		}
+	}
+
+	_, err = certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted signing certificate: %w", err)
+	}
+
+	identity := config.CertificateIdentity.Value
+	if !contains(certificate.EmailAddresses, identity) {
+		var uris []string
+		for _, uri := range certificate.URIs {
+			uris = append(uris, uri.String())
+		}
+
+		if !contains(uris, identity) {
+			return nil, fmt.Errorf("signing certificate was not issued to %q", identity)
+		}
+	}
+
+	if issuer := certificateIssuer(certificate); issuer != config.CertificateOIDCIssuer.Value {
+		return nil, fmt.Errorf("signing certificate was issued by %q, expected %q", issuer, config.CertificateOIDCIssuer.Value)
+	}
+
+	return certificate, nil
+}
+
+// Extracts the OIDC issuer from a Fulcio certificate
+func certificateIssuer(certificate *x509.Certificate) string {
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidcIssuerV2) {
+			var issuer string
+			if _, err := asn1.Unmarshal(extension.Value, &issuer); err == nil {
+				return issuer
+			}
+		}
+	}
+
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidcIssuerV1) {
+			return string(extension.Value)
+		}
+	}
+
+	return ""
+}
+
+func parsePublicKey(raw string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Verifies the signature over the content in the same manner as cosign
+func verifyBlob(publicKey crypto.PublicKey, content, signature []byte) error {
+	digest := sha256.Sum256(content)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, content, signature) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	return nil
+}
+
+func isSet(value types.String) bool {
+	return !value.Null && !value.Unknown && value.Value != ""
+}
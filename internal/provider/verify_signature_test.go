@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDataSource_VerifySignature_PublicKey(t *testing.T) {
+	signer := newTestSigner(t)
+	server := setupSignedServer(t, signer)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(publicKeySignatureStatement, server.URL, "single", server.URL, "single.sig", signer.publicKeyPEM),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_VerifySignature_PublicKey_Mismatch(t *testing.T) {
+	signer := newTestSigner(t)
+	server := setupSignedServer(t, signer)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(publicKeySignatureStatement, server.URL, "multiple", server.URL, "single.sig", signer.publicKeyPEM),
+				ExpectError: regexp.MustCompile("Signature verification failed: invalid signature"),
+			},
+		},
+	})
+}
+
+func TestDataSource_VerifySignature_Keyless(t *testing.T) {
+	signer := newTestSigner(t)
+	server := setupSignedServer(t, signer)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(keylessSignatureStatement, server.URL, server.URL, testSignerIdentity, signer.caPEM, signer.rekorPublicKeyPEM),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.#", "1"),
+					resource.TestCheckResourceAttr("data.manifest_fetch.test", "manifests.0", singleDocument),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSource_VerifySignature_Keyless_WrongIdentity(t *testing.T) {
+	signer := newTestSigner(t)
+	server := setupSignedServer(t, signer)
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(keylessSignatureStatement, server.URL, server.URL, "https://example.com/someone-else", signer.caPEM, signer.rekorPublicKeyPEM),
+				ExpectError: regexp.MustCompile("signing certificate was not issued to"),
+			},
+		},
+	})
+}
+
+func TestVerifySignature_RekorEntryKey(t *testing.T) {
+	signer := newTestSigner(t)
+	server := setupSignedServer(t, signer)
+	defer server.Close()
+
+	for bundle, expected := range map[string]string{
+		"single.bundle":     "",
+		"mismatched.bundle": "transparency log entry does not match the signing key",
+	} {
+		config := verifySignatureModel{
+			BundleURL:             types.String{Value: server.URL + "/" + bundle},
+			CertificateIdentity:   types.String{Value: testSignerIdentity},
+			CertificateOIDCIssuer: types.String{Value: testSignerIssuer},
+			CertificateChain:      types.String{Value: signer.caPEM},
+			RekorPublicKey:        types.String{Value: signer.rekorPublicKeyPEM},
+		}
+
+		err := verifySignature(context.Background(), newFetcher(nil), config, []byte(singleDocument))
+		if expected == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", bundle, err)
+		} else if expected != "" && (err == nil || err.Error() != expected) {
+			t.Errorf("%s: expected %q, got %v", bundle, expected, err)
+		}
+	}
+}
+
+const testSignerIdentity = "https://github.com/example/project/.github/workflows/release.yaml@refs/heads/main"
+const testSignerIssuer = "https://token.actions.githubusercontent.com"
+
+type testSigner struct {
+	publicKeyPEM      string
+	signature         string
+	caPEM             string
+	rekorPublicKeyPEM string
+	bundle            []byte
+	// A bundle whose transparency log entry was made for a different key than the signing certificate's
+	mismatchedBundle []byte
+}
+
+// Signs the single document with a static key and produces a keyless bundle with an ephemeral certificate
+func newTestSigner(t *testing.T) *testSigner {
+	content := []byte(singleDocument)
+	digest := sha256.Sum256(content)
+
+	key := mustGenerateKey(t)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedAt := time.Now().Add(-time.Hour)
+
+	caKey := mustGenerateKey(t)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             signedAt.Add(-24 * time.Hour),
+		NotAfter:              signedAt.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	identity, _ := url.Parse(testSignerIdentity)
+	issuer, _ := asn1.Marshal(testSignerIssuer)
+	leafKey := mustGenerateKey(t)
+	leafTemplate := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       signedAt.Add(-time.Minute),
+		NotAfter:        signedAt.Add(10 * time.Minute),
+		URIs:            []*url.URL{identity},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: oidcIssuerV2, Value: issuer}},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafSignature, err := ecdsa.SignASN1(rand.Reader, leafKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rekorKey := mustGenerateKey(t)
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+
+	// Logs the signature for the key in the PEM, producing a bundle of the signature and the entry
+	logSignature := func(entryKeyPEM []byte) []byte {
+		entry, _ := json.Marshal(map[string]any{
+			"apiVersion": "0.0.1",
+			"kind":       "hashedrekord",
+			"spec": map[string]any{
+				"data": map[string]any{
+					"hash": map[string]any{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])},
+				},
+				"signature": map[string]any{
+					"content":   base64.StdEncoding.EncodeToString(leafSignature),
+					"publicKey": map[string]any{"content": base64.StdEncoding.EncodeToString(entryKeyPEM)},
+				},
+			},
+		})
+		payload := rekorPayload{
+			Body:           base64.StdEncoding.EncodeToString(entry),
+			IntegratedTime: signedAt.Unix(),
+			LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+			LogIndex:       1,
+		}
+		canonical, _ := json.Marshal(payload)
+		payloadDigest := sha256.Sum256(canonical)
+
+		timestamp, err := ecdsa.SignASN1(rand.Reader, rekorKey, payloadDigest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bundle, _ := json.Marshal(cosignBundle{
+			Base64Signature: base64.StdEncoding.EncodeToString(leafSignature),
+			Cert:            base64.StdEncoding.EncodeToString(certificatePEM),
+			RekorBundle: &rekorBundle{
+				SignedEntryTimestamp: base64.StdEncoding.EncodeToString(timestamp),
+				Payload:              payload,
+			},
+		})
+		return bundle
+	}
+
+	return &testSigner{
+		publicKeyPEM:      mustEncodePublicKey(t, &key.PublicKey),
+		signature:         base64.StdEncoding.EncodeToString(signature),
+		caPEM:             string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		rekorPublicKeyPEM: mustEncodePublicKey(t, &rekorKey.PublicKey),
+		bundle:            logSignature(certificatePEM),
+		mismatchedBundle:  logSignature([]byte(mustEncodePublicKey(t, &key.PublicKey))),
+	}
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustEncodePublicKey(t *testing.T, key *ecdsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func setupSignedServer(t *testing.T, signer *testSigner) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/single":
+			_, _ = w.Write([]byte(singleDocument))
+		case "/multiple":
+			_, _ = w.Write([]byte(multipleDocuments))
+		case "/single.sig":
+			_, _ = w.Write([]byte(signer.signature))
+		case "/single.bundle":
+			_, _ = w.Write(signer.bundle)
+		case "/mismatched.bundle":
+			_, _ = w.Write(signer.mismatchedBundle)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+const publicKeySignatureStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/%s"
+
+	verify_signature {
+		signature_url = "%s/%s"
+		public_key    = <<EOT
+%s
+EOT
+	}
+}
+`
+
+const keylessSignatureStatement = `
+data "manifest_fetch" "test" {
+	url = "%s/single"
+
+	verify_signature {
+		bundle_url              = "%s/single.bundle"
+		certificate_identity    = "%s"
+		certificate_oidc_issuer = "` + testSignerIssuer + `"
+		certificate_chain       = <<EOT
+%s
+EOT
+		rekor_public_key        = <<EOT
+%s
+EOT
+	}
+}
+`
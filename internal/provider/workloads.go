@@ -0,0 +1,56 @@
+package provider
+
+// The path of the pod template's spec within each kind of workload
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// Retrieves the pod spec of the workload, or nil if the manifest isn't a workload or doesn't have one
+func podSpec(manifest map[any]any) map[any]any {
+	path, ok := podSpecPaths[stringValue(manifest["kind"])]
+	if !ok {
+		return nil
+	}
+
+	spec, _ := lookupMap(manifest, path...)
+	return spec
+}
+
+// Retrieves the containers and init containers of the pod spec
+func podContainers(spec map[any]any) []map[any]any {
+	var containers []map[any]any
+	for _, field := range []string{"initContainers", "containers"} {
+		list, _ := spec[field].([]any)
+		for _, container := range list {
+			if container, ok := container.(map[any]any); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
+
+// Retrieves the map nested at the keys, and whether it exists
+func lookupMap(value map[any]any, keys ...string) (map[any]any, bool) {
+	for _, key := range keys {
+		nested, ok := value[key].(map[any]any)
+		if !ok {
+			return nil, false
+		}
+		value = nested
+	}
+	return value, true
+}
+
+// The value if it is a string, otherwise an empty string
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}